@@ -1,63 +1,110 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
 
-	"github.com/thornzero/udc_codec/pkg/aggregator"
+	"github.com/thornzero/udc_codec/pkg/config"
 	"github.com/thornzero/udc_codec/pkg/pipeline"
-	"github.com/thornzero/udc_codec/pkg/udc"
+	"github.com/thornzero/udc_codec/pkg/stages"
 )
 
 func main() {
-	// Load UDC codec
-	udcCodec, err := udc.LoadCodec("data/udc_full.yaml")
-	if err != nil {
-		log.Fatalf("UDC load failed: %v", err)
-	}
+	suggestMode := flag.String("suggest", "", "Suggest UDC codes for entries without one: propose or fill")
+	minScore := flag.String("min-score", "", "Minimum score for the suggest stage to fill a code (default 0.5)")
+	allocate := flag.Bool("allocate", false, "Assign loop numbers to entries without an equipment ID")
+	bomFile := flag.String("bom", "data/project_bom.yaml", "BOM to process: YAML, CSV or XLSX")
+	profileName := flag.String("profile", "", "Column-mapping profile for CSV and XLSX BOMs")
+	projectName := flag.String("project", "", "Project name for CSV and XLSX BOMs (default: the file name)")
+	warnings := flag.String("warnings", "", "Whether validation warnings block the export: allow or block (default: the BOM's warning_policy)")
+	definition := flag.String("pipeline", "", "Pipeline definition (default: data/"+pipeline.DefinitionFile+", or the built-in pipeline)")
+	streaming := flag.Bool("stream", false, "Process the BOM row by row with the built-in streaming pipeline, for BOMs too large to load whole")
+	workers := flag.Int("workers", 0, "Workers for the stream stage (default: one per CPU)")
+	formats := flag.String("formats", "", "Comma-separated export formats: taglist, loops, loops-markdown or a tag list format ("+strings.Join(pipeline.DefaultExporters().Names(), ", ")+")")
+	flag.Parse()
 
-	// Load Aggregator
-	agg, err := aggregator.LoadAggregatedDatabase("data/aggregated_master.yaml")
-	if err != nil {
-		log.Fatalf("Aggregator load failed: %v", err)
+	cfg := config.Load()
+	def, err := pipeline.LoadDefinitionDir(cfg.DataDir)
+	switch {
+	case *definition != "":
+		def, err = pipeline.LoadDefinition(*definition)
+	case *streaming:
+		def, err = pipeline.StreamDefinition(), nil
 	}
-
-	// Load Project BOM
-	bom, err := pipeline.LoadBOM("data/project_bom.yaml")
 	if err != nil {
-		log.Fatalf("BOM load failed: %v", err)
+		log.Fatalf("Pipeline load failed: %v", err)
 	}
 
-	// Prepare validator
-	validator := &pipeline.Validator{
-		Aggregator: agg,
-		UDC:        udcCodec,
+	// Flags override the settings of the stages of the same name
+	run := &pipeline.Run{
+		Project: *projectName,
+		Input:   *bomFile,
+		Params: map[string]string{
+			"import.profile":     *profileName,
+			"classify.mode":      *suggestMode,
+			"classify.min_score": *minScore,
+			"validate.warnings":  *warnings,
+			"stream.profile":     *profileName,
+			"stream.suggest":     *suggestMode,
+			"stream.min_score":   *minScore,
+			"stream.warnings":    *warnings,
+			"export.formats":     *formats,
+			"stream.formats":     *formats,
+		},
+	}
+	if *allocate {
+		run.Params["allocate.enabled"] = "true"
+	}
+	if *workers > 0 {
+		run.Params["stream.workers"] = strconv.Itoa(*workers)
 	}
 
-	// Full pipeline process
-	var exportRecords []pipeline.ExportRecord
-	for _, entry := range bom.Entries {
-		// Validate entry
-		if err := validator.ValidateEntry(entry); err != nil {
-			log.Fatalf("Validation failed for entry %+v: %v", entry, err)
-		}
-
-		tag := pipeline.GenerateFullTag(entry)
-		system := agg.LookupSystem(entry.SystemCode)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	runner := &pipeline.Runner{Stages: stages.New(stages.NewEnv(cfg)), Events: printEvent}
+	err = runner.Run(ctx, def, run)
 
-		exportRecords = append(exportRecords, pipeline.ExportRecord{
-			FullTag:     tag,
-			SystemName:  system.SystemName,
-			Description: entry.Description,
-			UDCCode:     entry.UDCCode,
-		})
+	if run.Validation != nil {
+		for _, issue := range run.Validation.Issues {
+			if issue.Severity == pipeline.SeverityInfo {
+				continue
+			}
+			mark := "❌"
+			if issue.Severity == pipeline.SeverityWarning {
+				mark = "⚠️ "
+			}
+			fmt.Printf("%s Row %d %s [%s] %s: %s\n", mark, issue.Row, issue.Field, issue.Rule, issue.Tag, issue.Message)
+		}
 	}
-
-	outputFile := fmt.Sprintf("data/%s_taglist.yaml", bom.ProjectName)
-	if err := pipeline.ExportTagList(exportRecords, outputFile); err != nil {
-		log.Fatalf("Export failed: %v", err)
+	if err != nil {
+		if report, ok := run.Artifact("validation-html"); ok && run.Validation != nil && run.Validation.Blocks() {
+			log.Fatalf("Validation failed: %s, see %s", run.Validation.Summary(), report)
+		}
+		log.Fatalf("Pipeline failed: %v", err)
 	}
 
 	fmt.Println("✅ Pipeline complete!")
-	fmt.Printf("Exported tag list to: %s\n", outputFile)
+	for _, a := range run.Artifacts {
+		fmt.Printf("Exported %s to: %s\n", a.Kind, a.Path)
+	}
+}
+
+// printEvent shows the progress of the run
+func printEvent(e pipeline.Event) {
+	switch e.Kind {
+	case pipeline.EventStarted, pipeline.EventDone, pipeline.EventArtifact:
+		return
+	case pipeline.EventWarning:
+		fmt.Printf("⚠️  %s\n", e.Message)
+	case pipeline.EventFailed:
+		fmt.Printf("❌ %s\n", e)
+	default:
+		fmt.Println(e)
+	}
 }
@@ -33,7 +33,7 @@ func main() {
 func GetTag(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	tag := vars["tag"]
-	record, err := store.LookupTag(tag)
+	record, err := store.ResolveTag(tag)
 	if err != nil {
 		http.Error(w, "Tag not found", http.StatusNotFound)
 		return
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/thornzero/udc_codec/pkg/udc"
+)
+
+// addendumResult reports the outcome of an addendum change
+type addendumResult struct {
+	File  string `json:"file" yaml:"file"`
+	Code  string `json:"code,omitempty" yaml:"code,omitempty"`
+	Title string `json:"title,omitempty" yaml:"title,omitempty"`
+}
+
+// addendumFilename applies the udc_addendum_*.yaml naming convention
+func addendumFilename(filename string) string {
+	if !strings.HasPrefix(filename, "udc_addendum_") {
+		filename = "udc_addendum_" + filename
+	}
+	if !strings.HasSuffix(filename, ".yaml") {
+		filename = filename + ".yaml"
+	}
+	return filename
+}
+
+func newAddendumCmd() *cobra.Command {
+	var addendumCmd = &cobra.Command{
+		Use:   "addendum",
+		Short: "Manage UDC addendum files",
+	}
+
+	var listAddendumsCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List all addendum files",
+		Run: func(cmd *cobra.Command, args []string) {
+			am := udc.NewAddendumManager(dataDir)
+			addendums, err := am.ListAddendums()
+			if err != nil {
+				exitWithError(1, "Error listing addendums", err)
+			}
+			if len(addendums) == 0 && outputFormat == outputTable {
+				printMessage("No addendum files found.")
+				return
+			}
+
+			results := make([]addendumResult, 0, len(addendums))
+			t := table{headers: []string{"file"}}
+			for _, addendum := range addendums {
+				results = append(results, addendumResult{File: addendum})
+				t.rows = append(t.rows, []string{addendum})
+			}
+			if err := printResult(results, t); err != nil {
+				exitWithError(1, "Error writing output", err)
+			}
+		},
+	}
+
+	var addAddendumCmd = &cobra.Command{
+		Use:   "add [code] [title] [filename]",
+		Short: "Add a classification to an addendum file (filename is optional)",
+		Args:  cobra.RangeArgs(2, 3),
+		Run: func(cmd *cobra.Command, args []string) {
+			code := args[0]
+			title := args[1]
+			var filename string
+			if len(args) == 3 {
+				filename = args[2]
+			}
+
+			node := &udc.Node{
+				Code:  code,
+				Title: title,
+			}
+
+			am := udc.NewAddendumManager(dataDir)
+			err := am.Add(filename, []*udc.Node{node})
+			if err != nil {
+				exitWithError(1, "Error adding to addendum", err)
+			}
+
+			// Determine the actual filename used
+			actualFilename := filename
+			if actualFilename == "" {
+				actualFilename = "default"
+			}
+			actualFilename = addendumFilename(actualFilename)
+
+			if outputFormat == outputTable {
+				printMessage("✅ Added classification to addendum file: %s", actualFilename)
+				return
+			}
+			result := addendumResult{File: actualFilename, Code: code, Title: title}
+			t := table{headers: []string{"file", "code", "title"}, rows: [][]string{{actualFilename, code, title}}}
+			if err := printResult(result, t); err != nil {
+				exitWithError(1, "Error writing output", err)
+			}
+		},
+	}
+
+	var deleteAddendumCmd = &cobra.Command{
+		Use:   "delete [filename]",
+		Short: "Delete an addendum file",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			filename := addendumFilename(args[0])
+
+			am := udc.NewAddendumManager(dataDir)
+			err := am.DeleteAddendum(filename)
+			if err != nil {
+				exitWithError(1, "Error deleting addendum", err)
+			}
+
+			if outputFormat == outputTable {
+				printMessage("✅ Deleted addendum file: %s", filename)
+				return
+			}
+			t := table{headers: []string{"file"}, rows: [][]string{{filename}}}
+			if err := printResult(addendumResult{File: filename}, t); err != nil {
+				exitWithError(1, "Error writing output", err)
+			}
+		},
+	}
+
+	addendumCmd.AddCommand(listAddendumsCmd)
+	addendumCmd.AddCommand(addAddendumCmd)
+	addendumCmd.AddCommand(deleteAddendumCmd)
+	return addendumCmd
+}
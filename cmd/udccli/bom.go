@@ -0,0 +1,234 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/thornzero/udc_codec/pkg/aggregator"
+	"github.com/thornzero/udc_codec/pkg/assettag"
+	"github.com/thornzero/udc_codec/pkg/bomimport"
+	"github.com/thornzero/udc_codec/pkg/pipeline"
+)
+
+// loadValidator loads the aggregated systems, classification schemes and
+// validation rules, exiting on failure
+func loadValidator() *pipeline.Validator {
+	agg, err := aggregator.LoadAggregatedDatabase(filepath.Join(dataDir, "aggregated_master.yaml"))
+	if err != nil {
+		exitWithError(1, "Error loading aggregated systems", err)
+	}
+	return &pipeline.Validator{
+		Aggregator: agg,
+		Schemes:    loadResolver().Schemes,
+		Rules:      loadRules(),
+	}
+}
+
+// loadProfiles loads the BOM column-mapping profiles, exiting on failure
+func loadProfiles() *bomimport.Profiles {
+	profiles, err := bomimport.LoadProfilesDir(dataDir)
+	if err != nil {
+		exitWithError(1, "Error loading BOM profiles", err)
+	}
+	return profiles
+}
+
+func newBOMCmd() *cobra.Command {
+	var bomCmd = &cobra.Command{
+		Use:   "bom",
+		Short: "Import BOMs and instrument indexes from CSV and XLSX",
+	}
+
+	var (
+		profileName string
+		projectName string
+		outFile     string
+	)
+	var importCmd = &cobra.Command{
+		Use:   "import <file>",
+		Short: "Read a CSV, XLSX or YAML BOM and report rows that cannot be imported",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			profile, err := loadProfiles().Get(profileName)
+			if err != nil {
+				exitWithError(1, "Error loading BOM profiles", err)
+			}
+			tagSchemes, err := assettag.LoadTagSchemesDir(dataDir)
+			if err != nil {
+				exitWithError(1, "Error loading tag schemes", err)
+			}
+			bom, report, err := bomimport.Load(args[0], bomimport.Options{Profile: profile, ProjectName: projectName, TagSchemes: tagSchemes})
+			if err != nil {
+				exitWithError(1, "Error importing BOM", err)
+			}
+
+			t := table{headers: []string{"row", "column", "value", "severity", "message"}}
+			for _, e := range report.Errors {
+				t.rows = append(t.rows, []string{strconv.Itoa(e.Row), e.Column, e.Value, "error", e.Message})
+			}
+			for _, e := range report.Warnings {
+				t.rows = append(t.rows, []string{strconv.Itoa(e.Row), e.Column, e.Value, "warning", e.Message})
+			}
+			if err := printResult(report, t); err != nil {
+				exitWithError(1, "Error writing output", err)
+			}
+			var columns []string
+			for field, h := range report.Columns {
+				columns = append(columns, field+"="+h)
+			}
+			sort.Strings(columns)
+			printMessage("\nHeader row %d, columns %s", report.HeaderRow, strings.Join(columns, ", "))
+			printMessage("%d of %d rows imported, %d errors, %d warnings", report.Imported, report.Rows, len(report.Errors), len(report.Warnings))
+
+			if outFile != "" {
+				if err := pipeline.ExportBOM(bom, outFile); err != nil {
+					exitWithError(1, "Error writing BOM", err)
+				}
+				printMessage("✅ Wrote %d entries to %s", len(bom.Entries), outFile)
+			}
+			if len(report.Errors) > 0 {
+				os.Exit(1)
+			}
+		},
+	}
+	importCmd.Flags().StringVar(&profileName, "profile", bomimport.DefaultProfileName, "Column-mapping profile from "+bomimport.ProfilesFile)
+	importCmd.Flags().StringVar(&projectName, "project", "", "Project name (default: the file name)")
+	importCmd.Flags().StringVar(&outFile, "out", "", "Write the imported rows as a YAML BOM")
+
+	var profilesCmd = &cobra.Command{
+		Use:   "profiles",
+		Short: "List the column-mapping profiles in " + bomimport.ProfilesFile,
+		Run: func(cmd *cobra.Command, args []string) {
+			profiles := loadProfiles()
+			var results []*bomimport.Profile
+			t := table{headers: []string{"name", "sheet", "encoding", "tag scheme", "description"}}
+			for _, name := range profiles.Names() {
+				p, _ := profiles.Get(name)
+				results = append(results, p)
+				t.rows = append(t.rows, []string{p.Name, p.Sheet, p.Encoding, p.TagScheme, p.Description})
+			}
+			if err := printResult(results, t); err != nil {
+				exitWithError(1, "Error writing output", err)
+			}
+		},
+	}
+
+	var warnings string
+	var validateCmd = &cobra.Command{
+		Use:   "validate <file>",
+		Short: "Check every row of a BOM, including the project rules, and report all issues",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			profile, err := loadProfiles().Get(profileName)
+			if err != nil {
+				exitWithError(1, "Error loading BOM profiles", err)
+			}
+			tagSchemes, err := assettag.LoadTagSchemesDir(dataDir)
+			if err != nil {
+				exitWithError(1, "Error loading tag schemes", err)
+			}
+			bom, report, err := bomimport.Load(args[0], bomimport.Options{Profile: profile, ProjectName: projectName, TagSchemes: tagSchemes})
+			if err != nil {
+				exitWithError(1, "Error importing BOM", err)
+			}
+			if err := report.Err(); err != nil {
+				exitWithError(1, "Error importing BOM", err)
+			}
+			if projectName != "" {
+				bom.ProjectName = projectName
+			}
+			if warnings != "" {
+				bom.WarningPolicy = warnings
+			}
+			tagScheme, err := tagSchemes.Get(bom.TagScheme)
+			if err != nil {
+				exitWithError(1, "Error loading tag schemes", err)
+			}
+
+			validation := loadValidator().Validate(bom, tagScheme)
+			t := table{headers: []string{"row", "tag", "field", "rule", "severity", "message", "fix"}}
+			for _, i := range validation.Issues {
+				t.rows = append(t.rows, []string{strconv.Itoa(i.Row), i.Tag, i.Field, i.Rule, string(i.Severity), i.Message, i.Fix})
+			}
+			if err := printResult(validation, t); err != nil {
+				exitWithError(1, "Error writing output", err)
+			}
+			printMessage("\n%d rows: %s", validation.Rows, validation.Summary())
+			if validation.Blocks() {
+				os.Exit(1)
+			}
+		},
+	}
+	validateCmd.Flags().StringVar(&profileName, "profile", bomimport.DefaultProfileName, "Column-mapping profile from "+bomimport.ProfilesFile)
+	validateCmd.Flags().StringVar(&projectName, "project", "", "Project name, selecting its rules (default: the BOM's project)")
+	validateCmd.Flags().StringVar(&warnings, "warnings", "", "Whether warnings fail the check: allow or block (default: the BOM's warning_policy)")
+
+	var diffCmd = &cobra.Command{
+		Use:   "diff <old> <new>",
+		Short: "List the entries added, removed, modified and renumbered between two BOM revisions",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			profile, err := loadProfiles().Get(profileName)
+			if err != nil {
+				exitWithError(1, "Error loading BOM profiles", err)
+			}
+			tagSchemes, err := assettag.LoadTagSchemesDir(dataDir)
+			if err != nil {
+				exitWithError(1, "Error loading tag schemes", err)
+			}
+			var revisions []*pipeline.ProjectBOM
+			for _, filename := range args {
+				bom, report, err := bomimport.Load(filename, bomimport.Options{Profile: profile, ProjectName: projectName, TagSchemes: tagSchemes})
+				if err == nil {
+					err = report.Err()
+				}
+				if err != nil {
+					exitWithError(1, "Error importing BOM", err)
+				}
+				revisions = append(revisions, bom)
+			}
+			tagScheme, err := tagSchemes.Get(revisions[1].TagScheme)
+			if err != nil {
+				exitWithError(1, "Error loading tag schemes", err)
+			}
+
+			diff := pipeline.DiffBOM(revisions[0], revisions[1], tagScheme)
+			if projectName != "" {
+				diff.Project = projectName
+			}
+			t := table{headers: []string{"kind", "row", "tag", "old tag", "field", "old", "new"}}
+			for _, c := range diff.Changes {
+				row := []string{string(c.Kind), strconv.Itoa(c.Row), c.Tag, c.OldTag}
+				if len(c.Fields) == 0 {
+					t.rows = append(t.rows, append(row, "", "", ""))
+				}
+				for _, f := range c.Fields {
+					t.rows = append(t.rows, append(slices.Clone(row), f.Field, f.Old, f.New))
+				}
+			}
+			if err := printResult(diff, t); err != nil {
+				exitWithError(1, "Error writing output", err)
+			}
+			printMessage("\n%s", diff.Summary())
+
+			if outFile != "" {
+				if err := pipeline.ExportDiff(diff, outFile); err != nil {
+					exitWithError(1, "Error writing change list", err)
+				}
+				printMessage("✅ Wrote the change list to %s", outFile)
+			}
+		},
+	}
+	diffCmd.Flags().StringVar(&profileName, "profile", bomimport.DefaultProfileName, "Column-mapping profile from "+bomimport.ProfilesFile)
+	diffCmd.Flags().StringVar(&projectName, "project", "", "Project name (default: the new BOM's project)")
+	diffCmd.Flags().StringVar(&outFile, "out", "", "Write the change list as .yaml, .csv or .md")
+
+	bomCmd.AddCommand(importCmd, profilesCmd, validateCmd, diffCmd)
+	return bomCmd
+}
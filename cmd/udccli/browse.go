@@ -0,0 +1,434 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
+
+	"github.com/thornzero/udc_codec/pkg/udc"
+)
+
+// browseRow is a visible line of the tree pane
+type browseRow struct {
+	node  *udc.Node
+	depth int
+}
+
+// browser is the state of the interactive hierarchy browser
+type browser struct {
+	codec    *udc.Codec
+	screen   tcell.Screen
+	parents  map[*udc.Node]*udc.Node
+	order    []*udc.Node
+	expanded map[*udc.Node]bool
+	rows     []browseRow
+	cursor   int
+	offset   int
+
+	searching bool
+	query     string
+	status    string
+}
+
+func newBrowser(codec *udc.Codec, screen tcell.Screen) *browser {
+	b := &browser{
+		codec:    codec,
+		screen:   screen,
+		parents:  make(map[*udc.Node]*udc.Node),
+		expanded: make(map[*udc.Node]bool),
+	}
+	b.index(codec.Roots(), nil)
+
+	// Open single roots such as TOP so the main tables are visible
+	if roots := codec.Roots(); len(roots) > 0 && len(roots[0].Children) > 0 {
+		b.expanded[roots[0]] = true
+	}
+	b.refresh()
+	return b
+}
+
+// index records parent links and the pre-order sequence used for searching
+func (b *browser) index(nodes []*udc.Node, parent *udc.Node) {
+	for _, node := range nodes {
+		b.parents[node] = parent
+		b.order = append(b.order, node)
+		b.index(node.Children, node)
+	}
+}
+
+// refresh rebuilds the visible rows from the expanded state
+func (b *browser) refresh() {
+	var selected *udc.Node
+	if b.cursor < len(b.rows) {
+		selected = b.rows[b.cursor].node
+	}
+
+	b.rows = b.rows[:0]
+	var walk func(nodes []*udc.Node, depth int)
+	walk = func(nodes []*udc.Node, depth int) {
+		for _, node := range nodes {
+			b.rows = append(b.rows, browseRow{node: node, depth: depth})
+			if b.expanded[node] {
+				walk(node.Children, depth+1)
+			}
+		}
+	}
+	walk(b.codec.Roots(), 0)
+
+	b.cursor = 0
+	for i, row := range b.rows {
+		if row.node == selected {
+			b.cursor = i
+			break
+		}
+	}
+}
+
+func (b *browser) selected() *udc.Node {
+	if len(b.rows) == 0 {
+		return nil
+	}
+	return b.rows[b.cursor].node
+}
+
+// reveal expands every ancestor of a node and moves the cursor onto it
+func (b *browser) reveal(node *udc.Node) {
+	for p := b.parents[node]; p != nil; p = b.parents[p] {
+		b.expanded[p] = true
+	}
+	b.refresh()
+	for i, row := range b.rows {
+		if row.node == node {
+			b.cursor = i
+			return
+		}
+	}
+}
+
+func (b *browser) move(delta int) {
+	b.cursor += delta
+	b.cursor = max(min(b.cursor, len(b.rows)-1), 0)
+}
+
+// matches reports whether a node matches the current search query
+func (b *browser) matches(node *udc.Node) bool {
+	query := strings.ToLower(b.query)
+	return strings.HasPrefix(strings.ToLower(node.Code), query) ||
+		strings.Contains(strings.ToLower(node.Title), query)
+}
+
+// find moves to the next match in pre-order, starting after the selection
+// when step is 1, before it when step is -1, or at it when step is 0
+func (b *browser) find(step int) {
+	if b.query == "" || len(b.order) == 0 {
+		return
+	}
+	start := 0
+	if current := b.selected(); current != nil {
+		for i, node := range b.order {
+			if node == current {
+				start = i
+				break
+			}
+		}
+	}
+	direction := step
+	if direction == 0 {
+		direction = 1
+	}
+	for i := 0; i < len(b.order); i++ {
+		idx := ((start+step+i*direction)%len(b.order) + len(b.order)) % len(b.order)
+		if b.matches(b.order[idx]) {
+			b.reveal(b.order[idx])
+			b.status = ""
+			return
+		}
+	}
+	b.status = fmt.Sprintf("No match for %q", b.query)
+}
+
+// copyCode puts the selected code on the terminal clipboard
+func (b *browser) copyCode() {
+	node := b.selected()
+	if node == nil {
+		return
+	}
+	b.screen.SetClipboard([]byte(node.Code))
+	b.status = fmt.Sprintf("Copied %s to clipboard", node.Code)
+}
+
+// handleKey applies a key press and reports whether the browser should exit
+func (b *browser) handleKey(ev *tcell.EventKey) bool {
+	if b.searching {
+		switch ev.Key() {
+		case tcell.KeyEscape:
+			b.searching = false
+			b.query = ""
+		case tcell.KeyEnter:
+			b.searching = false
+		case tcell.KeyBackspace, tcell.KeyBackspace2:
+			if b.query != "" {
+				_, size := utf8.DecodeLastRuneInString(b.query)
+				b.query = b.query[:len(b.query)-size]
+				b.find(0)
+			}
+		case tcell.KeyRune:
+			b.query += string(ev.Rune())
+			b.find(0)
+		}
+		return false
+	}
+
+	_, height := b.screen.Size()
+	page := height - 4
+	b.status = ""
+
+	switch ev.Key() {
+	case tcell.KeyCtrlC, tcell.KeyEscape:
+		return true
+	case tcell.KeyUp:
+		b.move(-1)
+	case tcell.KeyDown:
+		b.move(1)
+	case tcell.KeyPgUp:
+		b.move(-page)
+	case tcell.KeyPgDn:
+		b.move(page)
+	case tcell.KeyHome:
+		b.cursor = 0
+	case tcell.KeyEnd:
+		b.cursor = max(len(b.rows)-1, 0)
+	case tcell.KeyRight, tcell.KeyEnter:
+		b.expand()
+	case tcell.KeyLeft:
+		b.collapse()
+	case tcell.KeyRune:
+		switch ev.Rune() {
+		case 'q':
+			return true
+		case 'k':
+			b.move(-1)
+		case 'j':
+			b.move(1)
+		case 'l':
+			b.expand()
+		case 'h':
+			b.collapse()
+		case ' ':
+			if node := b.selected(); node != nil && len(node.Children) > 0 {
+				b.expanded[node] = !b.expanded[node]
+				b.refresh()
+			}
+		case '/':
+			b.searching = true
+			b.query = ""
+		case 'n':
+			b.find(1)
+		case 'N':
+			b.find(-1)
+		case 'y', 'c':
+			b.copyCode()
+		}
+	}
+	return false
+}
+
+func (b *browser) expand() {
+	node := b.selected()
+	if node == nil || len(node.Children) == 0 {
+		return
+	}
+	if b.expanded[node] {
+		b.move(1)
+		return
+	}
+	b.expanded[node] = true
+	b.refresh()
+}
+
+func (b *browser) collapse() {
+	node := b.selected()
+	if node == nil {
+		return
+	}
+	if b.expanded[node] {
+		b.expanded[node] = false
+		b.refresh()
+		return
+	}
+	if parent := b.parents[node]; parent != nil {
+		b.reveal(parent)
+	}
+}
+
+// drawText writes a string at a position, clipped to a maximum width
+func drawText(screen tcell.Screen, x, y, width int, style tcell.Style, text string) int {
+	col := 0
+	for _, r := range text {
+		w := runewidth.RuneWidth(r)
+		if col+w > width {
+			break
+		}
+		screen.SetContent(x+col, y, r, nil, style)
+		col += w
+	}
+	return col
+}
+
+// wrapText splits text into lines no wider than width
+func wrapText(text string, width int) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			if line != "" && runewidth.StringWidth(line)+1+runewidth.StringWidth(word) > width {
+				lines = append(lines, line)
+				line = ""
+			}
+			if line != "" {
+				line += " "
+			}
+			line += word
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func (b *browser) draw() {
+	b.screen.Clear()
+	width, height := b.screen.Size()
+	if width < 20 || height < 5 {
+		b.screen.Show()
+		return
+	}
+
+	normal := tcell.StyleDefault
+	bold := normal.Bold(true)
+	dim := normal.Dim(true)
+	highlight := normal.Reverse(true)
+	addendum := normal.Foreground(tcell.ColorGreen)
+
+	node := b.selected()
+
+	// Breadcrumbs from the codec's ancestry
+	var crumbs []string
+	if node != nil {
+		if ancestry, ok := b.codec.Ancestry(node.Code); ok {
+			for _, a := range ancestry {
+				crumbs = append(crumbs, a.Code)
+			}
+		}
+	}
+	drawText(b.screen, 0, 0, width, bold, " "+strings.Join(crumbs, " › "))
+
+	treeWidth := width * 3 / 5
+	bodyTop, bodyHeight := 2, height-3
+
+	// Tree pane
+	if b.cursor < b.offset {
+		b.offset = b.cursor
+	}
+	if b.cursor >= b.offset+bodyHeight {
+		b.offset = b.cursor - bodyHeight + 1
+	}
+	for i := 0; i < bodyHeight && b.offset+i < len(b.rows); i++ {
+		row := b.rows[b.offset+i]
+		marker := "  "
+		if len(row.node.Children) > 0 {
+			marker = "▸ "
+			if b.expanded[row.node] {
+				marker = "▾ "
+			}
+		}
+		style := normal
+		if b.codec.IsAddendum(row.node.Code) {
+			style = addendum
+		}
+		if b.offset+i == b.cursor {
+			style = highlight
+		}
+		text := strings.Repeat("  ", row.depth) + marker + row.node.Code + "  " + row.node.Title
+		col := drawText(b.screen, 0, bodyTop+i, treeWidth-1, style, text)
+		if b.offset+i == b.cursor {
+			for ; col < treeWidth-1; col++ {
+				b.screen.SetContent(col, bodyTop+i, ' ', nil, style)
+			}
+		}
+	}
+	for y := bodyTop; y < bodyTop+bodyHeight; y++ {
+		b.screen.SetContent(treeWidth-1, y, '│', nil, dim)
+	}
+
+	// Detail pane
+	if node != nil {
+		x, detailWidth := treeWidth+1, width-treeWidth-2
+		y := bodyTop
+		line := func(style tcell.Style, text string) {
+			for _, l := range wrapText(text, detailWidth) {
+				if y < bodyTop+bodyHeight {
+					drawText(b.screen, x, y, detailWidth, style, l)
+				}
+				y++
+			}
+		}
+		line(bold, node.Code)
+		line(normal, node.Title)
+		y++
+		source, _ := b.codec.Source(node.Code)
+		if b.codec.IsAddendum(node.Code) {
+			line(addendum, "Addendum: "+source)
+		} else if source != "" {
+			line(dim, "Source: "+source)
+		}
+		line(dim, fmt.Sprintf("Children: %d", len(node.Children)))
+		if node.Notes != "" {
+			y++
+			line(bold, "Notes")
+			line(normal, node.Notes)
+		}
+	}
+
+	// Status line
+	status := " ↑↓ move  ←→ collapse/expand  / search  n/N next/prev  y copy code  q quit"
+	if b.searching {
+		status = " /" + b.query
+		b.screen.ShowCursor(runewidth.StringWidth(status), height-1)
+	} else {
+		b.screen.HideCursor()
+		if b.status != "" {
+			status = " " + b.status
+		}
+	}
+	drawText(b.screen, 0, height-1, width, dim, status)
+
+	b.screen.Show()
+}
+
+// runBrowser starts the full-screen browser and blocks until the user quits
+func runBrowser(codec *udc.Codec) error {
+	screen, err := tcell.NewScreen()
+	if err != nil {
+		return fmt.Errorf("failed to create screen: %w", err)
+	}
+	if err := screen.Init(); err != nil {
+		return fmt.Errorf("failed to initialise screen: %w", err)
+	}
+	defer screen.Fini()
+
+	b := newBrowser(codec, screen)
+	for {
+		b.draw()
+		switch ev := screen.PollEvent().(type) {
+		case *tcell.EventResize:
+			screen.Sync()
+		case *tcell.EventKey:
+			if b.handleKey(ev) {
+				return nil
+			}
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/thornzero/udc_codec/pkg/udc"
+)
+
+// nodeResult is the structured form of a single UDC node
+type nodeResult struct {
+	Code   string `json:"code" yaml:"code"`
+	Title  string `json:"title" yaml:"title"`
+	Notes  string `json:"notes,omitempty" yaml:"notes,omitempty"`
+	Source string `json:"source,omitempty" yaml:"source,omitempty"`
+}
+
+// treeResult is a UDC node with its children, limited to the requested depth
+type treeResult struct {
+	Code     string        `json:"code" yaml:"code"`
+	Title    string        `json:"title" yaml:"title"`
+	Addendum bool          `json:"addendum,omitempty" yaml:"addendum,omitempty"`
+	Children []*treeResult `json:"children,omitempty" yaml:"children,omitempty"`
+}
+
+// partResult is one component of a validated composite expression
+type partResult struct {
+	Code  string `json:"code" yaml:"code"`
+	Title string `json:"title,omitempty" yaml:"title,omitempty"`
+	Valid bool   `json:"valid" yaml:"valid"`
+}
+
+// validateResult is the outcome of validating a composite expression
+type validateResult struct {
+	Expression string       `json:"expression" yaml:"expression"`
+	Valid      bool         `json:"valid" yaml:"valid"`
+	Error      string       `json:"error,omitempty" yaml:"error,omitempty"`
+	Parts      []partResult `json:"parts" yaml:"parts"`
+}
+
+func toNodeResult(codec *udc.Codec, node *udc.Node) nodeResult {
+	source, _ := codec.Source(node.Code)
+	return nodeResult{Code: node.Code, Title: node.Title, Notes: node.Notes, Source: source}
+}
+
+// printNodes prints a list of nodes in the selected output format
+func printNodes(codec *udc.Codec, nodes []*udc.Node) {
+	results := make([]nodeResult, 0, len(nodes))
+	t := table{headers: []string{"code", "title", "source"}}
+	for _, node := range nodes {
+		r := toNodeResult(codec, node)
+		results = append(results, r)
+		t.rows = append(t.rows, []string{r.Code, r.Title, r.Source})
+	}
+	if err := printResult(results, t); err != nil {
+		exitWithError(1, "Error writing output", err)
+	}
+}
+
+// buildTree copies a node and its descendants down to depth levels (0 for all)
+func buildTree(codec *udc.Codec, node *udc.Node, depth int) *treeResult {
+	r := &treeResult{Code: node.Code, Title: node.Title, Addendum: codec.IsAddendum(node.Code)}
+	if depth == 1 {
+		return r
+	}
+	for _, child := range node.Children {
+		r.Children = append(r.Children, buildTree(codec, child, depth-1))
+	}
+	return r
+}
+
+// flattenTree renders a tree as table rows, indenting codes for table output
+func flattenTree(r *treeResult, parent string, depth int, t *table) {
+	code := r.Code
+	if outputFormat == outputTable {
+		code = strings.Repeat("  ", depth) + code
+	}
+	t.rows = append(t.rows, []string{strconv.Itoa(depth), code, parent, r.Title})
+	for _, child := range r.Children {
+		flattenTree(child, r.Code, depth+1, t)
+	}
+}
+
+// addCodecCommands registers the commands that query the UDC codec
+func addCodecCommands(rootCmd *cobra.Command) {
+	var lookupCmd = &cobra.Command{
+		Use:   "lookup [code]",
+		Short: "Lookup a UDC code",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			codec := loadCodec()
+			node, ok := codec.Node(args[0])
+			if !ok {
+				fmt.Fprintln(os.Stderr, "Code not found.")
+				os.Exit(1)
+			}
+			r := toNodeResult(codec, node)
+			t := table{headers: []string{"code", "title", "source"}, rows: [][]string{{r.Code, r.Title, r.Source}}}
+			if err := printResult(r, t); err != nil {
+				exitWithError(1, "Error writing output", err)
+			}
+		},
+	}
+
+	var searchLimit int
+	var searchCmd = &cobra.Command{
+		Use:   "search [term]",
+		Short: "Search UDC titles for a term",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			codec := loadCodec()
+			results := codec.Search(strings.Join(args, " "))
+			if searchLimit > 0 && len(results) > searchLimit {
+				results = results[:searchLimit]
+			}
+			printNodes(codec, results)
+		},
+	}
+	searchCmd.Flags().IntVar(&searchLimit, "limit", 0, "Maximum number of results (0 for all)")
+
+	var childrenCmd = &cobra.Command{
+		Use:   "children [code]",
+		Short: "List the direct children of a UDC code",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			codec := loadCodec()
+			children, ok := codec.Children(args[0])
+			if !ok {
+				fmt.Fprintln(os.Stderr, "Code not found.")
+				os.Exit(1)
+			}
+			printNodes(codec, children)
+		},
+	}
+
+	var ancestryCmd = &cobra.Command{
+		Use:   "ancestry [code]",
+		Short: "List the ancestors of a UDC code, from the root down",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			codec := loadCodec()
+			ancestry, ok := codec.Ancestry(args[0])
+			if !ok {
+				fmt.Fprintln(os.Stderr, "Code not found.")
+				os.Exit(1)
+			}
+			printNodes(codec, ancestry)
+		},
+	}
+
+	var treeDepth int
+	var treeCmd = &cobra.Command{
+		Use:   "tree [code]",
+		Short: "Show the subtree below a UDC code",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			codec := loadCodec()
+			node, ok := codec.Node(args[0])
+			if !ok {
+				fmt.Fprintln(os.Stderr, "Code not found.")
+				os.Exit(1)
+			}
+			depth := treeDepth
+			if depth > 0 {
+				// --depth counts levels below the requested code
+				depth++
+			}
+			tree := buildTree(codec, node, depth)
+
+			t := table{headers: []string{"depth", "code", "parent", "title"}}
+			flattenTree(tree, "", 0, &t)
+			if err := printResult(tree, t); err != nil {
+				exitWithError(1, "Error writing output", err)
+			}
+		},
+	}
+	treeCmd.Flags().IntVar(&treeDepth, "depth", 1, "Number of levels below the code to show (0 for all)")
+
+	var validateCmd = &cobra.Command{
+		Use:   "validate [expression]",
+		Short: "Validate a UDC code or composite expression such as 621.3:681.5(075)",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			codec := loadCodec()
+			result := validateResult{Expression: args[0], Valid: true, Parts: []partResult{}}
+			if err := codec.Validate(args[0]); err != nil {
+				result.Valid = false
+				result.Error = err.Error()
+			}
+
+			t := table{headers: []string{"code", "valid", "title"}}
+			parts, _ := udc.SplitComposite(args[0])
+			for _, code := range parts {
+				title, ok := codec.Lookup(code)
+				result.Parts = append(result.Parts, partResult{Code: code, Title: title, Valid: ok})
+				t.rows = append(t.rows, []string{code, strconv.FormatBool(ok), title})
+			}
+
+			if err := printResult(result, t); err != nil {
+				exitWithError(1, "Error writing output", err)
+			}
+			if !result.Valid {
+				if outputFormat == outputTable {
+					fmt.Fprintln(os.Stderr, "Invalid:", result.Error)
+				}
+				os.Exit(1)
+			}
+		},
+	}
+
+	rootCmd.AddCommand(lookupCmd)
+	rootCmd.AddCommand(searchCmd)
+	rootCmd.AddCommand(childrenCmd)
+	rootCmd.AddCommand(ancestryCmd)
+	rootCmd.AddCommand(treeCmd)
+	rootCmd.AddCommand(validateCmd)
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/thornzero/udc_codec/pkg/classification"
+	"github.com/thornzero/udc_codec/pkg/crosswalk"
+)
+
+// mappingResult is a crosswalk mapping with the file it came from
+type mappingResult struct {
+	crosswalk.Mapping `yaml:",inline"`
+	Source            string `json:"source" yaml:"source"`
+}
+
+// loadCrosswalk loads every crosswalk file in the data directory, exiting on failure
+func loadCrosswalk() *crosswalk.Crosswalk {
+	cw, err := crosswalk.Load(dataDir)
+	if err != nil {
+		exitWithError(1, "Error loading crosswalks", err)
+	}
+	return cw
+}
+
+// printTranslations prints translated codes with their titles where the scheme is registered
+func printTranslations(schemes *classification.Registry, translations []crosswalk.Translation) {
+	if translations == nil {
+		translations = []crosswalk.Translation{}
+	}
+	t := table{headers: []string{"scheme", "code", "equivalence", "title"}}
+	for _, tr := range translations {
+		title, _ := schemes.Lookup(tr.Scheme, tr.Code)
+		t.rows = append(t.rows, []string{tr.Scheme, tr.Code, string(tr.Equivalence), title})
+	}
+	if err := printResult(translations, t); err != nil {
+		exitWithError(1, "Error writing output", err)
+	}
+}
+
+// parseMapping builds a mapping from udc, scheme, code and equivalence arguments
+func parseMapping(args []string, note string) (crosswalk.Mapping, error) {
+	m := crosswalk.Mapping{UDC: args[0], Scheme: args[1], Code: args[2], Note: note}
+	if len(args) > 3 {
+		m.Equivalence = crosswalk.Equivalence(args[3])
+		if !m.Equivalence.Valid() {
+			return m, fmt.Errorf("equivalence must be exact, broader, narrower or related, got %q", args[3])
+		}
+	}
+	return m, nil
+}
+
+func newCrosswalkCmd() *cobra.Command {
+	var crosswalkCmd = &cobra.Command{
+		Use:   "crosswalk",
+		Short: "Map UDC codes to ECLASS, UNSPSC, IEC 61360 and other schemes",
+	}
+
+	var scheme string
+	var listCmd = &cobra.Command{
+		Use:   "list [udc-code]",
+		Short: "List crosswalk mappings, optionally for one UDC code",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cw := loadCrosswalk()
+			results := []mappingResult{}
+			t := table{headers: []string{"udc", "scheme", "code", "equivalence", "source", "note"}}
+			for _, m := range cw.Mappings() {
+				if len(args) == 1 && m.UDC != args[0] {
+					continue
+				}
+				if scheme != "" && m.Scheme != scheme {
+					continue
+				}
+				results = append(results, mappingResult{Mapping: m, Source: cw.Source(m)})
+				t.rows = append(t.rows, []string{m.UDC, m.Scheme, m.Code, string(m.Equivalence), cw.Source(m), m.Note})
+			}
+			if err := printResult(results, t); err != nil {
+				exitWithError(1, "Error writing output", err)
+			}
+		},
+	}
+	listCmd.Flags().StringVar(&scheme, "scheme", "", "Only list mappings to this scheme")
+
+	var translateCmd = &cobra.Command{
+		Use:   "translate [from-scheme] [code] [to-scheme]",
+		Short: "Translate a code between UDC and another scheme (to-scheme defaults to all)",
+		Args:  cobra.RangeArgs(2, 3),
+		Run: func(cmd *cobra.Command, args []string) {
+			to := ""
+			if len(args) == 3 {
+				to = args[2]
+			}
+			if args[0] != classification.UDC && to == "" {
+				to = classification.UDC
+			}
+			schemes := loadResolver().Schemes
+			translations := loadCrosswalk().Translate(args[0], args[1], to)
+			if len(translations) == 0 && outputFormat == outputTable {
+				fmt.Fprintln(os.Stderr, "No mappings found.")
+				os.Exit(1)
+			}
+			printTranslations(schemes, translations)
+		},
+	}
+
+	var note string
+	var addCmd = &cobra.Command{
+		Use:   "add [udc-code] [scheme] [code] [equivalence] [filename]",
+		Short: "Add a mapping to a local crosswalk file (filename is optional)",
+		Args:  cobra.RangeArgs(4, 5),
+		Run: func(cmd *cobra.Command, args []string) {
+			m, err := parseMapping(args, note)
+			if err != nil {
+				exitWithError(1, "Invalid mapping", err)
+			}
+			schemes := loadResolver().Schemes
+			if err := schemes.Validate(classification.UDC, m.UDC); err != nil {
+				exitWithError(1, "Invalid UDC code", err)
+			}
+			// Codes are only checked for schemes with a local class table
+			if target, ok := schemes.Get(m.Scheme); ok {
+				if err := target.Validate(m.Code); err != nil {
+					exitWithError(1, "Invalid target code", err)
+				}
+			}
+
+			var filename string
+			if len(args) == 5 {
+				filename = args[4]
+			}
+			if err := crosswalk.NewManager(dataDir).Add(filename, []crosswalk.Mapping{m}); err != nil {
+				exitWithError(1, "Error adding to crosswalk", err)
+			}
+
+			filename = crosswalk.Filename(filename)
+			if outputFormat == outputTable {
+				printMessage("✅ Added mapping %s -> %s:%s to crosswalk file: %s", m.UDC, m.Scheme, m.Code, filename)
+				return
+			}
+			t := table{headers: []string{"udc", "scheme", "code", "equivalence", "source"}, rows: [][]string{{m.UDC, m.Scheme, m.Code, string(m.Equivalence), filename}}}
+			if err := printResult(mappingResult{Mapping: m, Source: filename}, t); err != nil {
+				exitWithError(1, "Error writing output", err)
+			}
+		},
+	}
+	addCmd.Flags().StringVar(&note, "note", "", "Note stored with the mapping")
+
+	var removeCmd = &cobra.Command{
+		Use:   "remove [udc-code] [scheme] [code] [filename]",
+		Short: "Remove a mapping from a local crosswalk file (filename is optional)",
+		Args:  cobra.RangeArgs(3, 4),
+		Run: func(cmd *cobra.Command, args []string) {
+			m, _ := parseMapping(args[:3], "")
+			var filename string
+			if len(args) == 4 {
+				filename = args[3]
+			}
+			if err := crosswalk.NewManager(dataDir).Remove(filename, m); err != nil {
+				exitWithError(1, "Error removing from crosswalk", err)
+			}
+			printMessage("✅ Removed mapping %s -> %s:%s from crosswalk file: %s", m.UDC, m.Scheme, m.Code, crosswalk.Filename(filename))
+		},
+	}
+
+	var filesCmd = &cobra.Command{
+		Use:   "files",
+		Short: "List all local crosswalk files",
+		Run: func(cmd *cobra.Command, args []string) {
+			files, err := crosswalk.NewManager(dataDir).ListAddendums()
+			if err != nil {
+				exitWithError(1, "Error listing crosswalk files", err)
+			}
+			if len(files) == 0 && outputFormat == outputTable {
+				printMessage("No local crosswalk files found.")
+				return
+			}
+			results := make([]addendumResult, 0, len(files))
+			t := table{headers: []string{"file"}}
+			for _, file := range files {
+				results = append(results, addendumResult{File: file})
+				t.rows = append(t.rows, []string{file})
+			}
+			if err := printResult(results, t); err != nil {
+				exitWithError(1, "Error writing output", err)
+			}
+		},
+	}
+
+	var deleteCmd = &cobra.Command{
+		Use:   "delete [filename]",
+		Short: "Delete a local crosswalk file",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			filename := crosswalk.Filename(args[0])
+			if err := crosswalk.NewManager(dataDir).DeleteAddendum(filename); err != nil {
+				exitWithError(1, "Error deleting crosswalk file", err)
+			}
+			printMessage("✅ Deleted crosswalk file: %s", filename)
+		},
+	}
+
+	crosswalkCmd.AddCommand(listCmd, translateCmd, addCmd, removeCmd, filesCmd, deleteCmd)
+	return crosswalkCmd
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/thornzero/udc_codec/pkg/assettag"
+)
+
+func newDescribeCmd() *cobra.Command {
+	var (
+		schemeName string
+		project    string
+		udcCode    string
+	)
+
+	var describeCmd = &cobra.Command{
+		Use:   "describe [tag...]",
+		Short: "Describe tags with the description templates in " + assettag.DescriptionsFile,
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			scheme := loadTagScheme(schemeName)
+			resolver := loadResolver()
+
+			type described struct {
+				Tag         string `json:"tag" yaml:"tag"`
+				Description string `json:"description" yaml:"description"`
+			}
+			var results []described
+			t := table{headers: []string{"tag", "description"}}
+			failed := false
+			for _, arg := range args {
+				tag, err := scheme.Parse(arg)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+					failed = true
+					continue
+				}
+				tag.UDCCode = udcCode
+				desc, err := resolver.Describe(tag, project, language)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+					failed = true
+					continue
+				}
+				results = append(results, described{Tag: arg, Description: desc})
+				t.rows = append(t.rows, []string{arg, desc})
+			}
+			if err := printResult(results, t); err != nil {
+				exitWithError(1, "Error writing output", err)
+			}
+			if failed {
+				os.Exit(1)
+			}
+		},
+	}
+	describeCmd.Flags().StringVar(&schemeName, "scheme", assettag.DefaultTagSchemeName, "Tag scheme to parse with")
+	describeCmd.Flags().StringVar(&project, "project", "", "Project whose description templates to use")
+	describeCmd.Flags().StringVar(&udcCode, "udc", "", "UDC code to describe the tags with")
+	return describeCmd
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/thornzero/udc_codec/pkg/dictionary"
+)
+
+// termResult is a dictionary term and its expansions
+type termResult struct {
+	Term       string   `json:"term" yaml:"term"`
+	Expansions []string `json:"expansions" yaml:"expansions"`
+	Canonical  string   `json:"canonical" yaml:"canonical"`
+}
+
+func newDictionaryCmd() *cobra.Command {
+	var dictionaryCmd = &cobra.Command{
+		Use:   "dictionary",
+		Short: "Inspect and extend the abbreviation and synonym dictionary",
+	}
+
+	var listCmd = &cobra.Command{
+		Use:   "list [term]",
+		Short: "List dictionary terms and their expansions for --lang",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			dict := loadDictionary()
+			terms := dict.Terms()
+			if len(args) == 1 {
+				terms = []string{args[0]}
+			}
+
+			results := []termResult{}
+			t := table{headers: []string{"term", "expansions", "canonical"}}
+			for _, term := range terms {
+				expansions, ok := dict.Lookup(term)
+				if !ok {
+					continue
+				}
+				r := termResult{Term: term, Expansions: expansions, Canonical: dict.Canonical(term)}
+				results = append(results, r)
+				t.rows = append(t.rows, []string{r.Term, strings.Join(r.Expansions, ", "), r.Canonical})
+			}
+			if len(results) == 0 && len(args) == 1 {
+				fmt.Fprintln(os.Stderr, "Term not found.")
+				os.Exit(1)
+			}
+			if err := printResult(results, t); err != nil {
+				exitWithError(1, "Error writing output", err)
+			}
+		},
+	}
+
+	var expandCmd = &cobra.Command{
+		Use:   "expand [text]",
+		Short: "Show the search variants and normalised form of a text",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			dict := loadDictionary()
+			text := strings.Join(args, " ")
+			result := struct {
+				Text       string   `json:"text" yaml:"text"`
+				Normalised string   `json:"normalised" yaml:"normalised"`
+				Variants   []string `json:"variants" yaml:"variants"`
+			}{text, dict.Normalise(text), dict.Variants(text)}
+
+			t := table{headers: []string{"kind", "text"}}
+			t.rows = append(t.rows, []string{"normalised", result.Normalised})
+			for _, v := range result.Variants {
+				t.rows = append(t.rows, []string{"variant", v})
+			}
+			if err := printResult(result, t); err != nil {
+				exitWithError(1, "Error writing output", err)
+			}
+		},
+	}
+
+	var filename string
+	var addCmd = &cobra.Command{
+		Use:   "add [term] [expansion words...]",
+		Short: "Add an abbreviation and its expansion to a local dictionary file for --lang",
+		Args:  cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			m := dictionary.NewManager(dataDir)
+			if err := m.AddAbbreviation(filename, language, args[0], []string{strings.Join(args[1:], " ")}); err != nil {
+				exitWithError(1, "Error adding to dictionary", err)
+			}
+			printMessage("✅ Added %s to dictionary file: %s", args[0], dictionary.AddendumFilename(filename))
+		},
+	}
+	addCmd.Flags().StringVarP(&filename, "file", "f", "", "Local dictionary file (default dictionary_addendum_default.yaml)")
+
+	var synonymsCmd = &cobra.Command{
+		Use:   "synonyms [term] [term...]",
+		Short: "Add a group of interchangeable terms to a local dictionary file for --lang",
+		Args:  cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			m := dictionary.NewManager(dataDir)
+			if err := m.AddSynonyms(filename, language, args); err != nil {
+				exitWithError(1, "Error adding to dictionary", err)
+			}
+			printMessage("✅ Added synonyms %s to dictionary file: %s", strings.Join(args, ", "), dictionary.AddendumFilename(filename))
+		},
+	}
+	synonymsCmd.Flags().StringVarP(&filename, "file", "f", "", "Local dictionary file (default dictionary_addendum_default.yaml)")
+
+	var filesCmd = &cobra.Command{
+		Use:   "files",
+		Short: "List the dictionary files loaded for --lang",
+		Run: func(cmd *cobra.Command, args []string) {
+			sources := loadDictionary().Sources()
+			results := make([]addendumResult, 0, len(sources))
+			t := table{headers: []string{"file"}}
+			for _, source := range sources {
+				results = append(results, addendumResult{File: source})
+				t.rows = append(t.rows, []string{source})
+			}
+			if err := printResult(results, t); err != nil {
+				exitWithError(1, "Error writing output", err)
+			}
+		},
+	}
+
+	var deleteCmd = &cobra.Command{
+		Use:   "delete [filename]",
+		Short: "Delete a local dictionary file",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			name := dictionary.AddendumFilename(args[0])
+			if err := dictionary.NewManager(dataDir).DeleteAddendum(name); err != nil {
+				exitWithError(1, "Error deleting dictionary file", err)
+			}
+			printMessage("✅ Deleted dictionary file: %s", name)
+		},
+	}
+
+	dictionaryCmd.AddCommand(listCmd, expandCmd, addCmd, synonymsCmd, filesCmd, deleteCmd)
+	return dictionaryCmd
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/thornzero/udc_codec/pkg/assettag"
+	"github.com/thornzero/udc_codec/pkg/config"
+	"github.com/thornzero/udc_codec/pkg/db"
+	"github.com/thornzero/udc_codec/pkg/duplicates"
+	"github.com/thornzero/udc_codec/pkg/pipeline"
+)
+
+// openTagStore opens and migrates the tag DB, exiting on failure
+func openTagStore(dbPath string) *db.Store {
+	store, err := db.OpenDB(dbPath)
+	if err != nil {
+		exitWithError(1, "Error opening tag database", err)
+	}
+	if err := store.Migrate(); err != nil {
+		exitWithError(1, "Error migrating tag database", err)
+	}
+	return store
+}
+
+func newNormaliseCmd() *cobra.Command {
+	var schemeName string
+
+	var normaliseCmd = &cobra.Command{
+		Use:     "normalise [tag...]",
+		Aliases: []string{"normalize"},
+		Short:   "Rewrite tag variants such as pol lt 1001 or POLLT1001 into the scheme's canonical form",
+		Args:    cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			scheme := loadTagScheme(schemeName)
+			type normalised struct {
+				Tag       string `json:"tag" yaml:"tag"`
+				Canonical string `json:"canonical,omitempty" yaml:"canonical,omitempty"`
+				Error     string `json:"error,omitempty" yaml:"error,omitempty"`
+			}
+			var results []normalised
+			t := table{headers: []string{"tag", "canonical", "error"}}
+			failed := false
+			for _, arg := range args {
+				r := normalised{Tag: arg}
+				if tag, err := scheme.Normalise(arg); err != nil {
+					r.Error = err.Error()
+					failed = true
+				} else {
+					r.Canonical = tag.String()
+				}
+				results = append(results, r)
+				t.rows = append(t.rows, []string{r.Tag, r.Canonical, r.Error})
+			}
+			if err := printResult(results, t); err != nil {
+				exitWithError(1, "Error writing output", err)
+			}
+			if failed {
+				os.Exit(1)
+			}
+		},
+	}
+	normaliseCmd.Flags().StringVar(&schemeName, "scheme", assettag.DefaultTagSchemeName, "Tag scheme to normalise to")
+	return normaliseCmd
+}
+
+// duplicatesTable lists every occurrence of every group
+func duplicatesTable(report *duplicates.Report) table {
+	t := table{headers: []string{"kind", "canonical", "tag", "project", "source", "row", "cross-project"}}
+	for _, g := range report.Groups {
+		for _, o := range g.Occurrences {
+			row := ""
+			if o.Row > 0 {
+				row = strconv.Itoa(o.Row)
+			}
+			t.rows = append(t.rows, []string{g.Kind, g.Canonical, o.Tag, o.Project, o.Source, row, strconv.FormatBool(g.CrossProject)})
+		}
+	}
+	return t
+}
+
+func newDuplicatesCmd() *cobra.Command {
+	var (
+		schemeName string
+		dbPath     string
+		noDB       bool
+		merge      bool
+	)
+
+	var duplicatesCmd = &cobra.Command{
+		Use:   "duplicates [bom.yaml...]",
+		Short: "Find exact, variant and near-duplicate tags in the tag database and BOMs",
+		Run: func(cmd *cobra.Command, args []string) {
+			if noDB && merge {
+				exitWithError(1, "Error merging tags", fmt.Errorf("--merge works on the tag database and cannot be used with --no-db"))
+			}
+			scheme := loadTagScheme(schemeName)
+
+			var occurrences []duplicates.Occurrence
+			var store *db.Store
+			if !noDB {
+				store = openTagStore(dbPath)
+				found, err := duplicates.FromStore(store)
+				if err != nil {
+					exitWithError(1, "Error reading tag database", err)
+				}
+				occurrences = append(occurrences, found...)
+			}
+			for _, file := range args {
+				bom, err := pipeline.LoadBOM(file)
+				if err != nil {
+					exitWithError(1, "Error loading BOM", err)
+				}
+				occurrences = append(occurrences, duplicates.FromBOM(bom, file, loadTagScheme(bom.TagScheme))...)
+			}
+
+			detector := duplicates.New(scheme)
+			report := detector.Detect(occurrences)
+			if err := printResult(report, duplicatesTable(report)); err != nil {
+				exitWithError(1, "Error writing output", err)
+			}
+			printMessage("\n%d tags scanned, %d duplicate groups, %d tags outside scheme %s", report.Scanned, len(report.Groups), len(report.Unparsed), scheme.Name)
+
+			if !merge {
+				return
+			}
+			// Fuzzy groups may be genuinely different tags, so they are left
+			// for review and merged explicitly with "tags merge"
+			merged := 0
+			for _, g := range report.Groups {
+				tags := g.DBTags()
+				if g.Kind == duplicates.Fuzzy || len(tags) == 0 || len(tags) == 1 && tags[0] == g.Canonical {
+					continue
+				}
+				if _, err := detector.Merge(store, g); err != nil {
+					exitWithError(1, "Error merging tags", err)
+				}
+				printMessage("✅ Merged %s into %s", strings.Join(tags, ", "), g.Canonical)
+				merged++
+			}
+			printMessage("%d groups merged", merged)
+		},
+	}
+	duplicatesCmd.Flags().StringVar(&schemeName, "scheme", assettag.DefaultTagSchemeName, "Tag scheme to normalise to")
+	duplicatesCmd.Flags().StringVar(&dbPath, "db", config.Load().DBPath, "Tag database to scan")
+	duplicatesCmd.Flags().BoolVar(&noDB, "no-db", false, "Only scan the given BOMs")
+	duplicatesCmd.Flags().BoolVar(&merge, "merge", false, "Merge exact and variant duplicates in the tag database into their canonical tag")
+	return duplicatesCmd
+}
+
+func newMergeCmd() *cobra.Command {
+	var (
+		dbPath     string
+		schemeName string
+	)
+
+	var mergeCmd = &cobra.Command{
+		Use:   "merge <target> <tag...>",
+		Short: "Merge tags into one record, keeping the merged tags as aliases",
+		Args:  cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			store := openTagStore(dbPath)
+			detector := duplicates.New(loadTagScheme(schemeName))
+			tag, err := detector.MergeTags(store, args[0], args[1:], "merge")
+			if err != nil {
+				exitWithError(1, "Error merging tags", err)
+			}
+			printMessage("✅ Merged %s into %s", strings.Join(args[1:], ", "), tag.FullTag)
+		},
+	}
+	mergeCmd.Flags().StringVar(&schemeName, "scheme", assettag.DefaultTagSchemeName, "Tag scheme the target is parsed with")
+	mergeCmd.Flags().StringVar(&dbPath, "db", config.Load().DBPath, "Tag database")
+	return mergeCmd
+}
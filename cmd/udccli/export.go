@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/thornzero/udc_codec/pkg/pipeline"
+)
+
+func newExportCmd() *cobra.Command {
+	var (
+		format   string
+		fromFile string
+		outFile  string
+	)
+	exporters := pipeline.DefaultExporters()
+
+	var exportCmd = &cobra.Command{
+		Use:   "export <project>",
+		Short: "Write a project's tag list as " + strings.Join(exporters.Names(), ", "),
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			project := args[0]
+			if fromFile == "" {
+				fromFile = filepath.Join(dataDir, project+"_taglist.yaml")
+			}
+			// Without a format the --out extension picks one
+			if format == "" {
+				format = "csv"
+				for _, name := range exporters.Names() {
+					e, _ := exporters.Get(name)
+					if outFile != "" && strings.EqualFold(filepath.Ext(outFile), e.Extension()) {
+						format = name
+					}
+				}
+			}
+			exporter, err := exporters.Exporter(format)
+			if err != nil {
+				exitWithError(1, "Error selecting export format", err)
+			}
+			records, err := pipeline.LoadExportedTags(fromFile)
+			if err != nil {
+				exitWithError(1, "Error loading tag list", err)
+			}
+
+			if outFile == "" {
+				if err := exporter.Write(os.Stdout, project, records); err != nil {
+					exitWithError(1, "Error writing output", err)
+				}
+				return
+			}
+			if err := pipeline.ExportTags(exporter, project, records, outFile); err != nil {
+				exitWithError(1, "Error writing tag list", err)
+			}
+			fmt.Fprintf(os.Stderr, "✅ Wrote %d tags to %s\n", len(records), outFile)
+		},
+	}
+	exportCmd.Flags().StringVar(&format, "format", "", "Format: "+strings.Join(exporters.Names(), ", ")+" (default: from the --out extension, else csv)")
+	exportCmd.Flags().StringVar(&fromFile, "from", "", "Tag list to read (default: the project's tag list in the data directory)")
+	exportCmd.Flags().StringVar(&outFile, "out", "", "Write to a file instead of stdout")
+	return exportCmd
+}
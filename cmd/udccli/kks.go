@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/thornzero/udc_codec/pkg/assettag"
+	"github.com/thornzero/udc_codec/pkg/classification"
+)
+
+// kksResult is a parsed KKS code and, when converted, its project tag
+type kksResult struct {
+	Code        string            `json:"code" yaml:"code"`
+	Levels      *assettag.KKSCode `json:"levels" yaml:"levels"`
+	Description string            `json:"description" yaml:"description"`
+	Tag         string            `json:"tag,omitempty" yaml:"tag,omitempty"`
+	Designation string            `json:"designation,omitempty" yaml:"designation,omitempty"`
+	UDC         string            `json:"udc,omitempty" yaml:"udc,omitempty"`
+}
+
+// validateKKSTag validates a converted tag. Equipment units without an ISA
+// function convert to a designation only, so only the designation and UDC
+// code are checked for them.
+func validateKKSTag(resolver *assettag.Resolver, tag *assettag.Tag) error {
+	if tag.FunctionCode != "" {
+		return resolver.ValidateTag(tag)
+	}
+	if tag.UDCCode != "" {
+		if err := resolver.Schemes.Validate(classification.UDC, tag.UDCCode); err != nil {
+			return fmt.Errorf("unknown UDC code: %s", tag.UDCCode)
+		}
+	}
+	if tag.Designation == nil {
+		return nil
+	}
+	classes, err := resolver.Schemes.Scheme(classification.IEC81346)
+	if err != nil {
+		return err
+	}
+	return tag.Designation.Validate(classes)
+}
+
+func newKKSCmd() *cobra.Command {
+	var kksCmd = &cobra.Command{
+		Use:   "kks",
+		Short: "Parse, validate and convert KKS power-plant codes",
+	}
+
+	var parseCmd = &cobra.Command{
+		Use:   "parse [code...]",
+		Short: "Split KKS codes into breakdown levels and check their keys",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			keys, _, err := assettag.LoadKKS(dataDir)
+			if err != nil {
+				exitWithError(1, "Error loading KKS tables", err)
+			}
+			var results []kksResult
+			t := table{headers: []string{"code", "plant", "system", "equipment", "component", "description"}}
+			failed := false
+			for _, arg := range args {
+				k, err := assettag.ParseKKS(arg)
+				if err == nil {
+					err = keys.Validate(k)
+				}
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+					failed = true
+					continue
+				}
+				description := keys.Describe(k)
+				results = append(results, kksResult{Code: k.String(), Levels: k, Description: description})
+				t.rows = append(t.rows, []string{
+					k.String(),
+					k.Plant,
+					k.Prefix + k.FunctionKey + k.SystemNumber,
+					k.EquipmentKey + k.EquipmentNumber + k.Additional,
+					k.ComponentKey + k.ComponentNumber,
+					description,
+				})
+			}
+			if err := printResult(results, t); err != nil {
+				exitWithError(1, "Error writing output", err)
+			}
+			if failed {
+				exitWithError(1, "Error parsing KKS codes", fmt.Errorf("one or more codes are invalid"))
+			}
+		},
+	}
+
+	var schemeName string
+	var convertCmd = &cobra.Command{
+		Use:   "convert [code...]",
+		Short: "Convert KKS codes into project tags and IEC 81346 designations",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			keys, rules, err := assettag.LoadKKS(dataDir)
+			if err != nil {
+				exitWithError(1, "Error loading KKS tables", err)
+			}
+			scheme := loadTagScheme(schemeName)
+			resolver := loadResolver()
+
+			var results []kksResult
+			t := table{headers: []string{"code", "tag", "designation", "udc"}}
+			failed := false
+			for _, arg := range args {
+				k, err := assettag.ParseKKS(arg)
+				if err == nil {
+					err = keys.Validate(k)
+				}
+				var tag *assettag.Tag
+				if err == nil {
+					tag, err = rules.Convert(k)
+				}
+				if err == nil {
+					err = validateKKSTag(resolver, tag)
+				}
+				var formatted string
+				if err == nil && tag.FunctionCode != "" {
+					formatted = scheme.Format(tag)
+					if _, parseErr := scheme.Parse(formatted); parseErr != nil {
+						err = fmt.Errorf("converted tag does not fit scheme %s: %w", scheme.Name, parseErr)
+					}
+				}
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "❌ %s: %v\n", arg, err)
+					failed = true
+					continue
+				}
+
+				result := kksResult{Code: k.String(), Levels: k, Description: keys.Describe(k), Tag: formatted, UDC: tag.UDCCode}
+				if tag.Designation != nil {
+					result.Designation = tag.Designation.String()
+				}
+				results = append(results, result)
+				t.rows = append(t.rows, []string{result.Code, result.Tag, result.Designation, result.UDC})
+			}
+			if err := printResult(results, t); err != nil {
+				exitWithError(1, "Error writing output", err)
+			}
+			if failed {
+				exitWithError(1, "Error converting KKS codes", fmt.Errorf("one or more codes could not be converted"))
+			}
+		},
+	}
+	convertCmd.Flags().StringVar(&schemeName, "scheme", assettag.DefaultTagSchemeName, "Tag scheme to format converted tags with")
+
+	kksCmd.AddCommand(parseCmd, convertCmd)
+	return kksCmd
+}
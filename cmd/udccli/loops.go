@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/thornzero/udc_codec/pkg/assettag"
+	"github.com/thornzero/udc_codec/pkg/classification"
+	"github.com/thornzero/udc_codec/pkg/pipeline"
+)
+
+func newLoopsCmd() *cobra.Command {
+	var (
+		schemeName string
+		fromFile   string
+		summary    bool
+	)
+
+	var loopsCmd = &cobra.Command{
+		Use:   "loops [tag...]",
+		Short: "Group tags into ISA loops and flag loops with mixed measured variables",
+		Run: func(cmd *cobra.Command, args []string) {
+			names := args
+			if fromFile != "" {
+				listed, err := readTagList(fromFile)
+				if err != nil {
+					exitWithError(1, "Error reading tag list", err)
+				}
+				names = append(names, listed...)
+			}
+			if len(names) == 0 {
+				exitWithError(1, "No tags given", fmt.Errorf("pass tags as arguments or with --from"))
+			}
+
+			scheme := loadTagScheme(schemeName)
+			var tags []*assettag.Tag
+			for _, name := range names {
+				tag, err := scheme.Parse(name)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+					continue
+				}
+				tags = append(tags, tag)
+			}
+
+			resolver := loadResolver()
+			letters, _ := resolver.ISALetters()
+			list := pipeline.BuildLoopList("", tags, letters, func(code string) string {
+				name, _ := resolver.Schemes.Lookup(classification.Systems, code)
+				return name
+			})
+
+			if summary {
+				t := table{headers: []string{"system", "variable", "loops", "instruments", "inconsistent"}}
+				for _, s := range list.Summary {
+					t.rows = append(t.rows, []string{s.System, strings.TrimSpace(s.Variable + " " + s.VariableName), strconv.Itoa(s.Loops), strconv.Itoa(s.Instruments), strconv.Itoa(s.Inconsistent)})
+				}
+				if err := printResult(list.Summary, t); err != nil {
+					exitWithError(1, "Error writing output", err)
+				}
+				return
+			}
+
+			t := table{headers: []string{"system", "loop", "variable", "tags", "issues"}}
+			for _, r := range list.Loops {
+				t.rows = append(t.rows, []string{r.System, r.Loop, strings.TrimSpace(r.Variable + " " + r.VariableName), strings.Join(r.Tags, " "), strings.Join(r.Issues, "; ")})
+			}
+			if err := printResult(list.Loops, t); err != nil {
+				exitWithError(1, "Error writing output", err)
+			}
+		},
+	}
+	loopsCmd.Flags().StringVar(&schemeName, "scheme", assettag.DefaultTagSchemeName, "Tag scheme to parse with")
+	loopsCmd.Flags().StringVar(&fromFile, "from", "", "Read tags from a file, one per line")
+	loopsCmd.Flags().BoolVar(&summary, "summary", false, "Show loop counts per system and measured variable")
+	return loopsCmd
+}
@@ -1,147 +1,138 @@
 package main
 
 import (
-	"fmt"
 	"os"
-	"strings"
+	"path/filepath"
 
 	"github.com/spf13/cobra"
 
+	"github.com/thornzero/udc_codec/pkg/assettag"
+	"github.com/thornzero/udc_codec/pkg/config"
+	"github.com/thornzero/udc_codec/pkg/dictionary"
 	"github.com/thornzero/udc_codec/pkg/udc"
 )
 
+// Flags shared by every command
+var (
+	outputFormat string
+	dataDir      string
+	language     string
+)
+
+// udcFile returns the path of the main UDC data file in the data directory
+func udcFile() string {
+	return filepath.Join(dataDir, "udc_full.yaml")
+}
+
+// loadCodec loads the UDC codec from the data directory, exiting on failure.
+// Searches expand abbreviations with the dictionary for --lang.
+func loadCodec() *udc.Codec {
+	codec, err := udc.LoadCodec(udcFile())
+	if err != nil {
+		exitWithError(1, "Error loading codec", err)
+	}
+	codec.SetExpander(loadDictionary())
+	return codec
+}
+
+// loadResolver loads and checks the reference data in --data-dir, exiting on
+// failure
+func loadResolver() *assettag.Resolver {
+	cfg := *config.Load()
+	cfg.DataDir, cfg.Language = dataDir, language
+	resolver, err := assettag.NewResolver(&cfg)
+	if err != nil {
+		exitWithError(1, "Error loading reference data", err)
+	}
+	return resolver
+}
+
+// loadDictionary loads the abbreviation dictionary for --lang, exiting on failure
+func loadDictionary() *dictionary.Dictionary {
+	dict, err := dictionary.Load(dataDir, language)
+	if err != nil {
+		exitWithError(1, "Error loading dictionary", err)
+	}
+	return dict
+}
+
 func main() {
-	var rootCmd = &cobra.Command{Use: "udccli"}
+	var rootCmd = &cobra.Command{
+		Use: "udccli",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return checkOutputFormat()
+		},
+	}
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", outputTable, "Output format (table, json, yaml or csv)")
+	rootCmd.PersistentFlags().StringVar(&dataDir, "data-dir", config.Load().DataDir, "Directory containing udc_full.yaml and addendum files")
+	rootCmd.PersistentFlags().StringVar(&language, "lang", config.Load().Language, "Language of the abbreviation dictionary")
 
 	var scrapeCmd = &cobra.Command{
 		Use:   "scrape",
 		Short: "Production-grade full UDC recursive scrape",
 		Run: func(cmd *cobra.Command, args []string) {
-			err := udc.ScrapeFullHierarchy("data/udc_full.yaml")
+			err := udc.ScrapeFullHierarchy(udcFile())
 			if err != nil {
 				panic(err)
 			}
-			fmt.Println("✅ UDC recursive production scrape complete!")
+			printMessage("✅ UDC recursive production scrape complete!")
 		},
 	}
 
-	var lookupCmd = &cobra.Command{
-		Use:   "lookup [code]",
-		Short: "Lookup a UDC code",
-		Args:  cobra.ExactArgs(1),
+	var lintCmd = &cobra.Command{
+		Use:   "lint",
+		Short: "Check udc_full.yaml and addendum files for consistency problems",
 		Run: func(cmd *cobra.Command, args []string) {
-			codec, err := udc.LoadCodec("data/udc_full.yaml")
+			issues, err := udc.LintFile(udcFile())
 			if err != nil {
-				fmt.Println("Error loading codec:", err)
-				os.Exit(1)
+				exitWithError(2, "Error linting UDC data", err)
 			}
-			title, ok := codec.Lookup(args[0])
-			if ok {
-				fmt.Printf("%s => %s\n", args[0], title)
-			} else {
-				fmt.Println("Code not found.")
+			if issues == nil {
+				issues = []udc.LintIssue{}
 			}
-		},
-	}
 
-	var addendumCmd = &cobra.Command{
-		Use:   "addendum",
-		Short: "Manage UDC addendum files",
-	}
-
-	var listAddendumsCmd = &cobra.Command{
-		Use:   "list",
-		Short: "List all addendum files",
-		Run: func(cmd *cobra.Command, args []string) {
-			am := udc.NewAddendumManager("data")
-			addendums, err := am.ListAddendums()
-			if err != nil {
-				fmt.Println("Error listing addendums:", err)
-				os.Exit(1)
-			}
-			if len(addendums) == 0 {
-				fmt.Println("No addendum files found.")
-				return
+			t := table{headers: []string{"severity", "rule", "code", "parent", "expected", "source", "message"}}
+			for _, issue := range issues {
+				t.rows = append(t.rows, []string{issue.Severity, issue.Rule, issue.Code, issue.Parent, issue.Expected, issue.Source, issue.Message})
 			}
-			fmt.Println("Addendum files:")
-			for _, addendum := range addendums {
-				fmt.Printf("  - %s\n", addendum)
-			}
-		},
-	}
-
-	var addAddendumCmd = &cobra.Command{
-		Use:   "add [code] [title] [filename]",
-		Short: "Add a classification to an addendum file (filename is optional)",
-		Args:  cobra.RangeArgs(2, 3),
-		Run: func(cmd *cobra.Command, args []string) {
-			code := args[0]
-			title := args[1]
-			var filename string
-			if len(args) == 3 {
-				filename = args[2]
+			if err := printResult(issues, t); err != nil {
+				exitWithError(2, "Error writing lint report", err)
 			}
 
-			node := &udc.Node{
-				Code:  code,
-				Title: title,
-			}
-
-			am := udc.NewAddendumManager("data")
-			err := am.Add(filename, []*udc.Node{node})
-			if err != nil {
-				fmt.Println("Error adding to addendum:", err)
-				os.Exit(1)
-			}
-
-			// Determine the actual filename used
-			actualFilename := filename
-			if actualFilename == "" {
-				actualFilename = "default"
-			}
-			if !strings.HasPrefix(actualFilename, "udc_addendum_") {
-				actualFilename = "udc_addendum_" + actualFilename
+			for _, issue := range issues {
+				if issue.Severity == udc.SeverityError {
+					os.Exit(1)
+				}
 			}
-			if !strings.HasSuffix(actualFilename, ".yaml") {
-				actualFilename = actualFilename + ".yaml"
-			}
-
-			fmt.Printf("✅ Added classification to addendum file: %s\n", actualFilename)
 		},
 	}
 
-	var deleteAddendumCmd = &cobra.Command{
-		Use:   "delete [filename]",
-		Short: "Delete an addendum file",
-		Args:  cobra.ExactArgs(1),
+	var browseCmd = &cobra.Command{
+		Use:   "browse",
+		Short: "Browse the UDC hierarchy in a full-screen terminal UI",
 		Run: func(cmd *cobra.Command, args []string) {
-			filename := args[0]
-
-			// Ensure filename has correct format
-			if !strings.HasPrefix(filename, "udc_addendum_") {
-				filename = "udc_addendum_" + filename
-			}
-			if !strings.HasSuffix(filename, ".yaml") {
-				filename = filename + ".yaml"
-			}
-
-			am := udc.NewAddendumManager("data")
-			err := am.DeleteAddendum(filename)
-			if err != nil {
-				fmt.Println("Error deleting addendum:", err)
-				os.Exit(1)
+			if err := runBrowser(loadCodec()); err != nil {
+				exitWithError(1, "Error running browser", err)
 			}
-			fmt.Printf("✅ Deleted addendum file: %s\n", filename)
 		},
 	}
 
 	rootCmd.AddCommand(scrapeCmd)
-	rootCmd.AddCommand(lookupCmd)
-
-	addendumCmd.AddCommand(listAddendumsCmd)
-	addendumCmd.AddCommand(addAddendumCmd)
-	addendumCmd.AddCommand(deleteAddendumCmd)
-	rootCmd.AddCommand(addendumCmd)
-
-	rootCmd.Execute()
+	rootCmd.AddCommand(lintCmd)
+	rootCmd.AddCommand(browseCmd)
+	addCodecCommands(rootCmd)
+	rootCmd.AddCommand(newRenderCmd())
+	rootCmd.AddCommand(newAddendumCmd())
+	rootCmd.AddCommand(newCrosswalkCmd())
+	rootCmd.AddCommand(newSuggestCmd())
+	rootCmd.AddCommand(newDictionaryCmd())
+	rootCmd.AddCommand(newTagsCmd())
+	rootCmd.AddCommand(newBOMCmd())
+	rootCmd.AddCommand(newRulesCmd())
+	rootCmd.AddCommand(newPipelineCmd())
+	rootCmd.AddCommand(newExportCmd())
+
+	if err := rootCmd.Execute(); err != nil {
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/thornzero/udc_codec/pkg/config"
+	"github.com/thornzero/udc_codec/pkg/db"
+	"github.com/thornzero/udc_codec/pkg/migration"
+)
+
+// readTagList reads one tag per line, skipping blank lines and # comments
+func readTagList(filename string) ([]string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var tags []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			tags = append(tags, line)
+		}
+	}
+	return tags, scanner.Err()
+}
+
+// migrationTable lists every legacy tag of a report with its outcome
+func migrationTable(report *migration.Report) table {
+	t := table{headers: []string{"old", "new", "rule", "status", "reason"}}
+	for _, r := range report.Mapped {
+		t.rows = append(t.rows, []string{r.Old, r.New, r.Rule, "mapped", ""})
+	}
+	for _, c := range report.Conflicts {
+		for _, old := range c.Old {
+			t.rows = append(t.rows, []string{old, c.New, "", "conflict", c.Reason})
+		}
+	}
+	for _, r := range report.Unmapped {
+		t.rows = append(t.rows, []string{r.Old, "", r.Rule, "unmapped", r.Reason})
+	}
+	for _, old := range report.Unchanged {
+		t.rows = append(t.rows, []string{old, old, "", "unchanged", ""})
+	}
+	return t
+}
+
+func newMigrateCmd() *cobra.Command {
+	var (
+		rulesFile  string
+		schemeName string
+		dbPath     string
+		fromFile   string
+		apply      bool
+		noValidate bool
+	)
+
+	var migrateCmd = &cobra.Command{
+		Use:   "migrate",
+		Short: "Rewrite legacy tags into the tag scheme with migration rules (dry run unless --apply)",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if fromFile != "" && apply {
+				exitWithError(1, "Error applying migration", fmt.Errorf("--apply renames tags in the tag database and cannot be used with --from"))
+			}
+			if rulesFile == "" {
+				rulesFile = filepath.Join(dataDir, migration.RulesFile)
+			}
+			rules, err := migration.LoadRules(rulesFile)
+			if err != nil {
+				exitWithError(1, "Error loading migration rules", err)
+			}
+			if schemeName == "" {
+				schemeName = rules.Scheme
+			}
+			migrator, err := migration.New(rules, loadTagScheme(schemeName), loadDictionary())
+			if err != nil {
+				exitWithError(1, "Error loading migration rules", err)
+			}
+			if !noValidate {
+				migrator.Resolver = loadResolver()
+			}
+
+			var store *db.Store
+			if fromFile == "" {
+				if store, err = db.OpenDB(dbPath); err != nil {
+					exitWithError(1, "Error opening tag database", err)
+				}
+				if err := store.Migrate(); err != nil {
+					exitWithError(1, "Error migrating tag database", err)
+				}
+			}
+
+			var report *migration.Report
+			if fromFile != "" {
+				legacy, err := readTagList(fromFile)
+				if err != nil {
+					exitWithError(1, "Error reading tag list", err)
+				}
+				report = migrator.Plan(legacy, nil)
+			} else if report, err = migrator.PlanStore(store); err != nil {
+				exitWithError(1, "Error planning migration", err)
+			}
+
+			if err := printResult(report, migrationTable(report)); err != nil {
+				exitWithError(1, "Error writing output", err)
+			}
+			printMessage("\n%d mapped, %d unchanged, %d conflicting, %d unmapped", len(report.Mapped), len(report.Unchanged), len(report.Conflicts), len(report.Unmapped))
+
+			if !apply {
+				return
+			}
+			if err := migration.Apply(store, report); err != nil {
+				exitWithError(1, "Error applying migration", err)
+			}
+			printMessage("✅ Renamed %d tags, legacy tags kept as aliases", len(report.Mapped))
+		},
+	}
+	migrateCmd.Flags().StringVar(&rulesFile, "rules", "", "Migration rules file (default <data-dir>/"+migration.RulesFile+")")
+	migrateCmd.Flags().StringVar(&schemeName, "scheme", "", "Target tag scheme (default from the rules file)")
+	migrateCmd.Flags().StringVar(&dbPath, "db", config.Load().DBPath, "Tag database to migrate")
+	migrateCmd.Flags().StringVar(&fromFile, "from", "", "Plan a list of legacy tags, one per line, instead of the tag database")
+	migrateCmd.Flags().BoolVar(&apply, "apply", false, "Rename the mapped tags in the tag database")
+	migrateCmd.Flags().BoolVar(&noValidate, "no-validate", false, "Only check new tags against the tag scheme, not the classification schemes")
+	return migrateCmd
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/thornzero/udc_codec/pkg/allocator"
+	"github.com/thornzero/udc_codec/pkg/config"
+	"github.com/thornzero/udc_codec/pkg/db"
+)
+
+func newNumbersCmd() *cobra.Command {
+	var (
+		dbPath string
+		req    allocator.Request
+	)
+
+	// loadAllocator opens the tag DB and the numbering ranges, exiting on failure
+	loadAllocator := func() *allocator.Allocator {
+		cfg, err := allocator.LoadConfigDir(dataDir)
+		if err != nil {
+			exitWithError(1, "Error loading numbering ranges", err)
+		}
+		store, err := db.OpenDB(dbPath)
+		if err != nil {
+			exitWithError(1, "Error opening tag database", err)
+		}
+		if err := store.Migrate(); err != nil {
+			exitWithError(1, "Error migrating tag database", err)
+		}
+		a, err := allocator.New(store, cfg)
+		if err != nil {
+			exitWithError(1, "Error loading numbering ranges", err)
+		}
+		return a
+	}
+
+	var numbersCmd = &cobra.Command{
+		Use:   "numbers",
+		Short: "Allocate, reserve and release loop numbers from the ranges in " + allocator.NumberingFile,
+	}
+	numbersCmd.PersistentFlags().StringVar(&dbPath, "db", config.Load().DBPath, "Tag database holding the reservations")
+	numbersCmd.PersistentFlags().StringVar(&req.System, "system", "", "System code")
+	numbersCmd.PersistentFlags().StringVar(&req.Function, "function", "", "ISA function code")
+	numbersCmd.PersistentFlags().StringVar(&req.Area, "area", "", "Area")
+	numbersCmd.PersistentFlags().StringVar(&req.Tag, "tag", "", "Tag to record with the reservation")
+
+	var count int
+	var nextCmd = &cobra.Command{
+		Use:   "next",
+		Short: "Reserve the next free loop numbers",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			a := loadAllocator()
+			r, err := a.Range(req)
+			if err != nil {
+				exitWithError(1, "Error allocating loop number", err)
+			}
+			pool := r.Pool(req)
+			var numbers []string
+			t := table{headers: []string{"pool", "number"}}
+			for i := 0; i < count; i++ {
+				number, err := a.Allocate(req)
+				if err != nil {
+					exitWithError(1, "Error allocating loop number", err)
+				}
+				numbers = append(numbers, number)
+				t.rows = append(t.rows, []string{pool, number})
+			}
+			if err := printResult(numbers, t); err != nil {
+				exitWithError(1, "Error writing output", err)
+			}
+		},
+	}
+	nextCmd.Flags().IntVarP(&count, "count", "n", 1, "Number of loop numbers to reserve")
+
+	var reserveCmd = &cobra.Command{
+		Use:   "reserve [number...]",
+		Short: "Reserve specific loop numbers, e.g. ones used by existing tags",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			a := loadAllocator()
+			for _, number := range args {
+				ok, err := a.Reserve(req, number)
+				if err != nil {
+					exitWithError(1, "Error reserving loop number", err)
+				}
+				if ok {
+					printMessage("✅ Reserved %s", number)
+				} else {
+					printMessage("%s was already reserved", number)
+				}
+			}
+		},
+	}
+
+	var releaseCmd = &cobra.Command{
+		Use:   "release [number...]",
+		Short: "Release reserved loop numbers",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			a := loadAllocator()
+			for _, number := range args {
+				if err := a.Release(req, number); err != nil {
+					exitWithError(1, "Error releasing loop number", err)
+				}
+				printMessage("✅ Released %s", number)
+			}
+		},
+	}
+
+	var listCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List the reservations in the pool for --system, --function and --area",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			numbers, err := loadAllocator().Numbers(req)
+			if err != nil {
+				exitWithError(1, "Error listing loop numbers", err)
+			}
+			if numbers == nil {
+				numbers = []db.NumberReservation{}
+			}
+			t := table{headers: []string{"pool", "number", "status", "tag"}}
+			for _, n := range numbers {
+				t.rows = append(t.rows, []string{n.Pool, strconv.Itoa(n.Number), n.Status, n.Tag})
+			}
+			if err := printResult(numbers, t); err != nil {
+				exitWithError(1, "Error writing output", err)
+			}
+		},
+	}
+
+	var rangesCmd = &cobra.Command{
+		Use:   "ranges",
+		Short: "List the configured numbering ranges",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg, err := allocator.LoadConfigDir(dataDir)
+			if err != nil {
+				exitWithError(1, "Error loading numbering ranges", err)
+			}
+			a, err := allocator.New(nil, cfg)
+			if err != nil {
+				exitWithError(1, "Error loading numbering ranges", err)
+			}
+			t := table{headers: []string{"name", "system", "function", "area", "range", "by", "reuse"}}
+			for _, r := range a.Ranges() {
+				t.rows = append(t.rows, []string{r.Name, r.System, r.Function, r.Area, fmt.Sprintf("%s-%s", r.Format(r.Min), r.Format(r.Max)), fmt.Sprint(r.By), strconv.FormatBool(r.Reuse)})
+			}
+			if err := printResult(a.Ranges(), t); err != nil {
+				exitWithError(1, "Error writing output", err)
+			}
+		},
+	}
+
+	numbersCmd.AddCommand(nextCmd, reserveCmd, releaseCmd, listCmd, rangesCmd)
+	return numbersCmd
+}
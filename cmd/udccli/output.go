@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats accepted by --output
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+	outputCSV   = "csv"
+)
+
+// table is the tabular form of a command result, used for table and csv output
+type table struct {
+	headers []string
+	rows    [][]string
+}
+
+// checkOutputFormat rejects unknown --output values before a command runs
+func checkOutputFormat() error {
+	switch outputFormat {
+	case outputTable, outputJSON, outputYAML, outputCSV:
+		return nil
+	}
+	return fmt.Errorf("unknown output format %q (use table, json, yaml or csv)", outputFormat)
+}
+
+// printResult writes a command result to stdout in the selected output format.
+// JSON and YAML encode value directly; table and csv render t.
+func printResult(value any, t table) error {
+	switch outputFormat {
+	case outputJSON:
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	case outputYAML:
+		encoder := yaml.NewEncoder(os.Stdout)
+		encoder.SetIndent(2)
+		return encoder.Encode(value)
+	case outputCSV:
+		w := csv.NewWriter(os.Stdout)
+		if err := w.Write(t.headers); err != nil {
+			return err
+		}
+		if err := w.WriteAll(t.rows); err != nil {
+			return err
+		}
+		return w.Error()
+	default:
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		headers := make([]string, len(t.headers))
+		for i, h := range t.headers {
+			headers[i] = strings.ToUpper(h)
+		}
+		fmt.Fprintln(w, strings.Join(headers, "\t"))
+		for _, row := range t.rows {
+			fmt.Fprintln(w, strings.Join(row, "\t"))
+		}
+		return w.Flush()
+	}
+}
+
+// printMessage prints a human-readable status line, suppressed for
+// structured output so scripts only see the result on stdout
+func printMessage(format string, args ...any) {
+	if outputFormat == outputTable {
+		fmt.Printf(format+"\n", args...)
+	}
+}
+
+// exitWithError prints an error to stderr and exits with the given status
+func exitWithError(status int, msg string, err error) {
+	fmt.Fprintf(os.Stderr, "%s: %v\n", msg, err)
+	os.Exit(status)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/thornzero/udc_codec/pkg/config"
+	"github.com/thornzero/udc_codec/pkg/pipeline"
+	"github.com/thornzero/udc_codec/pkg/stages"
+)
+
+func newPipelineCmd() *cobra.Command {
+	var pipelineCmd = &cobra.Command{
+		Use:   "pipeline",
+		Short: "Show the BOM pipeline run by the web upload and the autopipeline",
+	}
+
+	var file string
+	var showCmd = &cobra.Command{
+		Use:   "show",
+		Short: "List the stages of the pipeline definition in the order they run",
+		Run: func(cmd *cobra.Command, args []string) {
+			def, err := pipeline.LoadDefinitionDir(dataDir)
+			if file != "" {
+				def, err = pipeline.LoadDefinition(file)
+			}
+			if err != nil {
+				exitWithError(1, "Error loading pipeline", err)
+			}
+			cfg := *config.Load()
+			cfg.DataDir = dataDir
+			runner := &pipeline.Runner{Stages: stages.New(stages.NewEnv(&cfg))}
+			if err := runner.Check(def); err != nil {
+				exitWithError(1, "Invalid pipeline", err)
+			}
+
+			order, _ := def.Order()
+			t := table{headers: []string{"stage", "type", "needs", "config"}}
+			for _, s := range order {
+				var settings []string
+				for k, v := range s.Config {
+					settings = append(settings, fmt.Sprintf("%s=%v", k, v))
+				}
+				sort.Strings(settings)
+				t.rows = append(t.rows, []string{s.ID, s.Type(), strings.Join(s.Needs, ", "), strings.Join(settings, " ")})
+			}
+			if err := printResult(order, t); err != nil {
+				exitWithError(1, "Error writing output", err)
+			}
+		},
+	}
+	showCmd.Flags().StringVar(&file, "file", "", "Pipeline definition (default: "+pipeline.DefinitionFile+" in the data directory, or the built-in pipeline)")
+
+	pipelineCmd.AddCommand(showCmd)
+	return pipelineCmd
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/thornzero/udc_codec/pkg/config"
+	"github.com/thornzero/udc_codec/pkg/db"
+	"github.com/thornzero/udc_codec/pkg/udc"
+)
+
+func newRenderCmd() *cobra.Command {
+	var (
+		format    string
+		outFile   string
+		depth     int
+		title     string
+		tagCounts bool
+		dbPath    string
+	)
+
+	var renderCmd = &cobra.Command{
+		Use:   "render [code]",
+		Short: "Render a UDC subtree as Graphviz DOT, Mermaid or a static HTML page",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			codec := loadCodec()
+
+			opts := udc.RenderOptions{Depth: depth, Title: title}
+			if tagCounts {
+				store, err := db.OpenDB(dbPath)
+				if err != nil {
+					exitWithError(1, "Error opening tag database", err)
+				}
+				if err := store.Migrate(); err != nil {
+					exitWithError(1, "Error migrating tag database", err)
+				}
+				opts.Counts, err = store.CountTagsByUDC()
+				if err != nil {
+					exitWithError(1, "Error counting tags", err)
+				}
+			}
+
+			var render func(w io.Writer, code string, opts udc.RenderOptions) error
+			switch format {
+			case "dot":
+				render = codec.RenderDOT
+			case "mermaid":
+				render = codec.RenderMermaid
+			case "html":
+				render = codec.RenderHTML
+			default:
+				exitWithError(1, "Error rendering", fmt.Errorf("unknown format %q (use dot, mermaid or html)", format))
+			}
+
+			var w io.Writer = os.Stdout
+			if outFile != "" {
+				f, err := os.Create(outFile)
+				if err != nil {
+					exitWithError(1, "Error creating output file", err)
+				}
+				defer f.Close()
+				w = f
+			}
+
+			if err := render(w, args[0], opts); err != nil {
+				exitWithError(1, "Error rendering", err)
+			}
+			if outFile != "" {
+				printMessage("✅ Rendered %s to %s", args[0], outFile)
+			}
+		},
+	}
+	renderCmd.Flags().StringVar(&format, "format", "dot", "Render format (dot, mermaid or html)")
+	renderCmd.Flags().StringVarP(&outFile, "file", "f", "", "File to write to (default stdout)")
+	renderCmd.Flags().IntVar(&depth, "depth", 0, "Number of levels below the code to render (0 for all)")
+	renderCmd.Flags().StringVar(&title, "title", "", "Graph or page title")
+	renderCmd.Flags().BoolVar(&tagCounts, "tag-counts", false, "Overlay the number of tags classified under each node")
+	renderCmd.Flags().StringVar(&dbPath, "db", config.Load().DBPath, "Tag database used for --tag-counts")
+	return renderCmd
+}
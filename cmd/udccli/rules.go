@@ -0,0 +1,47 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/thornzero/udc_codec/pkg/rules"
+)
+
+// loadRules loads the validation rules, exiting on failure
+func loadRules() *rules.Set {
+	set, err := rules.LoadDir(dataDir)
+	if err != nil {
+		exitWithError(1, "Error loading validation rules", err)
+	}
+	return set
+}
+
+func newRulesCmd() *cobra.Command {
+	var rulesCmd = &cobra.Command{
+		Use:   "rules",
+		Short: "Show the project validation rules in " + rules.RulesFile,
+	}
+
+	var project string
+	var listCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List the rules that apply to a project",
+		Run: func(cmd *cobra.Command, args []string) {
+			set := loadRules()
+			applied := set.For(project)
+			t := table{headers: []string{"id", "severity", "when", "require", "message"}}
+			for _, r := range applied {
+				t.rows = append(t.rows, []string{r.ID, r.Severity, r.When, r.Require, r.Violation()})
+			}
+			if err := printResult(applied, t); err != nil {
+				exitWithError(1, "Error writing output", err)
+			}
+			if project == "" && len(set.Projects) > 0 {
+				printMessage("\nProjects with their own rules: %v", set.ProjectNames())
+			}
+		},
+	}
+	listCmd.Flags().StringVar(&project, "project", "", "Project whose rules to list (default: the shared rules only)")
+
+	rulesCmd.AddCommand(listCmd)
+	return rulesCmd
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/thornzero/udc_codec/pkg/config"
+	"github.com/thornzero/udc_codec/pkg/db"
+	"github.com/thornzero/udc_codec/pkg/suggest"
+)
+
+func newSuggestCmd() *cobra.Command {
+	var (
+		limit   int
+		dbPath  string
+		noTrain bool
+	)
+
+	var suggestCmd = &cobra.Command{
+		Use:   "suggest [description]",
+		Short: "Suggest UDC codes for an equipment description",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			classifier := suggest.New(loadCodec(), loadDictionary())
+			if !noTrain {
+				if _, err := os.Stat(dbPath); err == nil {
+					store, err := db.OpenDB(dbPath)
+					if err != nil {
+						exitWithError(1, "Error opening tag database", err)
+					}
+					if err := store.Migrate(); err != nil {
+						exitWithError(1, "Error migrating tag database", err)
+					}
+					if err := classifier.TrainFromStore(store); err != nil {
+						exitWithError(1, "Error training on classified tags", err)
+					}
+				}
+			}
+
+			suggestions := classifier.Suggest(strings.Join(args, " "), limit)
+			if len(suggestions) == 0 && outputFormat == outputTable {
+				fmt.Fprintln(os.Stderr, "No suggestions found.")
+				os.Exit(1)
+			}
+			if suggestions == nil {
+				suggestions = []suggest.Suggestion{}
+			}
+
+			t := table{headers: []string{"code", "score", "title", "model"}}
+			for _, s := range suggestions {
+				t.rows = append(t.rows, []string{s.Code, fmt.Sprintf("%.3f", s.Score), s.Title, fmt.Sprintf("%.3f", s.ModelScore)})
+			}
+			if err := printResult(suggestions, t); err != nil {
+				exitWithError(1, "Error writing output", err)
+			}
+		},
+	}
+	suggestCmd.Flags().IntVar(&limit, "limit", 5, "Maximum number of suggestions")
+	suggestCmd.Flags().StringVar(&dbPath, "db", config.Load().DBPath, "Tag database to train on")
+	suggestCmd.Flags().BoolVar(&noTrain, "no-train", false, "Only match against UDC titles and notes")
+	return suggestCmd
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/thornzero/udc_codec/pkg/assettag"
+)
+
+// tagResult is a parsed tag
+type tagResult struct {
+	Tag      string            `json:"tag" yaml:"tag"`
+	Scheme   string            `json:"scheme" yaml:"scheme"`
+	Segments map[string]string `json:"segments" yaml:"segments"`
+}
+
+// loadTagScheme loads a tag scheme from the data directory, exiting on failure
+func loadTagScheme(name string) *assettag.TagScheme {
+	schemes, err := assettag.LoadTagSchemesDir(dataDir)
+	if err != nil {
+		exitWithError(1, "Error loading tag schemes", err)
+	}
+	scheme, err := schemes.Get(name)
+	if err != nil {
+		exitWithError(1, "Error loading tag schemes", err)
+	}
+	return scheme
+}
+
+func newTagsCmd() *cobra.Command {
+	var tagsCmd = &cobra.Command{
+		Use:   "tags",
+		Short: "Parse and format asset tags with the configured tag schemes",
+	}
+
+	var schemesCmd = &cobra.Command{
+		Use:   "schemes",
+		Short: "List the tag schemes in tag_schemes.yaml",
+		Run: func(cmd *cobra.Command, args []string) {
+			schemes, err := assettag.LoadTagSchemesDir(dataDir)
+			if err != nil {
+				exitWithError(1, "Error loading tag schemes", err)
+			}
+			var results []*assettag.TagScheme
+			t := table{headers: []string{"name", "example", "segments", "description"}}
+			for _, name := range schemes.Names() {
+				s, _ := schemes.Get(name)
+				results = append(results, s)
+				var segments []string
+				for _, seg := range s.Segments {
+					segments = append(segments, seg.Name)
+				}
+				t.rows = append(t.rows, []string{s.Name, s.Example, strings.Join(segments, ","), s.Description})
+			}
+			if err := printResult(results, t); err != nil {
+				exitWithError(1, "Error writing output", err)
+			}
+		},
+	}
+
+	var schemeName string
+	var parseCmd = &cobra.Command{
+		Use:   "parse [tag...]",
+		Short: "Split tags into their segments",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			scheme := loadTagScheme(schemeName)
+			var results []tagResult
+			t := table{headers: []string{"tag", "segment", "value"}}
+			failed := false
+			for _, arg := range args {
+				tag, err := scheme.Parse(arg)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+					failed = true
+					continue
+				}
+				results = append(results, tagResult{Tag: arg, Scheme: scheme.Name, Segments: tag.Segments})
+				for _, seg := range scheme.Segments {
+					if value, ok := tag.Segments[seg.Name]; ok {
+						t.rows = append(t.rows, []string{arg, seg.Name, value})
+					}
+				}
+			}
+			if err := printResult(results, t); err != nil {
+				exitWithError(1, "Error writing output", err)
+			}
+			if failed {
+				exitWithError(1, "Error parsing tags", fmt.Errorf("one or more tags do not match scheme %s", scheme.Name))
+			}
+		},
+	}
+	parseCmd.Flags().StringVar(&schemeName, "scheme", assettag.DefaultTagSchemeName, "Tag scheme to parse with")
+
+	var formatCmd = &cobra.Command{
+		Use:   "format [segment=value...]",
+		Short: "Build a tag from segment values, e.g. area=21 unit=U2 function=PIT number=42",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			scheme := loadTagScheme(schemeName)
+			values := make(map[string]string)
+			for _, arg := range args {
+				name, value, ok := strings.Cut(arg, "=")
+				if !ok {
+					exitWithError(1, "Invalid segment value", fmt.Errorf("expected segment=value, got %q", arg))
+				}
+				values[name] = value
+			}
+
+			tag := scheme.NewTag(values)
+			formatted := scheme.Format(tag)
+			if _, err := scheme.Parse(formatted); err != nil {
+				exitWithError(1, "Error formatting tag", err)
+			}
+
+			t := table{headers: []string{"tag"}, rows: [][]string{{formatted}}}
+			if err := printResult(tagResult{Tag: formatted, Scheme: scheme.Name, Segments: values}, t); err != nil {
+				exitWithError(1, "Error writing output", err)
+			}
+		},
+	}
+	formatCmd.Flags().StringVar(&schemeName, "scheme", assettag.DefaultTagSchemeName, "Tag scheme to format with")
+
+	tagsCmd.AddCommand(schemesCmd, parseCmd, formatCmd, newKKSCmd(), newMigrateCmd(), newNumbersCmd(), newLoopsCmd(), newNormaliseCmd(), newDuplicatesCmd(), newMergeCmd(), newDescribeCmd())
+	return tagsCmd
+}
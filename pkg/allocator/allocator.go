@@ -0,0 +1,282 @@
+package allocator
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/thornzero/udc_codec/pkg/db"
+)
+
+// NumberingFile is the tag numbering configuration in the data directory
+const NumberingFile = "tag_numbering.yaml"
+
+// Fields a range can be matched and numbered by
+const (
+	BySystem   = "system"
+	ByFunction = "function"
+	ByArea     = "area"
+)
+
+// ErrOutOfRange is returned for numbers no configured range covers
+var ErrOutOfRange = errors.New("number is outside the numbering ranges")
+
+// Range is a block of loop numbers for the tags matching its system,
+// function and area; an empty value matches anything. By lists the fields
+// that get a separate sequence within the range, so with the default
+// [system, area] FT and FIC tags of one system share loop numbers.
+type Range struct {
+	Name     string   `yaml:"name" json:"name"`
+	System   string   `yaml:"system,omitempty" json:"system,omitempty"`
+	Function string   `yaml:"function,omitempty" json:"function,omitempty"`
+	Area     string   `yaml:"area,omitempty" json:"area,omitempty"`
+	Min      int      `yaml:"min" json:"min"`
+	Max      int      `yaml:"max" json:"max"`
+	Width    int      `yaml:"width,omitempty" json:"width,omitempty"`
+	By       []string `yaml:"by,omitempty" json:"by,omitempty"`
+	Reuse    bool     `yaml:"reuse,omitempty" json:"reuse,omitempty"`
+}
+
+// Config is the tag numbering configuration
+type Config struct {
+	Ranges []Range `yaml:"ranges" json:"ranges"`
+}
+
+// LoadConfig loads a tag numbering configuration from a YAML file
+func LoadConfig(filename string) (*Config, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filename, err)
+	}
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+	return &cfg, nil
+}
+
+// LoadConfigDir loads the tag numbering configuration from the data directory
+func LoadConfigDir(dataDir string) (*Config, error) {
+	return LoadConfig(filepath.Join(dataDir, NumberingFile))
+}
+
+// Request identifies the tag a number is wanted for
+type Request struct {
+	System   string
+	Function string
+	Area     string
+	// Tag is recorded with the reservation for reference
+	Tag string
+}
+
+// Allocator hands out loop numbers from the configured ranges and records
+// every reservation in the tag DB
+type Allocator struct {
+	store  *db.Store
+	ranges []Range
+}
+
+// New checks the ranges and prepares an allocator
+func New(store *db.Store, cfg *Config) (*Allocator, error) {
+	names := make(map[string]bool)
+	for i := range cfg.Ranges {
+		r := &cfg.Ranges[i]
+		if r.Name == "" {
+			return nil, fmt.Errorf("numbering range %d has no name", i+1)
+		}
+		if names[r.Name] {
+			return nil, fmt.Errorf("duplicate numbering range: %s", r.Name)
+		}
+		names[r.Name] = true
+		if r.Min < 0 || r.Max < r.Min {
+			return nil, fmt.Errorf("numbering range %s: invalid range %d-%d", r.Name, r.Min, r.Max)
+		}
+		if len(r.By) == 0 {
+			r.By = []string{BySystem, ByArea}
+		}
+		for _, field := range r.By {
+			if field != BySystem && field != ByFunction && field != ByArea {
+				return nil, fmt.Errorf("numbering range %s: unknown field %q", r.Name, field)
+			}
+		}
+	}
+	return &Allocator{store: store, ranges: cfg.Ranges}, nil
+}
+
+// Ranges returns the configured ranges
+func (a *Allocator) Ranges() []Range {
+	return a.ranges
+}
+
+// specificity counts the fields a range restricts
+func (r *Range) specificity() int {
+	n := 0
+	for _, v := range []string{r.System, r.Function, r.Area} {
+		if v != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// matches reports whether a range covers a request
+func (r *Range) matches(req Request) bool {
+	return (r.System == "" || r.System == req.System) &&
+		(r.Function == "" || r.Function == req.Function) &&
+		(r.Area == "" || r.Area == req.Area)
+}
+
+// Range returns the most specific range covering a request; ties go to the
+// range listed first
+func (a *Allocator) Range(req Request) (*Range, error) {
+	var best *Range
+	for i := range a.ranges {
+		r := &a.ranges[i]
+		if r.matches(req) && (best == nil || r.specificity() > best.specificity()) {
+			best = r
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("no numbering range for system %q function %q area %q", req.System, req.Function, req.Area)
+	}
+	return best, nil
+}
+
+// Pool returns the name of the sequence a request draws from, e.g.
+// "pol/system=POL/area=21". Empty fields are left out.
+func (r *Range) Pool(req Request) string {
+	parts := []string{r.Name}
+	for _, field := range r.By {
+		var value string
+		switch field {
+		case BySystem:
+			value = req.System
+		case ByFunction:
+			value = req.Function
+		case ByArea:
+			value = req.Area
+		}
+		if value != "" {
+			parts = append(parts, field+"="+value)
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// Format writes a number with the range's width
+func (r *Range) Format(number int) string {
+	return fmt.Sprintf("%0*d", r.Width, number)
+}
+
+// Reservation is a number reserved for a request. Reused marks a number
+// that had been released before, so cancelling the reservation releases it
+// again rather than forgetting it.
+type Reservation struct {
+	Request Request
+	Number  string
+	Reused  bool
+}
+
+// Allocate reserves the next free number for a request
+func (a *Allocator) Allocate(req Request) (string, error) {
+	res, err := a.Next(req, nil)
+	if err != nil {
+		return "", err
+	}
+	return res.Number, nil
+}
+
+// Next reserves the next free number for a request. tag, if set, gives the
+// tag recorded with the number once it is known; otherwise req.Tag is kept.
+func (a *Allocator) Next(req Request, tag func(number string) string) (*Reservation, error) {
+	r, err := a.Range(req)
+	if err != nil {
+		return nil, err
+	}
+	number, reused, err := a.store.ReserveNextNumber(r.Pool(req), r.Min, r.Max, r.Reuse, func(n int) string {
+		if tag == nil {
+			return req.Tag
+		}
+		return tag(r.Format(n))
+	})
+	if err != nil {
+		return nil, err
+	}
+	res := &Reservation{Request: req, Number: r.Format(number), Reused: reused}
+	if tag != nil {
+		res.Request.Tag = tag(res.Number)
+	}
+	return res, nil
+}
+
+// Reserve records a number that is already in use, so it is not handed out.
+// It reports whether the number was free. Numbers outside every range are
+// rejected with ErrOutOfRange.
+func (a *Allocator) Reserve(req Request, number string) (bool, error) {
+	res, err := a.Hold(req, number)
+	return res != nil, err
+}
+
+// Hold reserves a number that is already in use like Reserve, returning the
+// reservation, or nil if the number was reserved before
+func (a *Allocator) Hold(req Request, number string) (*Reservation, error) {
+	r, n, err := a.parse(req, number)
+	if err != nil {
+		return nil, err
+	}
+	reserved, reused, err := a.store.ReserveNumber(r.Pool(req), n, req.Tag)
+	if err != nil || !reserved {
+		return nil, err
+	}
+	return &Reservation{Request: req, Number: number, Reused: reused}, nil
+}
+
+// Cancel takes back a reservation that was never used. Unlike Release, a
+// new number becomes free again even if the range does not allow reuse.
+func (a *Allocator) Cancel(res *Reservation) error {
+	r, n, err := a.parse(res.Request, res.Number)
+	if err != nil {
+		return err
+	}
+	return a.store.UndoNumber(r.Pool(res.Request), n, res.Reused)
+}
+
+// Release gives a number back. It stays recorded as released and is only
+// handed out again if the range allows reuse.
+func (a *Allocator) Release(req Request, number string) error {
+	r, n, err := a.parse(req, number)
+	if err != nil {
+		return err
+	}
+	return a.store.ReleaseNumber(r.Pool(req), n)
+}
+
+// Numbers lists the reservations in the pool of a request
+func (a *Allocator) Numbers(req Request) ([]db.NumberReservation, error) {
+	r, err := a.Range(req)
+	if err != nil {
+		return nil, err
+	}
+	return a.store.ListNumbers(r.Pool(req))
+}
+
+// parse finds the range of a request and checks a number against it
+func (a *Allocator) parse(req Request, number string) (*Range, int, error) {
+	r, err := a.Range(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", ErrOutOfRange, err)
+	}
+	n, err := strconv.Atoi(number)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: invalid loop number %q", ErrOutOfRange, number)
+	}
+	if n < r.Min || n > r.Max {
+		return nil, 0, fmt.Errorf("%w: loop number %d is outside range %s (%d-%d)", ErrOutOfRange, n, r.Name, r.Min, r.Max)
+	}
+	return r, n, nil
+}
@@ -0,0 +1,219 @@
+package allocator
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/thornzero/udc_codec/pkg/classification"
+	"github.com/thornzero/udc_codec/pkg/db"
+)
+
+func newStore(t *testing.T) *db.Store {
+	t.Helper()
+	store, err := db.OpenDB(filepath.Join(t.TempDir(), "tags.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestAllocate(t *testing.T) {
+	cfg, err := LoadConfigDir(filepath.Join("..", "..", "data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := New(newStore(t), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pol := Request{System: "POL", Function: "LT"}
+	if ok, err := a.Reserve(pol, "1000"); err != nil || !ok {
+		t.Fatalf("Expected 1000 to be reserved, got %v %v", ok, err)
+	}
+	if ok, _ := a.Reserve(Request{System: "POL", Function: "FT"}, "1000"); ok {
+		t.Error("Expected loop number to be shared within the system")
+	}
+	if _, err := a.Reserve(pol, "5000"); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("Expected number outside the range to be rejected, got %v", err)
+	}
+
+	for _, want := range []string{"1001", "1002"} {
+		got, err := a.Allocate(pol)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("Expected %s, got %s", want, got)
+		}
+	}
+
+	// Released numbers are tracked and not reused unless the range allows it
+	if err := a.Release(pol, "1001"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := a.Allocate(pol); got != "1003" {
+		t.Errorf("Expected released 1001 to be skipped, got %s", got)
+	}
+	numbers, err := a.Numbers(pol)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(numbers) != 4 || numbers[1].Number != 1001 || numbers[1].Status != db.NumberReleased {
+		t.Errorf("Expected 1001 to be recorded as released, got %+v", numbers)
+	}
+	if err := a.Release(pol, "1001"); err == nil {
+		t.Error("Expected releasing a released number to fail")
+	}
+
+	// Area ranges number per area and pad to four digits
+	if got, _ := a.Allocate(Request{System: "ABC", Area: "21"}); got != "0001" {
+		t.Errorf("Expected 0001, got %s", got)
+	}
+	if got, _ := a.Allocate(Request{System: "ABC", Area: "22"}); got != "0001" {
+		t.Errorf("Expected areas to be numbered separately, got %s", got)
+	}
+}
+
+func TestAllocateReuseAndExhaustion(t *testing.T) {
+	a, err := New(newStore(t), &Config{Ranges: []Range{
+		{Name: "small", Min: 1, Max: 2, Reuse: true},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := Request{System: "POL"}
+	a.Allocate(req)
+	a.Allocate(req)
+	if _, err := a.Allocate(req); !errors.Is(err, db.ErrRangeExhausted) {
+		t.Errorf("Expected exhausted range, got %v", err)
+	}
+	if err := a.Release(req, "1"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := a.Allocate(req); got != "1" {
+		t.Errorf("Expected released number to be reused, got %s", got)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	a, err := New(newStore(t), &Config{Ranges: []Range{
+		{Name: "kept", System: "POL", Min: 1, Max: 9},
+		{Name: "reused", System: "ISO", Min: 1, Max: 9, Reuse: true},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pol := Request{System: "POL"}
+	res, err := a.Next(pol, func(number string) string { return "POL-LT" + number })
+	if err != nil || res.Number != "1" || res.Request.Tag != "POL-LT1" || res.Reused {
+		t.Fatalf("Expected a new number 1 for POL-LT1, got %+v, %v", res, err)
+	}
+	if err := a.Cancel(res); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := a.Allocate(pol); got != "1" {
+		t.Errorf("Expected a cancelled number to be free again without reuse, got %s", got)
+	}
+
+	iso := Request{System: "ISO"}
+	a.Allocate(iso)
+	if err := a.Release(iso, "1"); err != nil {
+		t.Fatal(err)
+	}
+	res, err = a.Next(iso, nil)
+	if err != nil || !res.Reused {
+		t.Fatalf("Expected the released number to be reused, got %+v, %v", res, err)
+	}
+	if err := a.Cancel(res); err != nil {
+		t.Fatal(err)
+	}
+	numbers, _ := a.Numbers(iso)
+	if len(numbers) != 1 || numbers[0].Status != db.NumberReleased {
+		t.Errorf("Expected a cancelled reused number to be released again, got %+v", numbers)
+	}
+}
+
+func TestAllocateConcurrent(t *testing.T) {
+	a, err := New(newStore(t), &Config{Ranges: []Range{{Name: "all", Min: 1, Max: 1000}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const workers, each = 8, 10
+	var mu sync.Mutex
+	seen := make(map[string]bool)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < each; i++ {
+				number, err := a.Allocate(Request{System: "POL"})
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				mu.Lock()
+				if seen[number] {
+					t.Errorf("Number %s handed out twice", number)
+				}
+				seen[number] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if len(seen) != workers*each {
+		t.Errorf("Expected %d numbers, got %d", workers*each, len(seen))
+	}
+}
+
+func TestRangesUseKnownSystems(t *testing.T) {
+	dataDir := filepath.Join("..", "..", "data")
+	cfg, err := LoadConfigDir(dataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	schemes, err := classification.LoadRegistryLang(dataDir, "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range cfg.Ranges {
+		if _, ok := schemes.Lookup(classification.Systems, r.System); r.System != "" && !ok {
+			t.Errorf("Expected range %s to use a known system code, got %s", r.Name, r.System)
+		}
+	}
+}
+
+func TestRangeValidation(t *testing.T) {
+	bad := []Config{
+		{Ranges: []Range{{Min: 1, Max: 2}}},
+		{Ranges: []Range{{Name: "a", Min: 5, Max: 2}}},
+		{Ranges: []Range{{Name: "a", Max: 2}, {Name: "a", Max: 3}}},
+		{Ranges: []Range{{Name: "a", Max: 2, By: []string{"unit"}}}},
+	}
+	for i := range bad {
+		if _, err := New(nil, &bad[i]); err == nil {
+			t.Errorf("Expected config %d to be rejected", i)
+		}
+	}
+
+	a, _ := New(nil, &Config{Ranges: []Range{
+		{Name: "any", Max: 10},
+		{Name: "pol", System: "POL", Max: 10},
+		{Name: "pol-lt", System: "POL", Function: "LT", Max: 10, By: []string{ByFunction}},
+	}})
+	r, _ := a.Range(Request{System: "POL", Function: "LT"})
+	if r.Name != "pol-lt" || r.Pool(Request{System: "POL", Function: "LT"}) != "pol-lt/function=LT" {
+		t.Errorf("Expected most specific range pol-lt, got %s", r.Name)
+	}
+	if r, _ := a.Range(Request{System: "ISO"}); r.Name != "any" {
+		t.Errorf("Expected fallback range, got %s", r.Name)
+	}
+}
@@ -2,10 +2,13 @@ package api
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
 
+	"github.com/thornzero/udc_codec/pkg/bomimport"
 	"github.com/thornzero/udc_codec/pkg/config"
 	"github.com/thornzero/udc_codec/pkg/db"
 	"github.com/thornzero/udc_codec/pkg/pipeline"
@@ -25,20 +28,31 @@ func handleUpload(c *fiber.Ctx) error {
 		return c.Status(400).SendString("File upload error")
 	}
 
-	savePath := fmt.Sprintf("%s/%s", config.Load().DataDir, file.Filename)
-	if err := c.SaveFile(file, savePath); err != nil {
+	filename := filepath.Base(file.Filename)
+	if _, err := bomimport.FormatOf(filename); err != nil {
+		return c.Status(400).SendString(err.Error())
+	}
+	if err := c.SaveFile(file, pendingFile(filename)); err != nil {
 		return c.Status(500).SendString("File save error")
 	}
 
-	projectName := strings.TrimSuffix(file.Filename, ".yaml")
-
-	// Run pipeline automatically
-	if err := runFullPipeline(projectName, savePath); err != nil {
-		return c.Status(500).SendString(fmt.Sprintf("Pipeline failed: %v", err))
+	// Show what changed since the accepted revision before replacing it
+	projectName := strings.TrimSuffix(filename, filepath.Ext(filename))
+	profile := c.FormValue("profile")
+	diff, err := diffRevision(projectName, pendingFile(filename), profile)
+	if err != nil {
+		os.Remove(pendingFile(filename))
+		return pipelineFailed(c, err)
 	}
-
-	c.Redirect("/")
-	return nil
+	if diff == nil {
+		return acceptUpload(c, filename, profile)
+	}
+	return c.Render("diff", fiber.Map{
+		"Project": projectName,
+		"File":    filename,
+		"Profile": profile,
+		"Diff":    diff,
+	})
 }
 
 // Health Check
@@ -105,28 +119,81 @@ func projectDetailPage(c *fiber.Ctx) error {
 		return c.Status(500).SendString("Failed to load tag list")
 	}
 
+	// Loop lists are written by newer pipeline runs only
+	loops, _ := pipeline.LoadLoopList(loopListFile(project))
+	validation, _ := pipeline.LoadValidationReport(validationFile(project))
+	changes, _ := pipeline.LoadDiff(changesFile(project))
+
 	return c.Render("project_detail", fiber.Map{
-		"Project": project,
-		"Tags":    entries,
+		"Project":    project,
+		"Tags":       entries,
+		"Loops":      loops,
+		"Validation": validation,
+		"Changes":    changes,
+		"Formats":    exporters.Names(),
 	})
 }
 
+// exporters holds the tag list formats /export/:project serves
+var exporters = pipeline.DefaultExporters()
+
+// exportProjectPage downloads a project's tag list, as CSV unless the
+// format query selects another registered format
 func exportProjectPage(c *fiber.Ctx) error {
 	project := c.Params("project")
 	tagFile := fmt.Sprintf("%s/%s_taglist.yaml", config.Load().DataDir, project)
 
+	exporter, err := exporters.Exporter(c.Query("format", "csv"))
+	if err != nil {
+		return c.Status(400).SendString(err.Error())
+	}
 	entries, err := pipeline.LoadExportedTags(tagFile)
 	if err != nil {
 		return c.Status(500).SendString("Failed to load tag list")
 	}
 
-	// Export CSV file
-	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s_tags.csv\"", project))
+	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s_tags%s\"", project, exporter.Extension()))
+	c.Set("Content-Type", exporter.ContentType())
+	return exporter.Write(c, project, entries)
+}
+
+func exportLoopsPage(c *fiber.Ctx) error {
+	project := c.Params("project")
+	loops, err := pipeline.LoadLoopList(loopListFile(project))
+	if err != nil {
+		return c.Status(404).SendString("No loop list for project")
+	}
+
+	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s_loops.csv\"", project))
 	c.Set("Content-Type", "text/csv")
+	return pipeline.WriteLoopCSV(c, loops)
+}
+
+func validationPage(c *fiber.Ctx) error {
+	report, err := pipeline.LoadValidationReport(validationFile(c.Params("project")))
+	if err != nil {
+		return c.Status(404).SendString("No validation report for project")
+	}
+	c.Type("html")
+	return pipeline.WriteValidationHTML(c, report)
+}
+
+func validationJSON(c *fiber.Ctx) error {
+	report, err := pipeline.LoadValidationReport(validationFile(c.Params("project")))
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "No validation report for project")
+	}
+	return c.JSON(report)
+}
 
-	for _, rec := range entries {
-		line := fmt.Sprintf("%s,%s,%s,%s\n", rec.FullTag, rec.SystemName, rec.Description, rec.UDCCode)
-		c.Write([]byte(line))
+func exportValidationPage(c *fiber.Ctx) error {
+	project := c.Params("project")
+	report, err := pipeline.LoadValidationReport(validationFile(project))
+	if err != nil {
+		return c.Status(404).SendString("No validation report for project")
 	}
-	return nil
+
+	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s_validation.csv\"", project))
+	c.Set("Content-Type", "text/csv")
+	return pipeline.WriteValidationCSV(c, report)
 }
@@ -1,10 +1,14 @@
 package api
 
 type APITag struct {
-	FullTag    string `json:"full_tag"`
-	SystemCode string `json:"system_code"`
-	FunctionCode string `json:"function_code"`
-	EquipmentID string `json:"equipment_id"`
-	Description string `json:"description"`
-	UDCCode    string `json:"udc_code,omitempty"`
+	FullTag         string            `json:"full_tag"`
+	SystemCode      string            `json:"system_code"`
+	FunctionCode    string            `json:"function_code"`
+	EquipmentID     string            `json:"equipment_id"`
+	Description     string            `json:"description"`
+	UDCCode         string            `json:"udc_code,omitempty"`
+	Designation     string            `json:"designation,omitempty"`
+	Classifications map[string]string `json:"classifications,omitempty"`
+	// Project selects the project's validation rules
+	Project string `json:"project,omitempty"`
 }
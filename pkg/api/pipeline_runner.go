@@ -1,67 +1,40 @@
 package api
 
 import (
+	"context"
 	"fmt"
+	"log"
 
-	"github.com/thornzero/udc_codec/pkg/aggregator"
 	"github.com/thornzero/udc_codec/pkg/config"
-	"github.com/thornzero/udc_codec/pkg/db"
 	"github.com/thornzero/udc_codec/pkg/pipeline"
-	"github.com/thornzero/udc_codec/pkg/udc"
+	"github.com/thornzero/udc_codec/pkg/stages"
 )
 
-func runFullPipeline(projectName, bomFile string) error {
-	udcCodec, err := udc.LoadCodec(fmt.Sprintf("%s/udc_full.yaml", config.Load().DataDir))
-	if err != nil {
-		return err
-	}
-	agg, err := aggregator.LoadAggregatedDatabase(fmt.Sprintf("%s/aggregated_master.yaml", config.Load().DataDir))
-	if err != nil {
-		return err
-	}
-	bom, err := pipeline.LoadBOM(bomFile)
-	if err != nil {
-		return err
-	}
-
-	validator := &pipeline.Validator{
-		Aggregator: agg,
-		UDC:        udcCodec,
-	}
-
-	var exportRecords []pipeline.ExportRecord
-	for _, entry := range bom.Entries {
-		if err := validator.ValidateEntry(entry); err != nil {
-			return fmt.Errorf("validation failed: %v", err)
-		}
-		tag := pipeline.GenerateFullTag(entry)
-		system := agg.LookupSystem(entry.SystemCode)
-
-		exportRecords = append(exportRecords, pipeline.ExportRecord{
-			FullTag:     tag,
-			SystemName:  system.SystemName,
-			Description: entry.Description,
-			UDCCode:     entry.UDCCode,
-		})
-	}
+// runFullPipeline processes an uploaded BOM with the pipeline definition in
+// the data directory, the same one the autopipeline runs. CSV and XLSX
+// files are imported with a column-mapping profile. Entries keep the loop
+// numbers of the project's accepted revision.
+func runFullPipeline(projectName, bomFile, profileName string) (*pipeline.Run, error) {
+	run := &pipeline.Run{
+		Project: projectName,
+		Input:   bomFile,
+		Params: map[string]string{
+			"import.profile":    profileName,
+			"allocate.previous": revisionFile(projectName),
+		},
+	}
+	err := stages.Run(context.Background(), config.Load(), run, func(e pipeline.Event) {
+		log.Printf("pipeline %s: %v", projectName, e)
+	})
+	return run, err
+}
 
-	outputFile := fmt.Sprintf("%s/%s_taglist.yaml", config.Load().DataDir, projectName)
-	if err := pipeline.ExportTagList(exportRecords, outputFile); err != nil {
-		return err
-	}
+// loopListFile is where the pipeline writes a project's loop list
+func loopListFile(project string) string {
+	return fmt.Sprintf("%s/%s_loops.yaml", config.Load().DataDir, project)
+}
 
-	// Insert into DB registry
-	store, err := db.OpenDB(config.Load().DBPath)
-	if err != nil {
-		return err
-	}
-	if err := store.Migrate(); err != nil {
-		return err
-	}
-	_, err = store.InsertProject(db.ProjectRecord{
-		ProjectName: projectName,
-		FullBOMFile: bomFile,
-		Validated:   true,
-	})
-	return err
+// validationFile is where the pipeline writes a project's validation report
+func validationFile(project string) string {
+	return fmt.Sprintf("%s/%s_validation.json", config.Load().DataDir, project)
 }
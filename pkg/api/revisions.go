@@ -0,0 +1,188 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/thornzero/udc_codec/pkg/assettag"
+	"github.com/thornzero/udc_codec/pkg/bomimport"
+	"github.com/thornzero/udc_codec/pkg/config"
+	"github.com/thornzero/udc_codec/pkg/pipeline"
+)
+
+// pendingFile is where an upload waits while its changes are reviewed
+func pendingFile(filename string) string {
+	ext := filepath.Ext(filename)
+	return fmt.Sprintf("%s/%s_pending%s", config.Load().DataDir, strings.TrimSuffix(filename, ext), ext)
+}
+
+// revisionFile is where the last accepted revision of a project's BOM is kept
+func revisionFile(project string) string {
+	return fmt.Sprintf("%s/%s_accepted.yaml", config.Load().DataDir, project)
+}
+
+// changesFile is where the change list of the last accepted revision is kept
+func changesFile(project string) string {
+	return fmt.Sprintf("%s/%s_changes.yaml", config.Load().DataDir, project)
+}
+
+// importUpload reads an uploaded BOM with a column-mapping profile
+func importUpload(project, filename, profileName string) (*pipeline.ProjectBOM, *assettag.TagScheme, error) {
+	dataDir := config.Load().DataDir
+	profiles, err := bomimport.LoadProfilesDir(dataDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	profile, err := profiles.Get(profileName)
+	if err != nil {
+		return nil, nil, err
+	}
+	tagSchemes, err := assettag.LoadTagSchemesDir(dataDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	bom, report, err := bomimport.Load(filename, bomimport.Options{Profile: profile, ProjectName: project, TagSchemes: tagSchemes})
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := report.Err(); err != nil {
+		return nil, nil, err
+	}
+	scheme, err := tagSchemes.Get(bom.TagScheme)
+	if err != nil {
+		return nil, nil, err
+	}
+	return bom, scheme, nil
+}
+
+// diffRevision compares an upload with the last accepted revision of its
+// project, after carrying over the loop numbers the pipeline would keep. It
+// returns a nil diff for a project without one.
+func diffRevision(project, filename, profileName string) (*pipeline.BOMDiff, error) {
+	bom, scheme, err := importUpload(project, filename, profileName)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(revisionFile(project)); errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	accepted, err := pipeline.LoadBOM(revisionFile(project))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the accepted revision: %w", err)
+	}
+	pipeline.CarryNumbers(accepted, bom, scheme)
+	diff := pipeline.DiffBOM(accepted, bom, scheme)
+	diff.Project = project
+	return diff, nil
+}
+
+// acceptUpload runs the pipeline on a reviewed upload. When the pipeline
+// succeeds the upload is moved into place, the BOM with its allocated loop
+// numbers becomes the accepted revision and its change list is kept;
+// otherwise the upload stays pending.
+func acceptUpload(c *fiber.Ctx, filename, profileName string) error {
+	project := strings.TrimSuffix(filename, filepath.Ext(filename))
+	diff, err := diffRevision(project, pendingFile(filename), profileName)
+	if err != nil {
+		return pipelineFailed(c, err)
+	}
+	// Run pipeline automatically, leaving a rejected upload pending
+	run, err := runFullPipeline(project, pendingFile(filename), profileName)
+	if err != nil {
+		return pipelineFailed(c, err)
+	}
+	savePath := fmt.Sprintf("%s/%s", config.Load().DataDir, filename)
+	if err := os.Rename(pendingFile(filename), savePath); err != nil {
+		return c.Status(500).SendString("File save error")
+	}
+
+	// The revision keeps the allocated loop numbers
+	if err := pipeline.ExportBOM(run.BOM, revisionFile(project)); err != nil {
+		return c.Status(500).SendString(fmt.Sprintf("Failed to record the revision: %v", err))
+	}
+	if diff != nil {
+		if err := pipeline.ExportDiff(diff, changesFile(project)); err != nil {
+			return c.Status(500).SendString(fmt.Sprintf("Failed to record the changes: %v", err))
+		}
+	}
+	c.Redirect("/")
+	return nil
+}
+
+// pipelineFailed answers an upload the pipeline rejected
+func pipelineFailed(c *fiber.Ctx, err error) error {
+	var rowErr bomimport.RowError
+	var validationErr *pipeline.ValidationError
+	if errors.As(err, &validationErr) {
+		c.Status(422).Type("html")
+		return pipeline.WriteValidationHTML(c, validationErr.Report)
+	}
+	if errors.As(err, &rowErr) {
+		return c.Status(400).SendString(fmt.Sprintf("BOM import failed:\n%v", err))
+	}
+	return c.Status(500).SendString(fmt.Sprintf("Pipeline failed: %v", err))
+}
+
+// reviewedFile returns the pending upload named by a review form
+func reviewedFile(c *fiber.Ctx) (string, bool) {
+	filename := filepath.Base(c.FormValue("file"))
+	if _, err := bomimport.FormatOf(filename); err != nil {
+		return "", false
+	}
+	_, err := os.Stat(pendingFile(filename))
+	return filename, err == nil
+}
+
+// handleAcceptUpload accepts a revision after its changes were reviewed
+func handleAcceptUpload(c *fiber.Ctx) error {
+	filename, ok := reviewedFile(c)
+	if !ok {
+		return c.Status(404).SendString("No pending upload")
+	}
+	return acceptUpload(c, filename, c.FormValue("profile"))
+}
+
+// handleDiscardUpload drops a revision after its changes were reviewed
+func handleDiscardUpload(c *fiber.Ctx) error {
+	filename, ok := reviewedFile(c)
+	if !ok {
+		return c.Status(404).SendString("No pending upload")
+	}
+	if err := os.Remove(pendingFile(filename)); err != nil {
+		return c.Status(500).SendString("Failed to discard the upload")
+	}
+	c.Redirect("/upload")
+	return nil
+}
+
+// exportChangesPage downloads the change list of a project's last accepted
+// revision as CSV, Markdown or YAML
+func exportChangesPage(c *fiber.Ctx) error {
+	project := c.Params("project")
+	diff, err := pipeline.LoadDiff(changesFile(project))
+	if err != nil {
+		return c.Status(404).SendString("No change list for project")
+	}
+
+	var write func(io.Writer, *pipeline.BOMDiff) error
+	var ext, contentType string
+	switch c.Query("format", "csv") {
+	case "csv":
+		write, ext, contentType = pipeline.WriteDiffCSV, "csv", "text/csv"
+	case "md", "markdown":
+		write, ext, contentType = pipeline.WriteDiffMarkdown, "md", "text/markdown"
+	case "yaml":
+		write, ext, contentType = pipeline.WriteDiffYAML, "yaml", "application/yaml"
+	default:
+		return c.Status(400).SendString("Unknown format (use csv, md or yaml)")
+	}
+	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s_changes.%s\"", project, ext))
+	c.Set("Content-Type", contentType)
+	return write(c, diff)
+}
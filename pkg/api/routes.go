@@ -5,9 +5,21 @@ import "github.com/gofiber/fiber/v2"
 func registerRoutes(app *fiber.App) {
 	app.Get("/api/health", healthCheck)
 	app.Post("/api/upload-bom", uploadBOM)
+	app.Post("/api/tags/validate", validateTag)
 	app.Get("/api/tags/:tag", getTag)
 	app.Get("/api/projects", listProjects)
+	app.Get("/api/projects/:project/validation", validationJSON)
+	app.Get("/api/schemes", listSchemes)
+	app.Get("/api/schemes/:scheme/lookup", lookupClass)
+	app.Get("/api/schemes/:scheme/validate", validateClass)
+	app.Get("/api/schemes/:scheme/children", classChildren)
+	app.Get("/api/schemes/:scheme/ancestry", classAncestry)
+	app.Get("/api/schemes/:scheme/search", searchClasses)
 	app.Get("/projects", projectsPage)
 	app.Get("/projects/:project", projectDetailPage)
 	app.Get("/export/:project", exportProjectPage)
+	app.Get("/export/:project/loops", exportLoopsPage)
+	app.Get("/validation/:project", validationPage)
+	app.Get("/export/:project/validation", exportValidationPage)
+	app.Get("/export/:project/changes", exportChangesPage)
 }
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/thornzero/udc_codec/pkg/assettag"
+	"github.com/thornzero/udc_codec/pkg/classification"
+	"github.com/thornzero/udc_codec/pkg/config"
+)
+
+var (
+	registry     *classification.Registry
+	registryErr  error
+	registryOnce sync.Once
+)
+
+// loadSchemes loads and checks the classification schemes from the data
+// directory once
+func loadSchemes() (*classification.Registry, error) {
+	registryOnce.Do(func() {
+		var resolver *assettag.Resolver
+		resolver, registryErr = assettag.NewResolver(config.Load())
+		if registryErr == nil {
+			registry = resolver.Schemes
+		}
+	})
+	return registry, registryErr
+}
+
+// schemeFromRequest resolves the :scheme route parameter
+func schemeFromRequest(c *fiber.Ctx) (classification.Scheme, error) {
+	schemes, err := loadSchemes()
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to load classification schemes")
+	}
+	scheme, err := schemes.Scheme(c.Params("scheme"))
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+	return scheme, nil
+}
+
+// List registered classification schemes
+func listSchemes(c *fiber.Ctx) error {
+	schemes, err := loadSchemes()
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to load classification schemes")
+	}
+	return c.JSON(schemes.Names())
+}
+
+// Lookup a code in a scheme (?code=)
+func lookupClass(c *fiber.Ctx) error {
+	scheme, err := schemeFromRequest(c)
+	if err != nil {
+		return err
+	}
+	code := c.Query("code")
+	title, ok := scheme.Lookup(code)
+	if !ok {
+		return fiber.NewError(fiber.StatusNotFound, "Code not found")
+	}
+	return c.JSON(classification.Class{Code: code, Title: title})
+}
+
+// Validate a code against a scheme (?code=)
+func validateClass(c *fiber.Ctx) error {
+	scheme, err := schemeFromRequest(c)
+	if err != nil {
+		return err
+	}
+	code := c.Query("code")
+	if err := scheme.Validate(code); err != nil {
+		return c.JSON(fiber.Map{"code": code, "valid": false, "error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"code": code, "valid": true})
+}
+
+// List the children of a code (?code=)
+func classChildren(c *fiber.Ctx) error {
+	scheme, err := schemeFromRequest(c)
+	if err != nil {
+		return err
+	}
+	children, ok := scheme.Children(c.Query("code"))
+	if !ok {
+		return fiber.NewError(fiber.StatusNotFound, "Code not found")
+	}
+	return c.JSON(children)
+}
+
+// List the ancestors of a code (?code=)
+func classAncestry(c *fiber.Ctx) error {
+	scheme, err := schemeFromRequest(c)
+	if err != nil {
+		return err
+	}
+	ancestry, ok := scheme.Ancestry(c.Query("code"))
+	if !ok {
+		return fiber.NewError(fiber.StatusNotFound, "Code not found")
+	}
+	return c.JSON(ancestry)
+}
+
+// Search a scheme by title (?q=)
+func searchClasses(c *fiber.Ctx) error {
+	scheme, err := schemeFromRequest(c)
+	if err != nil {
+		return err
+	}
+	results := scheme.Search(c.Query("q"))
+	if results == nil {
+		results = []classification.Class{}
+	}
+	return c.JSON(results)
+}
@@ -2,12 +2,27 @@ package api
 
 import (
 	"fmt"
+	"path/filepath"
 
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/thornzero/udc_codec/pkg/aggregator"
+	"github.com/thornzero/udc_codec/pkg/config"
 	"github.com/thornzero/udc_codec/pkg/pipeline"
+	"github.com/thornzero/udc_codec/pkg/rules"
 )
 
-// ValidateAPITag checks for required fields and delegates deep validation to pipeline.Validator.
+// ValidateAPITag checks for required fields and delegates deep validation to
+// pipeline.Validator, including the rules of the tag's project
 func ValidateAPITag(tag APITag, validator *pipeline.Validator) error {
+	if err := checkRequired(tag); err != nil {
+		return err
+	}
+	return validator.ValidateProjectEntry(tag.Project, apiEntry(tag))
+}
+
+// checkRequired checks the fields a tag cannot be validated without
+func checkRequired(tag APITag) error {
 	if tag.SystemCode == "" {
 		return fmt.Errorf("system_code is required")
 	}
@@ -17,15 +32,59 @@ func ValidateAPITag(tag APITag, validator *pipeline.Validator) error {
 	if tag.EquipmentID == "" {
 		return fmt.Errorf("equipment_id is required")
 	}
-	// Optionally check for description or UDCCode presence/format here
+	return nil
+}
+
+// apiEntry converts an APITag to a pipeline.BOMEntry for deep validation
+func apiEntry(tag APITag) pipeline.BOMEntry {
+	return pipeline.BOMEntry{
+		SystemCode:      tag.SystemCode,
+		FunctionCode:    tag.FunctionCode,
+		EquipmentID:     tag.EquipmentID,
+		UDCCode:         tag.UDCCode,
+		Designation:     tag.Designation,
+		Description:     tag.Description,
+		Classifications: tag.Classifications,
+	}
+}
+
+// loadValidator loads the validator with the aggregated systems and rules
+func loadValidator() (*pipeline.Validator, error) {
+	schemes, err := loadSchemes()
+	if err != nil {
+		return nil, err
+	}
+	agg, err := aggregator.LoadAggregatedDatabase(filepath.Join(config.Load().DataDir, "aggregated_master.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	ruleSet, err := rules.LoadDir(config.Load().DataDir)
+	if err != nil {
+		return nil, err
+	}
+	return &pipeline.Validator{Aggregator: agg, Schemes: schemes, Rules: ruleSet}, nil
+}
 
-	// Convert APITag to pipeline.BOMEntry for deep validation
-	entry := pipeline.BOMEntry{
-		SystemCode:   tag.SystemCode,
-		FunctionCode: tag.FunctionCode,
-		EquipmentID:  tag.EquipmentID,
-		UDCCode:      tag.UDCCode,
-		Description:  tag.Description,
+// Validate a tag against the reference data and its project's rules,
+// listing every issue
+func validateTag(c *fiber.Ctx) error {
+	var tag APITag
+	if err := c.BodyParser(&tag); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid tag")
+	}
+	if err := checkRequired(tag); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	validator, err := loadValidator()
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("Failed to load validator: %v", err))
+	}
+	issues := validator.CheckEntry(tag.Project, apiEntry(tag))
+	valid := true
+	for _, i := range issues {
+		if i.Severity == pipeline.SeverityError {
+			valid = false
+		}
 	}
-	return validator.ValidateEntry(entry)
+	return c.JSON(fiber.Map{"valid": valid, "issues": issues})
 }
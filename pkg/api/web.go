@@ -24,6 +24,8 @@ func StartWebPortal() {
 	app.Get("/", indexPage)
 	app.Get("/upload", uploadPage)
 	app.Post("/upload-bom", handleUpload)
+	app.Post("/upload-bom/accept", handleAcceptUpload)
+	app.Post("/upload-bom/discard", handleDiscardUpload)
 	app.Get("/tags", tagsPage)
 
 	port := config.Load().Port
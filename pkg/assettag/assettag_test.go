@@ -1,6 +1,14 @@
 package assettag
 
-import "testing"
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/thornzero/udc_codec/pkg/classification"
+	"github.com/thornzero/udc_codec/pkg/config"
+)
 
 func TestParser(t *testing.T) {
 	tag, err := ParseTag("POL-LT1001")
@@ -11,3 +19,582 @@ func TestParser(t *testing.T) {
 		t.Errorf("expected LT, got %s", tag.FunctionCode)
 	}
 }
+
+func loadLetters(t *testing.T) *classification.ISALetters {
+	t.Helper()
+	letters, err := classification.LoadISALetters(filepath.Join("..", "..", "data", classification.ISALettersFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return letters
+}
+
+func TestParseISAFunction(t *testing.T) {
+	letters := loadLetters(t)
+
+	tests := []struct {
+		code        string
+		description string
+	}{
+		{"PDIT", "Pressure Differential Indicating Transmitter"},
+		{"FQIC", "Flow Totalizing Indicating Controller"},
+		{"LSHH", "Level Switch High High"},
+		{"PSV", "Pressure Safety Valve"},
+		{"PSH", "Pressure Switch High"},
+		{"TE", "Temperature Sensor"},
+		{"FCV", "Flow Controller Valve"},
+		{"PAL", "Pressure Alarm Low"},
+		{"lit", "Level Indicating Transmitter"},
+	}
+	for _, tt := range tests {
+		f, err := ParseISAFunction(tt.code, letters)
+		if err != nil {
+			t.Errorf("Expected %s to parse, got %v", tt.code, err)
+			continue
+		}
+		if got := f.Description(); got != tt.description {
+			t.Errorf("Expected %q for %s, got %q", tt.description, tt.code, got)
+		}
+	}
+
+	f, err := ParseISAFunction("PDIT", letters)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.FirstLetter.Letter != "P" || f.VariableModifier == nil || f.VariableModifier.Letter != "D" {
+		t.Errorf("Expected first letter P and variable modifier D, got %+v", f)
+	}
+	if len(f.ReadoutFunctions) != 1 || len(f.OutputFunctions) != 1 || len(f.FunctionModifiers) != 0 {
+		t.Errorf("Expected one readout and one output function, got %+v", f)
+	}
+
+	for _, code := range []string{"", "PD", "PH", "PTI", "FIQ", "1T"} {
+		if _, err := ParseISAFunction(code, letters); err == nil {
+			t.Errorf("Expected %q to be rejected", code)
+		}
+	}
+}
+
+func TestResolverValidateTag(t *testing.T) {
+	letters := loadLetters(t)
+	schemes := classification.NewRegistry(
+		classification.NewTable(classification.Systems, map[string]string{"POL": "Polyol System"}),
+		classification.NewISAScheme(letters, map[string]string{"PIT": "Pressure Indication Transmitter"}),
+	)
+	r := &Resolver{Schemes: schemes}
+
+	for _, fulltag := range []string{"POL-PDIT1001", "POL-FQIC2001", "POL-LSHH3001-A"} {
+		tag, err := ParseTag(fulltag)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := r.ValidateTag(tag); err != nil {
+			t.Errorf("Expected %s to be valid, got %v", fulltag, err)
+		}
+	}
+
+	tag, _ := ParseTag("POL-PTI1001")
+	if err := r.ValidateTag(tag); err == nil {
+		t.Error("Expected output function before readout to be rejected")
+	}
+
+	tag, _ = ParseTag("POL-PDIT1001")
+	if got := r.DescribeTag(tag); got != "Pressure Differential Indicating Transmitter 1001, Polyol System" {
+		t.Errorf("Unexpected description %q", got)
+	}
+
+	if !RegisterISA(schemes) {
+		t.Fatal("Expected ISA scheme to be replaced")
+	}
+	if title, ok := schemes.Lookup(classification.ISA, "LSHH"); !ok || title != "Level Switch High High" {
+		t.Errorf("Expected registry lookup to use the parser, got %q", title)
+	}
+}
+
+func TestISASchemeLookupPrefersNamed(t *testing.T) {
+	schemes, err := classification.LoadRegistryLang(filepath.Join("..", "..", "data"), "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	RegisterISA(schemes)
+	for code, want := range map[string]string{
+		"FCV":  "Flow Control Valve",
+		"PG":   "Pressure Gauge",
+		"PDIT": "Pressure Differential Indicating Transmitter",
+	} {
+		if title, ok := schemes.Lookup(classification.ISA, code); !ok || title != want {
+			t.Errorf("Expected %s to be %q, got %q", code, want, title)
+		}
+	}
+}
+
+func TestParseDesignation(t *testing.T) {
+	d, err := ParseDesignation("=A1=WP2-QA1+R101")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Levels) != 4 {
+		t.Fatalf("Expected 4 levels, got %d", len(d.Levels))
+	}
+	if got := d.String(); got != "=A1=WP2-QA1+R101" {
+		t.Errorf("Expected round trip, got %s", got)
+	}
+	if l := d.Levels[2]; l.Aspect != ProductAspect || l.Class != "QA" || l.Number != "1" {
+		t.Errorf("Expected product level QA 1, got %+v", l)
+	}
+
+	function, ok := d.Aspect(FunctionAspect)
+	if !ok || function.String() != "=A1=WP2" {
+		t.Errorf("Expected function aspect =A1=WP2, got %v", function)
+	}
+	if _, ok := d.Aspect('#'); ok {
+		t.Error("Expected no levels for an unused aspect")
+	}
+
+	parent, ok := d.Parent()
+	if !ok || parent.String() != "=A1=WP2-QA1" {
+		t.Errorf("Expected parent =A1=WP2-QA1, got %v", parent)
+	}
+	top, _ := ParseDesignation("=A1")
+	if _, ok := top.Parent(); ok {
+		t.Error("Expected no parent for a single level")
+	}
+
+	// A full stop continues the previous aspect
+	short, err := ParseDesignation("=A1.WP2-QA1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if short.String() != "=A1=WP2-QA1" {
+		t.Errorf("Expected =A1=WP2-QA1, got %s", short)
+	}
+
+	for _, s := range []string{"", "A1", "=A1-", "=1", "-qa1", "=A1..B2", "+R"} {
+		if _, err := ParseDesignation(s); err == nil {
+			t.Errorf("Expected %q to be rejected", s)
+		}
+	}
+}
+
+func TestDesignationValidate(t *testing.T) {
+	classes, err := classification.NewTree(classification.IEC81346, []*classification.TreeNode{
+		{Code: "A", Title: "Two or more purposes"},
+		{Code: "Q", Title: "Controlled switching", Children: []*classification.TreeNode{{Code: "QA", Title: "Switching electric energy"}}},
+		{Code: "W", Title: "Guiding", Children: []*classification.TreeNode{{Code: "WP", Title: "Guiding fluids"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, _ := ParseDesignation("=A1=WP2-QA1+R101")
+	if err := d.Validate(classes); err != nil {
+		t.Errorf("Expected designation to be valid, got %v", err)
+	}
+	d, _ = ParseDesignation("=A1-QZ1")
+	if err := d.Validate(classes); err == nil {
+		t.Error("Expected unknown subclass QZ to be rejected")
+	}
+	d, _ = ParseDesignation("-ZA1")
+	if err := d.Validate(classes); err == nil {
+		t.Error("Expected unknown main class Z to be rejected")
+	}
+
+	r := &Resolver{Schemes: classification.NewRegistry(
+		classification.NewTable(classification.Systems, map[string]string{"POL": "Polyol System"}),
+		classification.NewISAScheme(loadLetters(t), nil),
+		classes,
+	)}
+	tag, _ := ParseTag("POL-PT1001")
+	tag.Designation, _ = ParseDesignation("=A1-QZ1")
+	if err := r.ValidateTag(tag); err == nil {
+		t.Error("Expected tag with invalid designation to be rejected")
+	}
+}
+
+func TestTagScheme(t *testing.T) {
+	schemes, err := LoadTagSchemes(filepath.Join("..", "..", "data", TagSchemesFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scheme, err := schemes.Get("area-unit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tag, err := scheme.Parse("21-U2-PDIT-0042B")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tag.Segments["area"] != "21" || tag.Segments["unit"] != "U2" {
+		t.Errorf("Expected area 21 and unit U2, got %v", tag.Segments)
+	}
+	if tag.FunctionCode != "PDIT" || tag.EquipmentID != "0042" || tag.InstrumentID != "B" {
+		t.Errorf("Expected mapped fields PDIT 0042 B, got %+v", tag)
+	}
+	if got := scheme.Format(tag); got != "21-U2-PDIT-0042B" {
+		t.Errorf("Expected round trip, got %s", got)
+	}
+
+	// Digits are padded and empty optional segments left out
+	formatted := scheme.Format(scheme.NewTag(map[string]string{"area": "21", "unit": "UT", "function": "LT", "number": "7"}))
+	if formatted != "21-UT-LT-0007" {
+		t.Errorf("Expected 21-UT-LT-0007, got %s", formatted)
+	}
+
+	for _, bad := range []string{"21-U9-PIT-0042", "2-U2-PIT-0042", "21-U2-PIT-42"} {
+		if _, err := scheme.Parse(bad); err == nil {
+			t.Errorf("Expected %s to be rejected", bad)
+		}
+	}
+
+	if _, err := schemes.Get("missing"); err == nil {
+		t.Error("Expected error for unknown scheme")
+	}
+	if s, err := schemes.Get(""); err != nil || s.Name != DefaultTagSchemeName {
+		t.Errorf("Expected default scheme for empty name, got %v", err)
+	}
+}
+
+func TestDefaultTagScheme(t *testing.T) {
+	tag, err := ParseTag("CAT-FT3005-A")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tag.SystemCode != "CAT" || tag.FunctionCode != "FT" || tag.EquipmentID != "3005" || tag.InstrumentID != "A" {
+		t.Errorf("Unexpected tag %+v", tag)
+	}
+	if got := DefaultTagScheme().Format(tag); got != "CAT-FT3005-A" {
+		t.Errorf("Expected round trip, got %s", got)
+	}
+	if _, err := ParseTag("CAT-FT30"); err == nil {
+		t.Error("Expected short loop number to be rejected")
+	}
+}
+
+func TestTagSchemeValidation(t *testing.T) {
+	bad := []*TagScheme{
+		{Name: "empty"},
+		{Name: "type", Segments: []Segment{{Name: "a", Type: "hex"}}},
+		{Name: "dup", Segments: []Segment{{Name: "a", Type: SegmentDigits}, {Name: "a", Type: SegmentDigits}}},
+		{Name: "field", Segments: []Segment{{Name: "a", Type: SegmentDigits, Field: "area"}}},
+		{Name: "range", Segments: []Segment{{Name: "a", Type: SegmentDigits, Min: 4, Max: 2}}},
+	}
+	for _, s := range bad {
+		if _, err := NewTagSchemes(s); err == nil {
+			t.Errorf("Expected scheme %s to be rejected", s.Name)
+		}
+	}
+
+	letters := loadLetters(t)
+	r := &Resolver{Schemes: classification.NewRegistry(classification.NewISAScheme(letters, nil))}
+	scheme := &TagScheme{Name: "unit", Segments: []Segment{
+		{Name: "unit", Type: SegmentAlphanumeric, Length: 2, Vocabulary: classification.Systems},
+		{Name: "function", Type: SegmentLetters, Min: 1, Max: 5, Separator: "-", Vocabulary: classification.ISA, Field: FieldFunction},
+	}}
+	if _, err := NewTagSchemes(scheme); err != nil {
+		t.Fatal(err)
+	}
+	tag, err := scheme.Parse("U1-PTI")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.ValidateTag(tag); err == nil {
+		t.Error("Expected validation against a missing vocabulary to fail")
+	}
+}
+
+func TestParseKKS(t *testing.T) {
+	tests := []struct {
+		code      string
+		want      KKSCode
+		canonical string
+	}{
+		{"1LAB10AP001", KKSCode{Plant: "1", FunctionKey: "LAB", SystemNumber: "10", EquipmentKey: "AP", EquipmentNumber: "001"}, "1LAB10AP001"},
+		{"1 lab10 ap001 kp01", KKSCode{Plant: "1", FunctionKey: "LAB", SystemNumber: "10", EquipmentKey: "AP", EquipmentNumber: "001", ComponentKey: "KP", ComponentNumber: "01"}, "1LAB10AP001KP01"},
+		{"12PAB20CP101A", KKSCode{Plant: "1", Prefix: "2", FunctionKey: "PAB", SystemNumber: "20", EquipmentKey: "CP", EquipmentNumber: "101", Additional: "A"}, "12PAB20CP101A"},
+		{"LCA30", KKSCode{FunctionKey: "LCA", SystemNumber: "30"}, "LCA30"},
+	}
+	for _, tt := range tests {
+		k, err := ParseKKS(tt.code)
+		if err != nil {
+			t.Errorf("Expected %s to parse, got %v", tt.code, err)
+			continue
+		}
+		if *k != tt.want {
+			t.Errorf("Expected %+v for %s, got %+v", tt.want, tt.code, *k)
+		}
+		if k.String() != tt.canonical {
+			t.Errorf("Expected %s, got %s", tt.canonical, k.String())
+		}
+	}
+
+	for _, bad := range []string{"", "1LA10AP001", "1LAB1AP001", "1LAB10AP01", "1LAB10AP001K01"} {
+		if _, err := ParseKKS(bad); err == nil {
+			t.Errorf("Expected %q to be rejected", bad)
+		}
+	}
+
+	k, _ := ParseKKS("1LAB10AP001KP01")
+	parent, ok := k.Parent()
+	if !ok || parent.String() != "1LAB10AP001" {
+		t.Errorf("Expected parent 1LAB10AP001, got %v", parent)
+	}
+	parent, _ = parent.Parent()
+	if parent.String() != "1LAB10" {
+		t.Errorf("Expected parent 1LAB10, got %s", parent)
+	}
+	if _, ok := parent.Parent(); ok {
+		t.Error("Expected system level to have no parent")
+	}
+}
+
+func TestKKSValidateAndConvert(t *testing.T) {
+	dataDir := filepath.Join("..", "..", "data")
+	keys, rules, err := LoadKKS(dataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, code := range []string{"1LAB10AP001KP01", "1PAB20CP101", "1GHA10BB001"} {
+		k, _ := ParseKKS(code)
+		if err := keys.Validate(k); err != nil {
+			t.Errorf("Expected %s to be valid, got %v", code, err)
+		}
+	}
+	for _, code := range []string{"1IAB10AP001", "1LZB10AP001", "1LAZ10AP001", "1LAB10ZZ001", "1LAB10AP001ZZ01"} {
+		k, _ := ParseKKS(code)
+		if err := keys.Validate(k); err == nil {
+			t.Errorf("Expected %s to be rejected", code)
+		}
+	}
+
+	k, _ := ParseKKS("1LAB10AP001KP01")
+	if got := keys.Describe(k); got != "Feedwater piping system (excl. feedwater pump system) / Pump units / Pumps" {
+		t.Errorf("Unexpected description %q", got)
+	}
+
+	schemes, err := classification.LoadRegistry(dataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &Resolver{Schemes: schemes}
+
+	k, _ = ParseKKS("1LAB10CP101")
+	tag, err := rules.Convert(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := DefaultTagScheme().Format(tag); got != "FWS-PT10101" {
+		t.Errorf("Expected FWS-PT10101, got %s", got)
+	}
+	if tag.Designation.String() != "=A1=WP10-BP101" {
+		t.Errorf("Expected =A1=WP10-BP101, got %s", tag.Designation)
+	}
+	if tag.Segments["kks"] != "1LAB10CP101" {
+		t.Errorf("Expected original code in kks segment, got %v", tag.Segments)
+	}
+	if err := r.ValidateTag(tag); err != nil {
+		t.Errorf("Expected converted tag to be valid, got %v", err)
+	}
+
+	// Every rule must produce classes and ISA codes the registry accepts
+	for key := range rules.EquipmentUnits {
+		k, _ := ParseKKS("1LAB10" + key + "001")
+		tag, err := rules.Convert(k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tag.FunctionCode == "" {
+			tag.FunctionCode = "HV"
+		}
+		if err := r.ValidateTag(tag); err != nil {
+			t.Errorf("Expected rule %s to convert to a valid tag, got %v", key, err)
+		}
+	}
+
+	k, _ = ParseKKS("1AAB10AP001")
+	if _, err := rules.Convert(k); err == nil {
+		t.Error("Expected missing system rule to fail")
+	}
+	k, _ = ParseKKS("1LAB10")
+	if _, err := rules.Convert(k); err == nil {
+		t.Error("Expected system-level code to fail")
+	}
+}
+
+func TestGroupLoops(t *testing.T) {
+	letters := loadLetters(t)
+	var tags []*Tag
+	for _, s := range []string{"POL-FT1001", "POL-FIC1001", "POL-FV01001", "POL-LT1002", "POL-LIC1002", "POL-TT1002", "ISO-PDIT1001", "POL-LSHH1003"} {
+		tag, err := ParseTag(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tags = append(tags, tag)
+	}
+	tags = append(tags, &Tag{SystemCode: "POL", FunctionCode: "QQ", EquipmentID: "1004"})
+
+	loops := GroupLoops(tags, letters)
+	if len(loops) != 5 {
+		t.Fatalf("Expected 5 loops, got %d", len(loops))
+	}
+	if l := loops[0]; l.System != "ISO" || l.Variable != "P" || l.VariableName != "Pressure" || !l.Consistent() {
+		t.Errorf("Unexpected ISO loop %+v", l)
+	}
+	if l := loops[1]; l.Number != "1001" || len(l.Tags) != 3 || l.Variable != "F" || !l.Consistent() {
+		t.Errorf("Expected FT, FIC and FV to share loop 1001, got %+v", l)
+	}
+	l := loops[2]
+	if l.Variable != "L" || l.Consistent() {
+		t.Errorf("Expected loop 1002 to be level and flagged, got %+v", l)
+	}
+	if want := "tags measure different variables: L (POL-LT1002, POL-LIC1002), T (POL-TT1002)"; len(l.Issues) != 1 || l.Issues[0] != want {
+		t.Errorf("Expected issue %q, got %v", want, l.Issues)
+	}
+	if loops[4].Consistent() {
+		t.Error("Expected unparsable function code to be flagged")
+	}
+
+	summary := SummariseLoops(loops)
+	if len(summary) != 4 {
+		t.Fatalf("Expected 4 summary rows, got %+v", summary)
+	}
+	if s := summary[2]; s.System != "POL" || s.Variable != "F" || s.Loops != 1 || s.Instruments != 3 {
+		t.Errorf("Unexpected summary row %+v", s)
+	}
+	if s := summary[3]; s.Variable != "L" || s.Loops != 2 || s.Inconsistent != 1 {
+		t.Errorf("Expected both level loops with one inconsistent, got %+v", s)
+	}
+}
+
+func TestNormalise(t *testing.T) {
+	for _, variant := range []string{"POL-LT1001", "POL-LT-1001", "pol lt 1001", "POLLT1001", " pol_lt.1001 "} {
+		tag, err := NormaliseTag(variant)
+		if err != nil {
+			t.Errorf("Expected %q to normalise, got %v", variant, err)
+			continue
+		}
+		if got := tag.String(); got != "POL-LT1001" {
+			t.Errorf("Expected %q to normalise to POL-LT1001, got %s", variant, got)
+		}
+	}
+	if tag, _ := NormaliseTag("cat ft 3005 a"); tag == nil || tag.String() != "CAT-FT3005-A" {
+		t.Errorf("Expected suffix to be kept, got %v", tag)
+	}
+	if _, err := NormaliseTag("POL LT 10"); err == nil {
+		t.Error("Expected short loop number to be rejected")
+	}
+
+	schemes, err := LoadTagSchemes(filepath.Join("..", "..", "data", TagSchemesFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	scheme, _ := schemes.Get("area-unit")
+	tag, err := scheme.Normalise("21 u2 pit 42b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := tag.String(); got != "21-U2-PIT-0042B" {
+		t.Errorf("Expected padded 21-U2-PIT-0042B, got %s", got)
+	}
+}
+
+func TestNewResolver(t *testing.T) {
+	data := filepath.Join("..", "..", "data")
+	r, err := NewResolver(&config.Config{DataDir: data, Language: "en"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tag, _ := ParseTag("CAT-FT3005-A")
+	if err := r.ValidateTag(tag); err != nil {
+		t.Errorf("Expected CAT-FT3005-A to be valid, got %v", err)
+	}
+
+	dir := t.TempDir()
+	for _, name := range []string{classification.UDCFile, classification.ISALettersFile, classification.ISANamedFile, classification.SystemsFile, classification.IEC81346File} {
+		content, err := os.ReadFile(filepath.Join(data, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, TagListFile), []byte(`["POL-LT1001", "RES-TT5001", "POL-QQ1"]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{DataDir: dir, Language: "en"}
+	_, err = NewResolver(cfg)
+	if err == nil {
+		t.Fatal("Expected unknown system and bad tag to be reported")
+	}
+	for _, want := range []string{"RES-TT5001: unknown system code: RES", "POL-QQ1"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to mention %q, got %v", want, err)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "81346_systems_addendum_site.yaml"), []byte("RES: Resin System\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, TagListFile), []byte(`["RES-TT5001"]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewResolver(cfg); err != nil {
+		t.Errorf("Expected addendum system to be accepted, got %v", err)
+	}
+
+	if err := os.Remove(filepath.Join(dir, classification.IEC81346File)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewResolver(cfg); err == nil || !strings.Contains(err.Error(), classification.IEC81346File) {
+		t.Errorf("Expected missing %s to be reported, got %v", classification.IEC81346File, err)
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	letters := loadLetters(t)
+	schemes := classification.NewRegistry(
+		classification.NewTable(classification.Systems, map[string]string{"POL": "Polyol System"}),
+		classification.NewISAScheme(letters, nil),
+	)
+	templates, err := NewDescriptionTemplates(
+		map[string]string{
+			"de": "Messstelle {{ .Function }} {{ .Loop }}, {{ .SystemName }}",
+			"fr": "{{ upper .FunctionName }}",
+		},
+		map[string]map[string]string{
+			"alpha": {"en": "{{ .Tag }} {{ .Segments.suffix }}"},
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &Resolver{Schemes: schemes, Descriptions: templates}
+	tag, _ := ParseTag("POL-LT1001-A")
+
+	for _, c := range []struct{ project, language, want string }{
+		{"", "en", "Level Transmitter 1001-A, Polyol System"},
+		{"", "de-AT", "Messstelle LT 1001, Polyol System"},
+		{"", "it", "Level Transmitter 1001-A, Polyol System"},
+		{"alpha", "en", "POL-LT1001-A A"},
+		{"alpha", "de", "Messstelle LT 1001, Polyol System"},
+		{"alpha", "it", "POL-LT1001-A A"},
+		{"beta", "FR", "LEVEL TRANSMITTER"},
+	} {
+		got, err := r.Describe(tag, c.project, c.language)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != c.want {
+			t.Errorf("Expected %q for %s/%s, got %q", c.want, c.project, c.language, got)
+		}
+	}
+
+	if _, err := NewDescriptionTemplates(map[string]string{"en": "{{ .Unknown }}"}, nil); err == nil {
+		t.Error("Expected template with an unknown field to be rejected")
+	}
+	if _, err := NewDescriptionTemplates(map[string]string{"en": "{{ .Loop "}, nil); err == nil {
+		t.Error("Expected malformed template to be rejected")
+	}
+}
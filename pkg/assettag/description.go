@@ -0,0 +1,228 @@
+package assettag
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/thornzero/udc_codec/pkg/classification"
+	"github.com/thornzero/udc_codec/pkg/dictionary"
+)
+
+// DescriptionsFile is the file in the data directory holding the tag
+// description templates
+const DescriptionsFile = "tag_descriptions.yaml"
+
+// DefaultDescriptionTemplate is used when no template is defined for a
+// project or language, e.g. "Level Transmitter 1001-A, Polyol System"
+const DefaultDescriptionTemplate = `{{ .FunctionName }} {{ .Loop }}{{ with .Suffix }}-{{ . }}{{ end }}{{ with .SystemName }}, {{ . }}{{ end }}`
+
+// DescriptionData is what a description template can refer to
+type DescriptionData struct {
+	Project      string
+	Tag          string // Formatted tag, e.g. POL-LT1001-A
+	System       string // System code
+	SystemName   string
+	Function     string // ISA function code
+	FunctionName string // ISA expansion, e.g. Level Transmitter
+	Loop         string // Loop number
+	Suffix       string // Instrument suffix
+	UDC          string
+	UDCTitle     string
+	// UDCAncestry lists the UDC classes from the top of the hierarchy down
+	// to the tag's UDC code
+	UDCAncestry []classification.Class
+	Segments    map[string]string
+}
+
+// descriptionFuncs are available to description templates
+var descriptionFuncs = template.FuncMap{
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"join": func(sep string, values []string) string {
+		return strings.Join(values, sep)
+	},
+	// titles lists the titles of classes, e.g. of UDCAncestry
+	"titles": func(classes []classification.Class) []string {
+		titles := make([]string, len(classes))
+		for i, c := range classes {
+			titles[i] = c.Title
+		}
+		return titles
+	},
+}
+
+// DescriptionTemplates holds tag description templates by language, with
+// per-project overrides
+type DescriptionTemplates struct {
+	Templates map[string]string            `yaml:"templates"`
+	Projects  map[string]map[string]string `yaml:"projects,omitempty"`
+
+	compiled map[string]*template.Template
+}
+
+// NewDescriptionTemplates checks and compiles description templates
+func NewDescriptionTemplates(templates map[string]string, projects map[string]map[string]string) (*DescriptionTemplates, error) {
+	d := &DescriptionTemplates{Templates: templates, Projects: projects}
+	if err := d.compile(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// DefaultDescriptionTemplates returns the built-in English template only
+func DefaultDescriptionTemplates() *DescriptionTemplates {
+	d, err := NewDescriptionTemplates(nil, nil)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// LoadDescriptionTemplates loads description templates from a YAML file
+func LoadDescriptionTemplates(filename string) (*DescriptionTemplates, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filename, err)
+	}
+	var d DescriptionTemplates
+	if err := yaml.Unmarshal(data, &d); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+	if err := d.compile(); err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", filename, err)
+	}
+	return &d, nil
+}
+
+// LoadDescriptionTemplatesDir loads tag_descriptions.yaml from the data
+// directory, falling back to the built-in template when the file is missing
+func LoadDescriptionTemplatesDir(dataDir string) (*DescriptionTemplates, error) {
+	filename := filepath.Join(dataDir, DescriptionsFile)
+	if _, err := os.Stat(filename); err != nil {
+		return DefaultDescriptionTemplates(), nil
+	}
+	return LoadDescriptionTemplates(filename)
+}
+
+// compile parses every template, keyed by project and language
+func (d *DescriptionTemplates) compile() error {
+	d.compiled = make(map[string]*template.Template)
+	add := func(project, language, text string) error {
+		key := templateKey(project, language)
+		t, err := template.New(key).Funcs(descriptionFuncs).Option("missingkey=zero").Parse(text)
+		if err != nil {
+			return fmt.Errorf("invalid description template %s: %w", key, err)
+		}
+		// Run once against empty data to catch unknown fields
+		if err := t.Execute(&bytes.Buffer{}, DescriptionData{}); err != nil {
+			return fmt.Errorf("invalid description template %s: %w", key, err)
+		}
+		d.compiled[key] = t
+		return nil
+	}
+	if err := add("", "", DefaultDescriptionTemplate); err != nil {
+		return err
+	}
+	for language, text := range d.Templates {
+		if err := add("", language, text); err != nil {
+			return err
+		}
+	}
+	for project, templates := range d.Projects {
+		for language, text := range templates {
+			if err := add(project, language, text); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// templateKey names a template, e.g. "alpha/de" or "en"
+func templateKey(project, language string) string {
+	if project == "" {
+		return language
+	}
+	return project + "/" + language
+}
+
+// Template returns the template for a project and language. A locale such as
+// de-AT falls back to its language, then to English; for each language the
+// project's own template is preferred over the shared one.
+func (d *DescriptionTemplates) Template(project, language string) *template.Template {
+	for _, lang := range fallbackLanguages(language) {
+		if project != "" {
+			if t, ok := d.compiled[templateKey(project, lang)]; ok {
+				return t
+			}
+		}
+		if t, ok := d.compiled[templateKey("", lang)]; ok {
+			return t
+		}
+	}
+	return d.compiled[""]
+}
+
+// fallbackLanguages lists the languages to try for a locale, e.g. de-AT,
+// de, en
+func fallbackLanguages(locale string) []string {
+	var languages []string
+	locale = strings.ReplaceAll(strings.ToLower(locale), "_", "-")
+	for locale != "" {
+		languages = append(languages, locale)
+		i := strings.LastIndex(locale, "-")
+		if i < 0 {
+			break
+		}
+		locale = locale[:i]
+	}
+	if len(languages) == 0 || languages[len(languages)-1] != dictionary.DefaultLanguage {
+		languages = append(languages, dictionary.DefaultLanguage)
+	}
+	return languages
+}
+
+// DescriptionData collects what templates can refer to for a tag
+func (r *Resolver) DescriptionData(tag *Tag, project string) DescriptionData {
+	data := DescriptionData{
+		Project:  project,
+		Tag:      tag.String(),
+		System:   tag.SystemCode,
+		Function: tag.FunctionCode,
+		Loop:     tag.EquipmentID,
+		Suffix:   tag.InstrumentID,
+		UDC:      tag.UDCCode,
+		Segments: tag.Segments,
+	}
+	data.SystemName, _ = r.Schemes.Lookup(classification.Systems, tag.SystemCode)
+	if scheme, ok := r.isa(); ok && tag.FunctionCode != "" {
+		data.FunctionName, _ = scheme.Lookup(tag.FunctionCode)
+	}
+	if tag.UDCCode != "" {
+		data.UDCTitle, _ = r.Schemes.Lookup(classification.UDC, tag.UDCCode)
+		if udc, ok := r.Schemes.Get(classification.UDC); ok {
+			data.UDCAncestry, _ = udc.Ancestry(tag.UDCCode)
+		}
+	}
+	return data
+}
+
+// Describe writes a tag's description with the template for a project and
+// language. Runs of whitespace left by empty fields are collapsed.
+func (r *Resolver) Describe(tag *Tag, project, language string) (string, error) {
+	templates := r.Descriptions
+	if templates == nil {
+		templates = DefaultDescriptionTemplates()
+	}
+	var b bytes.Buffer
+	if err := templates.Template(project, language).Execute(&b, r.DescriptionData(tag, project)); err != nil {
+		return "", fmt.Errorf("failed to describe %s: %w", tag.String(), err)
+	}
+	return strings.Join(strings.Fields(b.String()), " "), nil
+}
@@ -0,0 +1,170 @@
+package assettag
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/thornzero/udc_codec/pkg/classification"
+)
+
+// Aspect is the prefix sign of an IEC 81346 reference designation
+type Aspect byte
+
+const (
+	FunctionAspect Aspect = '='
+	ProductAspect  Aspect = '-'
+	LocationAspect Aspect = '+'
+)
+
+func (a Aspect) String() string {
+	switch a {
+	case FunctionAspect:
+		return "function"
+	case ProductAspect:
+		return "product"
+	case LocationAspect:
+		return "location"
+	}
+	return string(a)
+}
+
+// DesignationLevel is one single-level reference designation, e.g. -QA1
+type DesignationLevel struct {
+	Aspect Aspect
+	Class  string // IEC 81346-2 class letters, e.g. QA
+	Number string
+}
+
+func (l DesignationLevel) String() string {
+	return string(l.Aspect) + l.Class + l.Number
+}
+
+// Designation is a multi-level IEC 81346 reference designation such as
+// =A1=WP2-QA1+R101, read left to right from the top of each aspect
+type Designation struct {
+	Levels []DesignationLevel
+}
+
+// Letters and number of a single level. Location levels may be a number
+// only, e.g. +101.
+var levelRegex = regexp.MustCompile(`^([A-Z]{0,3})(\d+)$`)
+
+// ParseDesignation parses a reference designation. Each level starts with
+// its aspect prefix; a full stop continues the aspect of the previous level,
+// so =A1.WP2 is the same as =A1=WP2.
+func ParseDesignation(s string) (*Designation, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, fmt.Errorf("empty reference designation")
+	}
+
+	d := &Designation{}
+	var aspect Aspect
+	start := -1
+	flush := func(end int) error {
+		if start < 0 {
+			return nil
+		}
+		level, err := parseLevel(aspect, s[start:end])
+		if err != nil {
+			return fmt.Errorf("invalid reference designation %s: %w", s, err)
+		}
+		d.Levels = append(d.Levels, level)
+		return nil
+	}
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '=' || c == '-' || c == '+':
+			if err := flush(i); err != nil {
+				return nil, err
+			}
+			aspect, start = Aspect(c), i+1
+		case c == '.':
+			if start < 0 {
+				return nil, fmt.Errorf("invalid reference designation %s: full stop before first aspect", s)
+			}
+			if err := flush(i); err != nil {
+				return nil, err
+			}
+			start = i + 1
+		case start < 0:
+			return nil, fmt.Errorf("invalid reference designation %s: must start with =, - or +", s)
+		}
+	}
+	if err := flush(len(s)); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// parseLevel parses the letters and number following an aspect prefix
+func parseLevel(aspect Aspect, s string) (DesignationLevel, error) {
+	parts := levelRegex.FindStringSubmatch(s)
+	if parts == nil {
+		return DesignationLevel{}, fmt.Errorf("invalid %s level %q", aspect, string(aspect)+s)
+	}
+	if parts[1] == "" && aspect != LocationAspect {
+		return DesignationLevel{}, fmt.Errorf("%s level %q has no class letters", aspect, string(aspect)+s)
+	}
+	return DesignationLevel{Aspect: aspect, Class: parts[1], Number: parts[2]}, nil
+}
+
+// String formats the designation with every prefix written out
+func (d *Designation) String() string {
+	var b strings.Builder
+	for _, l := range d.Levels {
+		b.WriteString(l.String())
+	}
+	return b.String()
+}
+
+// Parent returns the designation without its last level
+func (d *Designation) Parent() (*Designation, bool) {
+	if len(d.Levels) <= 1 {
+		return nil, false
+	}
+	return &Designation{Levels: append([]DesignationLevel(nil), d.Levels[:len(d.Levels)-1]...)}, true
+}
+
+// Aspect returns only the levels of one aspect, e.g. =A1=WP2 for the
+// function aspect of =A1=WP2-QA1+R101
+func (d *Designation) Aspect(a Aspect) (*Designation, bool) {
+	var levels []DesignationLevel
+	for _, l := range d.Levels {
+		if l.Aspect == a {
+			levels = append(levels, l)
+		}
+	}
+	if len(levels) == 0 {
+		return nil, false
+	}
+	return &Designation{Levels: levels}, true
+}
+
+// Last returns the lowest level of the designation
+func (d *Designation) Last() DesignationLevel {
+	return d.Levels[len(d.Levels)-1]
+}
+
+// Validate checks the class letters of the function and product levels
+// against the IEC 81346-2 classes. Location levels name spaces, which are
+// not in that table, and are not checked.
+func (d *Designation) Validate(classes classification.Scheme) error {
+	for _, l := range d.Levels {
+		if l.Aspect == LocationAspect || l.Class == "" {
+			continue
+		}
+		if _, ok := classes.Lookup(l.Class[:1]); !ok {
+			return fmt.Errorf("unknown IEC 81346-2 main class %s in %s", l.Class[:1], l)
+		}
+		if len(l.Class) > 1 {
+			if _, ok := classes.Lookup(l.Class[:2]); !ok {
+				return fmt.Errorf("unknown IEC 81346-2 subclass %s in %s", l.Class[:2], l)
+			}
+		}
+	}
+	return nil
+}
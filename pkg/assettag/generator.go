@@ -1,15 +1,11 @@
 package assettag
 
-import "fmt"
-
+// DescribeTag describes a tag with the shared description template for the
+// resolver's language, falling back to the tag itself if the template fails
 func (r *Resolver) DescribeTag(tag *Tag) string {
-	sys := r.IEC81346[tag.SystemCode]
-	isa := r.ISA[tag.FunctionCode]
-	udc := ""
-	if tag.UDCCode != "" {
-		if desc, ok := r.UDC.Lookup(tag.UDCCode); ok {
-			udc = fmt.Sprintf(" (%s)", desc)
-		}
+	desc, err := r.Describe(tag, "", r.Language)
+	if err != nil {
+		return tag.String()
 	}
-	return fmt.Sprintf("%s: %s %s %s%s", sys, isa, tag.EquipmentID, tag.InstrumentID, udc)
+	return desc
 }
@@ -0,0 +1,330 @@
+package assettag
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/thornzero/udc_codec/pkg/classification"
+)
+
+// DefaultTagSchemeName is the scheme used when a project names none
+const DefaultTagSchemeName = "default"
+
+// TagSchemesFile is the file in the data directory holding the tag schemes
+const TagSchemesFile = "tag_schemes.yaml"
+
+// Segment types
+const (
+	SegmentLetters      = "letters"
+	SegmentDigits       = "digits"
+	SegmentAlphanumeric = "alphanumeric"
+)
+
+// Tag fields a segment can fill
+const (
+	FieldSystem     = "system"
+	FieldFunction   = "function"
+	FieldEquipment  = "equipment"
+	FieldInstrument = "instrument"
+)
+
+// Segment is one named part of a tag
+type Segment struct {
+	Name string `json:"name" yaml:"name"`
+	// Type is letters, digits or alphanumeric
+	Type string `json:"type" yaml:"type"`
+	// Length fixes the length; otherwise Min and Max bound it (Max 0 is unbounded)
+	Length int `json:"length,omitempty" yaml:"length,omitempty"`
+	Min    int `json:"min,omitempty" yaml:"min,omitempty"`
+	Max    int `json:"max,omitempty" yaml:"max,omitempty"`
+	// Pad zero-pads digits up to Min (or Length) when formatting
+	Pad bool `json:"pad,omitempty" yaml:"pad,omitempty"`
+	// Separator is written before the segment
+	Separator string `json:"separator,omitempty" yaml:"separator,omitempty"`
+	Optional  bool   `json:"optional,omitempty" yaml:"optional,omitempty"`
+	// Values restricts the segment to a fixed list
+	Values []string `json:"values,omitempty" yaml:"values,omitempty"`
+	// Vocabulary names a classification scheme the value must exist in,
+	// e.g. systems or isa
+	Vocabulary string `json:"vocabulary,omitempty" yaml:"vocabulary,omitempty"`
+	// Field is the Tag field the segment fills: system, function,
+	// equipment or instrument
+	Field string `json:"field,omitempty" yaml:"field,omitempty"`
+}
+
+// TagScheme is a site's tag convention, defined as a list of segments.
+// The same definition parses and formats tags.
+type TagScheme struct {
+	Name        string    `json:"name" yaml:"name"`
+	Description string    `json:"description,omitempty" yaml:"description,omitempty"`
+	Example     string    `json:"example,omitempty" yaml:"example,omitempty"`
+	Segments    []Segment `json:"segments" yaml:"segments"`
+
+	regex *regexp.Regexp
+	loose *regexp.Regexp // Any or no separators, used by Normalise
+}
+
+// DefaultTagScheme returns the built-in convention, e.g. POL-LT1001-A
+func DefaultTagScheme() *TagScheme {
+	s := &TagScheme{
+		Name:        DefaultTagSchemeName,
+		Description: "System, ISA function letters, loop number and optional suffix",
+		Example:     "POL-LT1001-A",
+		Segments: []Segment{
+			{Name: "system", Type: SegmentLetters, Length: 3, Vocabulary: classification.Systems, Field: FieldSystem},
+			{Name: "function", Type: SegmentLetters, Min: 1, Max: 5, Separator: "-", Vocabulary: classification.ISA, Field: FieldFunction},
+			{Name: "number", Type: SegmentDigits, Min: 3, Max: 5, Field: FieldEquipment},
+			{Name: "suffix", Type: SegmentLetters, Length: 1, Separator: "-", Optional: true, Field: FieldInstrument},
+		},
+	}
+	if err := s.compile(); err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// compile checks the segments and builds the parsing expression
+func (s *TagScheme) compile() error {
+	if len(s.Segments) == 0 {
+		return fmt.Errorf("tag scheme %s has no segments", s.Name)
+	}
+	seen := make(map[string]bool)
+	var b, loose strings.Builder
+	b.WriteString("^")
+	loose.WriteString("^")
+	for _, seg := range s.Segments {
+		if seg.Name == "" {
+			return fmt.Errorf("tag scheme %s has a segment without a name", s.Name)
+		}
+		if seen[seg.Name] {
+			return fmt.Errorf("tag scheme %s has duplicate segment %s", s.Name, seg.Name)
+		}
+		seen[seg.Name] = true
+		switch seg.Field {
+		case "", FieldSystem, FieldFunction, FieldEquipment, FieldInstrument:
+		default:
+			return fmt.Errorf("tag scheme %s: unknown field %q for segment %s", s.Name, seg.Field, seg.Name)
+		}
+		pattern, err := seg.pattern()
+		if err != nil {
+			return fmt.Errorf("tag scheme %s: %w", s.Name, err)
+		}
+		fmt.Fprintf(&b, "(?:%s(%s))", regexp.QuoteMeta(seg.Separator), pattern)
+		loosePattern := pattern
+		if seg.Pad && seg.Type == SegmentDigits && len(seg.Values) == 0 {
+			// Padding is added when formatting, so accept shorter numbers
+			padded := seg
+			padded.Min, padded.Length = 1, 0
+			if padded.Max == 0 {
+				padded.Max = seg.Length
+			}
+			loosePattern, _ = padded.pattern()
+		}
+		fmt.Fprintf(&loose, "(?:%s(%s))", looseSeparator, loosePattern)
+		if seg.Optional {
+			b.WriteString("?")
+			loose.WriteString("?")
+		}
+	}
+	b.WriteString("$")
+	loose.WriteString(looseSeparator + "$")
+
+	regex, err := regexp.Compile(b.String())
+	if err != nil {
+		return fmt.Errorf("tag scheme %s: %w", s.Name, err)
+	}
+	s.regex = regex
+	s.loose = regexp.MustCompile(loose.String())
+	return nil
+}
+
+// pattern returns the expression matching a segment's value
+func (seg Segment) pattern() (string, error) {
+	if len(seg.Values) > 0 {
+		values := append([]string(nil), seg.Values...)
+		// Longest first so that alternation prefers the full value
+		sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+		for i, v := range values {
+			values[i] = regexp.QuoteMeta(v)
+		}
+		return strings.Join(values, "|"), nil
+	}
+
+	var class string
+	switch seg.Type {
+	case SegmentLetters:
+		class = "[A-Z]"
+	case SegmentDigits:
+		class = "[0-9]"
+	case SegmentAlphanumeric:
+		class = "[A-Z0-9]"
+	default:
+		return "", fmt.Errorf("segment %s has unknown type %q", seg.Name, seg.Type)
+	}
+
+	min, max := seg.Min, seg.Max
+	if seg.Length > 0 {
+		min, max = seg.Length, seg.Length
+	}
+	if min < 1 {
+		min = 1
+	}
+	switch {
+	case max == 0:
+		return fmt.Sprintf("%s{%d,}", class, min), nil
+	case max < min:
+		return "", fmt.Errorf("segment %s has max %d below min %d", seg.Name, max, min)
+	}
+	return fmt.Sprintf("%s{%d,%d}", class, min, max), nil
+}
+
+// Parse splits a tag into its segments
+func (s *TagScheme) Parse(tag string) (*Tag, error) {
+	parts := s.regex.FindStringSubmatch(tag)
+	if parts == nil {
+		if s.Example != "" {
+			return nil, fmt.Errorf("invalid tag format for scheme %s (expected e.g. %s): %s", s.Name, s.Example, tag)
+		}
+		return nil, fmt.Errorf("invalid tag format for scheme %s: %s", s.Name, tag)
+	}
+
+	values := make(map[string]string, len(s.Segments))
+	for i, seg := range s.Segments {
+		if parts[i+1] != "" {
+			values[seg.Name] = parts[i+1]
+		}
+	}
+	return s.NewTag(values), nil
+}
+
+// Format writes a tag using the scheme. Mapped fields take their value from
+// the Tag fields and other segments from Tag.Segments; empty optional
+// segments are left out together with their separator.
+func (s *TagScheme) Format(t *Tag) string {
+	var b strings.Builder
+	for _, seg := range s.Segments {
+		value := segmentValue(seg, t)
+		if value == "" && seg.Optional {
+			continue
+		}
+		if seg.Pad && seg.Type == SegmentDigits {
+			width := seg.Min
+			if seg.Length > 0 {
+				width = seg.Length
+			}
+			if len(value) < width {
+				value = strings.Repeat("0", width-len(value)) + value
+			}
+		}
+		b.WriteString(seg.Separator)
+		b.WriteString(value)
+	}
+	return b.String()
+}
+
+// NewTag builds a tag from segment values by name, filling the mapped fields
+func (s *TagScheme) NewTag(values map[string]string) *Tag {
+	t := &Tag{Scheme: s, Segments: values}
+	for _, seg := range s.Segments {
+		value := values[seg.Name]
+		switch seg.Field {
+		case FieldSystem:
+			t.SystemCode = value
+		case FieldFunction:
+			t.FunctionCode = value
+		case FieldEquipment:
+			t.EquipmentID = value
+		case FieldInstrument:
+			t.InstrumentID = value
+		}
+	}
+	return t
+}
+
+// segmentValue returns the value of a segment in a tag
+func segmentValue(seg Segment, t *Tag) string {
+	switch seg.Field {
+	case FieldSystem:
+		return t.SystemCode
+	case FieldFunction:
+		return t.FunctionCode
+	case FieldEquipment:
+		return t.EquipmentID
+	case FieldInstrument:
+		return t.InstrumentID
+	}
+	return t.Segments[seg.Name]
+}
+
+// TagSchemes holds the tag schemes available to projects by name
+type TagSchemes struct {
+	schemes map[string]*TagScheme
+}
+
+// NewTagSchemes creates a set holding the default scheme and the given schemes
+func NewTagSchemes(schemes ...*TagScheme) (*TagSchemes, error) {
+	set := &TagSchemes{schemes: map[string]*TagScheme{DefaultTagSchemeName: DefaultTagScheme()}}
+	for _, s := range schemes {
+		if err := s.compile(); err != nil {
+			return nil, err
+		}
+		set.schemes[s.Name] = s
+	}
+	return set, nil
+}
+
+// LoadTagSchemes loads tag schemes from a YAML list. A scheme named
+// "default" replaces the built-in default.
+func LoadTagSchemes(filename string) (*TagSchemes, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filename, err)
+	}
+	var schemes []*TagScheme
+	if err := yaml.Unmarshal(data, &schemes); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+	set, err := NewTagSchemes(schemes...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", filename, err)
+	}
+	return set, nil
+}
+
+// LoadTagSchemesDir loads tag_schemes.yaml from the data directory, falling
+// back to the default scheme alone when the file is missing
+func LoadTagSchemesDir(dataDir string) (*TagSchemes, error) {
+	filename := filepath.Join(dataDir, TagSchemesFile)
+	if _, err := os.Stat(filename); err != nil {
+		return NewTagSchemes()
+	}
+	return LoadTagSchemes(filename)
+}
+
+// Get returns a scheme by name; an empty name selects the default scheme
+func (ts *TagSchemes) Get(name string) (*TagScheme, error) {
+	if name == "" {
+		name = DefaultTagSchemeName
+	}
+	s, ok := ts.schemes[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown tag scheme: %s", name)
+	}
+	return s, nil
+}
+
+// Names returns the scheme names in sorted order
+func (ts *TagSchemes) Names() []string {
+	names := make([]string, 0, len(ts.schemes))
+	for name := range ts.schemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
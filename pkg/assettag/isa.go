@@ -0,0 +1,205 @@
+package assettag
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/thornzero/udc_codec/pkg/classification"
+)
+
+// ISALetter is one letter of a function identifier with its meaning
+type ISALetter struct {
+	Letter  string `json:"letter" yaml:"letter"`
+	Meaning string `json:"meaning" yaml:"meaning"`
+}
+
+// ISAFunction is an ISA-5.1 function identifier split into its parts,
+// e.g. PDIT = P (Pressure) D (Differential) I (Indicating) T (Transmitter)
+type ISAFunction struct {
+	Code              string      `json:"code" yaml:"code"`
+	FirstLetter       ISALetter   `json:"first_letter" yaml:"first_letter"`
+	VariableModifier  *ISALetter  `json:"variable_modifier,omitempty" yaml:"variable_modifier,omitempty"`
+	ReadoutFunctions  []ISALetter `json:"readout_functions,omitempty" yaml:"readout_functions,omitempty"`
+	OutputFunctions   []ISALetter `json:"output_functions,omitempty" yaml:"output_functions,omitempty"`
+	FunctionModifiers []ISALetter `json:"function_modifiers,omitempty" yaml:"function_modifiers,omitempty"`
+}
+
+// Positions of the succeeding letters, in the order they may appear
+const (
+	isaVariableModifier = iota
+	isaReadout
+	isaOutput
+	isaFunctionModifier
+)
+
+// ParseISAFunction splits a function identifier using the ISA-5.1 letter
+// tables. The first letter is the measured or initiating variable, followed
+// by an optional variable modifier, readout/passive functions, output
+// functions and function modifiers, in that order. At least one readout or
+// output function is required. Where a letter fits more than one position,
+// as S in PSV (Safety) and PSH (Switch), the variable modifier is preferred
+// as long as the rest of the identifier still parses.
+func ParseISAFunction(code string, letters *classification.ISALetters) (*ISAFunction, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return nil, fmt.Errorf("empty ISA function code")
+	}
+	first, ok := letters.FirstLetters[code[:1]]
+	if !ok {
+		return nil, fmt.Errorf("unknown ISA first letter %s in %s", code[:1], code)
+	}
+
+	p := &isaParser{letters: letters, code: code}
+	f := &ISAFunction{Code: code, FirstLetter: ISALetter{Letter: code[:1], Meaning: first}}
+	if !p.parse(f, 1, isaVariableModifier) {
+		if p.furthest >= len(code) {
+			return nil, fmt.Errorf("ISA function code %s has no readout or output function", code)
+		}
+		return nil, fmt.Errorf("invalid ISA letter %c at position %d in %s", code[p.furthest], p.furthest+1, code)
+	}
+	return f, nil
+}
+
+// isaParser backtracks over the positions a letter may take
+type isaParser struct {
+	letters  *classification.ISALetters
+	code     string
+	furthest int
+}
+
+func (p *isaParser) parse(f *ISAFunction, i, position int) bool {
+	if i > p.furthest {
+		p.furthest = i
+	}
+	if i == len(p.code) {
+		return len(f.ReadoutFunctions)+len(f.OutputFunctions) > 0
+	}
+
+	// Once there is a function letter, H, L and M are read as modifiers
+	// (PAL is Alarm Low, not Alarm Light)
+	hasFunction := len(f.ReadoutFunctions)+len(f.OutputFunctions) > 0
+	var positions []int
+	if hasFunction {
+		positions = append(positions, isaFunctionModifier)
+	}
+	for pos := position; pos < isaFunctionModifier; pos++ {
+		if pos != isaVariableModifier || i == 1 {
+			positions = append(positions, pos)
+		}
+	}
+
+	letter := p.code[i : i+1]
+	for _, pos := range positions {
+		meaning, ok := p.table(pos)[letter]
+		if !ok {
+			continue
+		}
+		next := *f
+		l := ISALetter{Letter: letter, Meaning: meaning}
+		switch pos {
+		case isaVariableModifier:
+			next.VariableModifier = &l
+		case isaReadout:
+			next.ReadoutFunctions = append(append([]ISALetter(nil), f.ReadoutFunctions...), l)
+		case isaOutput:
+			next.OutputFunctions = append(append([]ISALetter(nil), f.OutputFunctions...), l)
+		case isaFunctionModifier:
+			next.FunctionModifiers = append(append([]ISALetter(nil), f.FunctionModifiers...), l)
+		}
+		// Readouts and outputs may repeat; the variable modifier may not
+		following := pos
+		if pos == isaVariableModifier {
+			following = isaReadout
+		}
+		if p.parse(&next, i+1, following) {
+			*f = next
+			return true
+		}
+	}
+	return false
+}
+
+func (p *isaParser) table(position int) map[string]string {
+	switch position {
+	case isaVariableModifier:
+		return p.letters.VariableModifiers
+	case isaReadout:
+		return p.letters.ReadoutFunctions
+	case isaOutput:
+		return p.letters.OutputFunctions
+	}
+	return p.letters.FunctionModifiers
+}
+
+// Letters returns every letter in identifier order
+func (f *ISAFunction) Letters() []ISALetter {
+	letters := []ISALetter{f.FirstLetter}
+	if f.VariableModifier != nil {
+		letters = append(letters, *f.VariableModifier)
+	}
+	letters = append(letters, f.ReadoutFunctions...)
+	letters = append(letters, f.OutputFunctions...)
+	return append(letters, f.FunctionModifiers...)
+}
+
+// Description spells out the identifier using the first meaning of each
+// letter, e.g. "Pressure Differential Indicating Transmitter"
+func (f *ISAFunction) Description() string {
+	var words []string
+	for _, l := range f.Letters() {
+		meaning, _, _ := strings.Cut(l.Meaning, ",")
+		words = append(words, strings.TrimSpace(meaning))
+	}
+	return strings.Join(words, " ")
+}
+
+// ISAScheme is the ISA classification scheme backed by the full letter
+// parser. Identifiers in isa_prefix.yaml keep their curated names.
+type ISAScheme struct {
+	*classification.ISAScheme
+}
+
+// Lookup returns the name of an identifier from isa_prefix.yaml, else its
+// generated description
+func (s *ISAScheme) Lookup(code string) (string, bool) {
+	if title, ok := s.Named[code]; ok {
+		return title, true
+	}
+	if f, err := ParseISAFunction(code, s.Letters); err == nil {
+		return f.Description(), true
+	}
+	return s.ISAScheme.Lookup(code)
+}
+
+// Validate parses an identifier against the letter tables
+func (s *ISAScheme) Validate(code string) error {
+	if _, err := ParseISAFunction(code, s.Letters); err != nil {
+		if _, named := s.Named[code]; named {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// Parse splits an identifier into its letters
+func (s *ISAScheme) Parse(code string) (*ISAFunction, error) {
+	return ParseISAFunction(code, s.Letters)
+}
+
+// RegisterISA replaces the registry's ISA scheme with the parser-backed
+// scheme. It reports false if no ISA letter tables are loaded.
+func RegisterISA(schemes *classification.Registry) bool {
+	s, ok := schemes.Get(classification.ISA)
+	if !ok {
+		return false
+	}
+	switch s := s.(type) {
+	case *ISAScheme:
+		return true
+	case *classification.ISAScheme:
+		schemes.Register(&ISAScheme{ISAScheme: s})
+		return true
+	}
+	return false
+}
@@ -0,0 +1,270 @@
+package assettag
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Files in the data directory holding the KKS tables and conversion rules
+const (
+	KKSKeysFile  = "kks_keys.yaml"
+	KKSRulesFile = "kks_conversion.yaml"
+)
+
+// KKSCode is a process-related KKS identifier split into its breakdown
+// levels, e.g. 1LAB10AP001KP01:
+//
+//	level 0 plant       1
+//	level 1 system      LAB10  (function key LAB, system number 10)
+//	level 2 equipment   AP001  (equipment unit key AP, number 001)
+//	level 3 component   KP01   (component key KP, number 01)
+type KKSCode struct {
+	Plant           string `json:"plant,omitempty" yaml:"plant,omitempty"`
+	Prefix          string `json:"prefix,omitempty" yaml:"prefix,omitempty"`
+	FunctionKey     string `json:"function_key" yaml:"function_key"`
+	SystemNumber    string `json:"system_number" yaml:"system_number"`
+	EquipmentKey    string `json:"equipment_key,omitempty" yaml:"equipment_key,omitempty"`
+	EquipmentNumber string `json:"equipment_number,omitempty" yaml:"equipment_number,omitempty"`
+	Additional      string `json:"additional,omitempty" yaml:"additional,omitempty"`
+	ComponentKey    string `json:"component_key,omitempty" yaml:"component_key,omitempty"`
+	ComponentNumber string `json:"component_number,omitempty" yaml:"component_number,omitempty"`
+}
+
+// Plant, optional system prefix number, function key and system number,
+// then the optional equipment unit and component levels
+var kksRegex = regexp.MustCompile(`^([0-9A-Z]?)([0-9]?)([A-Z]{3})([0-9]{2})(?:([A-Z]{2})([0-9]{3})([A-Z]?)(?:-?([A-Z]{2})([0-9]{2}))?)?$`)
+
+// ParseKKS parses a KKS code. Spaces between breakdown levels, as in
+// "1 LAB10 AP001", are ignored.
+func ParseKKS(code string) (*KKSCode, error) {
+	compact := strings.ToUpper(strings.Join(strings.Fields(code), ""))
+	parts := kksRegex.FindStringSubmatch(compact)
+	if parts == nil {
+		return nil, fmt.Errorf("invalid KKS code: %s", code)
+	}
+	return &KKSCode{
+		Plant:           parts[1],
+		Prefix:          parts[2],
+		FunctionKey:     parts[3],
+		SystemNumber:    parts[4],
+		EquipmentKey:    parts[5],
+		EquipmentNumber: parts[6],
+		Additional:      parts[7],
+		ComponentKey:    parts[8],
+		ComponentNumber: parts[9],
+	}, nil
+}
+
+// String formats the code without spaces
+func (k *KKSCode) String() string {
+	s := k.Plant + k.Prefix + k.FunctionKey + k.SystemNumber
+	if k.EquipmentKey != "" {
+		s += k.EquipmentKey + k.EquipmentNumber + k.Additional
+	}
+	if k.ComponentKey != "" {
+		s += k.ComponentKey + k.ComponentNumber
+	}
+	return s
+}
+
+// Parent returns the code one breakdown level up
+func (k *KKSCode) Parent() (*KKSCode, bool) {
+	parent := *k
+	switch {
+	case k.ComponentKey != "":
+		parent.ComponentKey, parent.ComponentNumber = "", ""
+	case k.EquipmentKey != "":
+		parent.EquipmentKey, parent.EquipmentNumber, parent.Additional = "", "", ""
+	default:
+		return nil, false
+	}
+	return &parent, true
+}
+
+// KKSKeys holds the KKS key tables: function keys for systems (main group,
+// group and subgroup, e.g. L, LA, LAB), equipment unit keys (e.g. AP) and
+// component keys (e.g. KP)
+type KKSKeys struct {
+	FunctionKeys      map[string]string `yaml:"function_keys"`
+	EquipmentUnitKeys map[string]string `yaml:"equipment_unit_keys"`
+	ComponentKeys     map[string]string `yaml:"component_keys"`
+}
+
+// LoadKKSKeys loads the KKS key tables from a YAML file
+func LoadKKSKeys(filename string) (*KKSKeys, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filename, err)
+	}
+	var keys KKSKeys
+	if err := yaml.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+	return &keys, nil
+}
+
+// Validate checks each key of a code against the tables. Function keys
+// must be known down to the group (LA); the subgroup (LAB) is checked when
+// the table lists subgroups for that group.
+func (keys *KKSKeys) Validate(k *KKSCode) error {
+	fk := k.FunctionKey
+	if _, ok := keys.FunctionKeys[fk[:1]]; !ok {
+		return fmt.Errorf("unknown KKS function key main group %s in %s", fk[:1], k)
+	}
+	if _, ok := keys.FunctionKeys[fk[:2]]; !ok {
+		return fmt.Errorf("unknown KKS function key group %s in %s", fk[:2], k)
+	}
+	if _, ok := keys.FunctionKeys[fk]; !ok && keys.hasSubgroups(fk[:2]) {
+		return fmt.Errorf("unknown KKS function key %s in %s", fk, k)
+	}
+	if k.EquipmentKey != "" {
+		if _, ok := keys.EquipmentUnitKeys[k.EquipmentKey]; !ok {
+			return fmt.Errorf("unknown KKS equipment unit key %s in %s", k.EquipmentKey, k)
+		}
+	}
+	if k.ComponentKey != "" {
+		if _, ok := keys.ComponentKeys[k.ComponentKey]; !ok {
+			return fmt.Errorf("unknown KKS component key %s in %s", k.ComponentKey, k)
+		}
+	}
+	return nil
+}
+
+// hasSubgroups reports whether the table lists three-letter keys for a group
+func (keys *KKSKeys) hasSubgroups(group string) bool {
+	for key := range keys.FunctionKeys {
+		if len(key) == 3 && strings.HasPrefix(key, group) {
+			return true
+		}
+	}
+	return false
+}
+
+// Describe names the levels of a code, e.g. "Feedwater piping system /
+// Pump unit / Pump"
+func (keys *KKSKeys) Describe(k *KKSCode) string {
+	var parts []string
+	for _, key := range []string{k.FunctionKey, k.FunctionKey[:2], k.FunctionKey[:1]} {
+		if title, ok := keys.FunctionKeys[key]; ok {
+			parts = append(parts, title)
+			break
+		}
+	}
+	if title, ok := keys.EquipmentUnitKeys[k.EquipmentKey]; ok {
+		parts = append(parts, title)
+	}
+	if title, ok := keys.ComponentKeys[k.ComponentKey]; ok {
+		parts = append(parts, title)
+	}
+	return strings.Join(parts, " / ")
+}
+
+// KKSSystemRule maps a KKS function key to the project's system code and
+// the IEC 81346-2 class used for the function aspect
+type KKSSystemRule struct {
+	System string `yaml:"system,omitempty"`
+	Class  string `yaml:"class,omitempty"`
+}
+
+// KKSEquipmentRule maps a KKS equipment unit key to an ISA function code,
+// an IEC 81346-2 product class and optionally a UDC code
+type KKSEquipmentRule struct {
+	ISA   string `yaml:"isa,omitempty"`
+	Class string `yaml:"class,omitempty"`
+	UDC   string `yaml:"udc,omitempty"`
+}
+
+// KKSRules converts KKS codes into project tags. Systems are matched on the
+// longest known prefix of the function key (LAB, then LA, then L).
+type KKSRules struct {
+	Systems        map[string]KKSSystemRule    `yaml:"systems"`
+	EquipmentUnits map[string]KKSEquipmentRule `yaml:"equipment_units"`
+}
+
+// LoadKKSRules loads KKS conversion rules from a YAML file
+func LoadKKSRules(filename string) (*KKSRules, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filename, err)
+	}
+	var rules KKSRules
+	if err := yaml.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+	return &rules, nil
+}
+
+// LoadKKS loads the KKS key tables and conversion rules from the data directory
+func LoadKKS(dataDir string) (*KKSKeys, *KKSRules, error) {
+	keys, err := LoadKKSKeys(filepath.Join(dataDir, KKSKeysFile))
+	if err != nil {
+		return nil, nil, err
+	}
+	rules, err := LoadKKSRules(filepath.Join(dataDir, KKSRulesFile))
+	if err != nil {
+		return nil, nil, err
+	}
+	return keys, rules, nil
+}
+
+// system returns the rule for the longest matching function key prefix
+func (r *KKSRules) system(functionKey string) (KKSSystemRule, bool) {
+	for n := len(functionKey); n > 0; n-- {
+		if rule, ok := r.Systems[functionKey[:n]]; ok {
+			return rule, true
+		}
+	}
+	return KKSSystemRule{}, false
+}
+
+// Convert builds a project tag from a KKS code. The system and equipment
+// unit rules give the system code, ISA function code and UDC code; the loop
+// number joins the system and equipment numbers (LAB10 AP001 -> 10001).
+// The tag's designation has the plant and system in the function aspect and
+// the equipment unit in the product aspect, e.g. =A1=WP10-GP001. Without a
+// system code or ISA function the tag carries the designation only. The
+// original code is kept in the "kks" segment.
+func (r *KKSRules) Convert(k *KKSCode) (*Tag, error) {
+	sys, ok := r.system(k.FunctionKey)
+	if !ok {
+		return nil, fmt.Errorf("no conversion rule for KKS function key %s", k.FunctionKey)
+	}
+	if k.EquipmentKey == "" {
+		return nil, fmt.Errorf("KKS code %s has no equipment unit to convert", k)
+	}
+	eq, ok := r.EquipmentUnits[k.EquipmentKey]
+	if !ok {
+		return nil, fmt.Errorf("no conversion rule for KKS equipment unit key %s", k.EquipmentKey)
+	}
+
+	tag := &Tag{
+		SystemCode:   sys.System,
+		EquipmentID:  k.SystemNumber + k.EquipmentNumber,
+		InstrumentID: k.Additional,
+		UDCCode:      eq.UDC,
+		Segments:     map[string]string{"kks": k.String()},
+	}
+	if sys.System != "" {
+		tag.FunctionCode = eq.ISA
+	}
+
+	d := &Designation{}
+	if k.Plant != "" {
+		d.Levels = append(d.Levels, DesignationLevel{Aspect: FunctionAspect, Class: "A", Number: k.Plant})
+	}
+	if sys.Class != "" {
+		d.Levels = append(d.Levels, DesignationLevel{Aspect: FunctionAspect, Class: sys.Class, Number: k.SystemNumber})
+	}
+	if eq.Class != "" {
+		d.Levels = append(d.Levels, DesignationLevel{Aspect: ProductAspect, Class: eq.Class, Number: k.EquipmentNumber})
+	}
+	if len(d.Levels) > 0 {
+		tag.Designation = d
+	}
+	return tag, nil
+}
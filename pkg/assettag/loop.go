@@ -0,0 +1,151 @@
+package assettag
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/thornzero/udc_codec/pkg/classification"
+)
+
+// Loop is a group of instruments sharing a system and loop number, such as
+// FT-1001, FIC-1001 and FV-1001
+type Loop struct {
+	System       string
+	Number       string
+	Variable     string // Measured variable letter shared by most of the tags
+	VariableName string
+	Tags         []*Tag
+	Issues       []string // Inconsistent variable letters and unparsable function codes
+}
+
+// Consistent reports whether every tag in the loop measures the same variable
+func (l *Loop) Consistent() bool {
+	return len(l.Issues) == 0
+}
+
+// loopKey groups loop numbers written with and without leading zeros
+func loopKey(system, number string) string {
+	trimmed := strings.TrimLeft(number, "0")
+	if trimmed == "" {
+		trimmed = "0"
+	}
+	return system + "\x00" + trimmed
+}
+
+// GroupLoops groups tags into loops by system and loop number and infers
+// each loop's measured variable from the first letter of its function codes.
+// Loops whose tags disagree on the variable are flagged. letters may be nil,
+// in which case the first letter is taken as is.
+func GroupLoops(tags []*Tag, letters *classification.ISALetters) []*Loop {
+	byKey := make(map[string]*Loop)
+	var loops []*Loop
+	for _, tag := range tags {
+		key := loopKey(tag.SystemCode, tag.EquipmentID)
+		loop, ok := byKey[key]
+		if !ok {
+			loop = &Loop{System: tag.SystemCode, Number: tag.EquipmentID}
+			byKey[key] = loop
+			loops = append(loops, loop)
+		}
+		loop.Tags = append(loop.Tags, tag)
+	}
+	for _, loop := range loops {
+		loop.inferVariable(letters)
+	}
+	sort.SliceStable(loops, func(i, j int) bool {
+		if loops[i].System != loops[j].System {
+			return loops[i].System < loops[j].System
+		}
+		return loops[i].Number < loops[j].Number
+	})
+	return loops
+}
+
+// inferVariable picks the variable most tags share, the first seen on a tie
+func (l *Loop) inferVariable(letters *classification.ISALetters) {
+	counts := make(map[string]int)
+	byVariable := make(map[string][]string)
+	var order []string
+	meanings := make(map[string]string)
+	for _, tag := range l.Tags {
+		variable, meaning, err := measuredVariable(tag.FunctionCode, letters)
+		if err != nil {
+			l.Issues = append(l.Issues, fmt.Sprintf("%s: %v", tag, err))
+			continue
+		}
+		if counts[variable] == 0 {
+			order = append(order, variable)
+		}
+		counts[variable]++
+		byVariable[variable] = append(byVariable[variable], tag.String())
+		meanings[variable] = meaning
+	}
+	for _, v := range order {
+		if counts[v] > counts[l.Variable] {
+			l.Variable = v
+		}
+	}
+	l.VariableName = meanings[l.Variable]
+	if len(order) > 1 {
+		var parts []string
+		for _, v := range order {
+			parts = append(parts, fmt.Sprintf("%s (%s)", v, strings.Join(byVariable[v], ", ")))
+		}
+		l.Issues = append(l.Issues, "tags measure different variables: "+strings.Join(parts, ", "))
+	}
+}
+
+// measuredVariable returns the first letter of a function code and its meaning
+func measuredVariable(code string, letters *classification.ISALetters) (string, string, error) {
+	if letters == nil {
+		if code == "" {
+			return "", "", fmt.Errorf("empty ISA function code")
+		}
+		return strings.ToUpper(code[:1]), "", nil
+	}
+	f, err := ParseISAFunction(code, letters)
+	if err != nil {
+		return "", "", err
+	}
+	meaning, _, _ := strings.Cut(f.FirstLetter.Meaning, ",")
+	return f.FirstLetter.Letter, strings.TrimSpace(meaning), nil
+}
+
+// LoopSummary counts the loops and instruments of one measured variable in
+// a system
+type LoopSummary struct {
+	System       string `json:"system" yaml:"system"`
+	Variable     string `json:"variable" yaml:"variable"`
+	VariableName string `json:"variable_name,omitempty" yaml:"variable_name,omitempty"`
+	Loops        int    `json:"loops" yaml:"loops"`
+	Instruments  int    `json:"instruments" yaml:"instruments"`
+	Inconsistent int    `json:"inconsistent,omitempty" yaml:"inconsistent,omitempty"`
+}
+
+// SummariseLoops counts loops per system and measured variable
+func SummariseLoops(loops []*Loop) []LoopSummary {
+	index := make(map[string]int)
+	var summaries []LoopSummary
+	for _, l := range loops {
+		key := l.System + "\x00" + l.Variable
+		i, ok := index[key]
+		if !ok {
+			i = len(summaries)
+			index[key] = i
+			summaries = append(summaries, LoopSummary{System: l.System, Variable: l.Variable, VariableName: l.VariableName})
+		}
+		summaries[i].Loops++
+		summaries[i].Instruments += len(l.Tags)
+		if !l.Consistent() {
+			summaries[i].Inconsistent++
+		}
+	}
+	sort.SliceStable(summaries, func(i, j int) bool {
+		if summaries[i].System != summaries[j].System {
+			return summaries[i].System < summaries[j].System
+		}
+		return summaries[i].Variable < summaries[j].Variable
+	})
+	return summaries
+}
@@ -0,0 +1,38 @@
+package assettag
+
+import (
+	"fmt"
+	"strings"
+)
+
+// looseSeparator matches any run of separator characters, or none
+const looseSeparator = `[\s\-_./:]*`
+
+// Normalise maps a written variant of a tag to the scheme's canonical form.
+// Case, spacing and separators are ignored, so POL-LT1001, POL-LT-1001,
+// pol lt 1001 and POLLT1001 all give POL-LT1001 under the default scheme.
+// Digit segments are padded as the scheme specifies.
+func (s *TagScheme) Normalise(tag string) (*Tag, error) {
+	upper := strings.ToUpper(strings.TrimSpace(tag))
+	parts := s.loose.FindStringSubmatch(upper)
+	if parts == nil {
+		return nil, fmt.Errorf("cannot normalise %q under tag scheme %s", tag, s.Name)
+	}
+	values := make(map[string]string, len(s.Segments))
+	for i, seg := range s.Segments {
+		if parts[i+1] != "" {
+			values[seg.Name] = parts[i+1]
+		}
+	}
+	// The canonical form must also parse under the strict grammar
+	canonical, err := s.Parse(s.Format(s.NewTag(values)))
+	if err != nil {
+		return nil, fmt.Errorf("cannot normalise %q under tag scheme %s: %w", tag, s.Name, err)
+	}
+	return canonical, nil
+}
+
+// NormaliseTag normalises a tag under the default tag scheme
+func NormaliseTag(tag string) (*Tag, error) {
+	return defaultScheme.Normalise(tag)
+}
@@ -0,0 +1,122 @@
+package assettag
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/thornzero/udc_codec/pkg/classification"
+	"github.com/thornzero/udc_codec/pkg/config"
+)
+
+// TagListFile is the JSON list of site tags checked when a resolver is built
+const TagListFile = "asset_tags.json"
+
+// requiredSchemes are the schemes a resolver cannot work without, with the
+// file each is loaded from
+var requiredSchemes = []struct{ name, file string }{
+	{classification.UDC, classification.UDCFile},
+	{classification.ISA, classification.ISALettersFile},
+	{classification.Systems, classification.SystemsFile},
+	{classification.IEC81346, classification.IEC81346File},
+}
+
+// NewResolver loads the UDC, ISA, system and IEC 81346 reference tables and
+// the tag description templates from the configured data directory, with any
+// local addendums layered over the tables,
+// and checks that they agree with each other: named ISA identifiers must fit
+// the letter tables, tag scheme vocabularies must exist and every tag in
+// asset_tags.json must validate. All problems are reported together.
+func NewResolver(cfg *config.Config) (*Resolver, error) {
+	schemes, err := classification.LoadRegistryLang(cfg.DataDir, cfg.Language)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load reference data: %w", err)
+	}
+	RegisterISA(schemes)
+	descriptions, err := LoadDescriptionTemplatesDir(cfg.DataDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load reference data: %w", err)
+	}
+	r := &Resolver{Schemes: schemes, Descriptions: descriptions, Language: cfg.Language}
+	if err := r.check(cfg.DataDir); err != nil {
+		return nil, fmt.Errorf("invalid reference data in %s: %w", cfg.DataDir, err)
+	}
+	return r, nil
+}
+
+// check validates the loaded reference data against itself
+func (r *Resolver) check(dataDir string) error {
+	var errs []error
+	for _, s := range requiredSchemes {
+		if _, ok := r.Schemes.Get(s.name); !ok {
+			errs = append(errs, fmt.Errorf("%s: missing, needed for the %s scheme", s.file, s.name))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	isa, _ := r.Schemes.Get(classification.ISA)
+	if isa, ok := isa.(*ISAScheme); ok {
+		for code := range isa.Named {
+			if _, err := isa.Parse(code); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %s: %w", classification.ISANamedFile, code, err))
+			}
+		}
+	}
+
+	tagSchemes, err := LoadTagSchemesDir(dataDir)
+	if err != nil {
+		return err
+	}
+	for _, name := range tagSchemes.Names() {
+		s, _ := tagSchemes.Get(name)
+		for _, seg := range s.Segments {
+			if seg.Vocabulary == "" {
+				continue
+			}
+			if _, ok := r.Schemes.Get(seg.Vocabulary); !ok {
+				errs = append(errs, fmt.Errorf("%s: scheme %s segment %s: unknown vocabulary %s", TagSchemesFile, name, seg.Name, seg.Vocabulary))
+				continue
+			}
+			for _, v := range seg.Values {
+				if err := r.validateVocabulary(seg.Vocabulary, v); err != nil {
+					errs = append(errs, fmt.Errorf("%s: scheme %s segment %s: %w", TagSchemesFile, name, seg.Name, err))
+				}
+			}
+		}
+	}
+
+	tags, err := loadTagList(filepath.Join(dataDir, TagListFile))
+	if err != nil {
+		return err
+	}
+	for _, name := range tags {
+		tag, err := ParseTag(name)
+		if err == nil {
+			err = r.ValidateTag(tag)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s: %w", TagListFile, name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// loadTagList reads a JSON list of tags; a missing file is an empty list
+func loadTagList(filename string) ([]string, error) {
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filename, err)
+	}
+	var tags []string
+	if err := json.Unmarshal(data, &tags); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+	return tags, nil
+}
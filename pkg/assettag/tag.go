@@ -1,19 +1,47 @@
 package assettag
 
 import (
-	"github.com/thornzero/udc_codec/pkg/udc"
+	"github.com/thornzero/udc_codec/pkg/classification"
 )
 
 type Tag struct {
-	SystemCode   string // IEC 81346 system
-	EquipmentID  string // Equipment unique ID
-	InstrumentID string // Instrument unique ID
-	FunctionCode string // ISA-5.1 function letters
-	UDCCode      string // UDC functional context
+	SystemCode      string            // IEC 81346 system
+	EquipmentID     string            // Equipment unique ID
+	InstrumentID    string            // Instrument unique ID
+	FunctionCode    string            // ISA-5.1 function letters
+	UDCCode         string            // UDC functional context
+	Classifications map[string]string // Further codes keyed by scheme name, e.g. "eclass"
+	Designation     *Designation      // Full IEC 81346 reference designation, e.g. =A1=WP2-QA1+R101
+	Segments        map[string]string // Every segment value by name, including site-specific ones such as area
+	Scheme          *TagScheme        // Tag scheme the tag was parsed with, if any
 }
 
+// Resolver validates and describes tags against the registered classification
+// schemes: "systems" for system codes, "isa" for function codes and "udc"
+// for UDC codes, plus any scheme named in Tag.Classifications
 type Resolver struct {
-	UDC      *udc.Codec
-	ISA      map[string]string
-	IEC81346 map[string]string
+	Schemes *classification.Registry
+	// Descriptions holds the tag description templates; nil uses the
+	// built-in template
+	Descriptions *DescriptionTemplates
+	// Language selects the description template used by DescribeTag
+	Language string
+}
+
+// String formats the tag with the scheme it was parsed with, or the default
+// scheme
+func (t *Tag) String() string {
+	if t.Scheme != nil {
+		return t.Scheme.Format(t)
+	}
+	return defaultScheme.Format(t)
+}
+
+// ISALetters returns the ISA letter tables of the registered ISA scheme
+func (r *Resolver) ISALetters() (*classification.ISALetters, bool) {
+	s, _ := r.isa()
+	if isa, ok := s.(*ISAScheme); ok {
+		return isa.Letters, true
+	}
+	return nil, false
 }
@@ -1,16 +1,85 @@
 package assettag
 
-import "fmt"
+import (
+	"fmt"
 
+	"github.com/thornzero/udc_codec/pkg/classification"
+)
+
+// ValidateTag checks a tag against the classification schemes. Tags parsed
+// with a tag scheme are checked segment by segment against each segment's
+// vocabulary; other tags must have a known system and ISA function code.
 func (r *Resolver) ValidateTag(tag *Tag) error {
-	if _, ok := r.IEC81346[tag.SystemCode]; !ok {
-		return fmt.Errorf("unknown system code: %s", tag.SystemCode)
+	if tag.Scheme != nil {
+		if err := r.validateSegments(tag); err != nil {
+			return err
+		}
+	} else {
+		if err := r.validateVocabulary(classification.Systems, tag.SystemCode); err != nil {
+			return fmt.Errorf("unknown system code: %s", tag.SystemCode)
+		}
+		if err := r.validateVocabulary(classification.ISA, tag.FunctionCode); err != nil {
+			return fmt.Errorf("invalid ISA function code %s: %w", tag.FunctionCode, err)
+		}
+	}
+	if tag.UDCCode != "" {
+		if err := r.Schemes.Validate(classification.UDC, tag.UDCCode); err != nil {
+			return fmt.Errorf("unknown UDC code: %s", tag.UDCCode)
+		}
+	}
+	if tag.Designation != nil {
+		classes, err := r.Schemes.Scheme(classification.IEC81346)
+		if err != nil {
+			return err
+		}
+		if err := tag.Designation.Validate(classes); err != nil {
+			return err
+		}
 	}
-	if _, ok := r.ISA[tag.FunctionCode]; !ok {
-		return fmt.Errorf("unknown ISA function code: %s", tag.FunctionCode)
+	for scheme, code := range tag.Classifications {
+		if err := r.Schemes.Validate(scheme, code); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isa returns the ISA scheme, backed by the full letter parser when the
+// registry holds the plain letter tables
+func (r *Resolver) isa() (classification.Scheme, bool) {
+	s, ok := r.Schemes.Get(classification.ISA)
+	if plain, isPlain := s.(*classification.ISAScheme); isPlain {
+		return &ISAScheme{ISAScheme: plain}, true
 	}
-	if _, ok := r.UDC.Lookup(tag.UDCCode); !ok && tag.UDCCode != "" {
-		return fmt.Errorf("unknown UDC code: %s", tag.UDCCode)
+	return s, ok
+}
+
+// validateSegments checks every segment that names a vocabulary
+func (r *Resolver) validateSegments(tag *Tag) error {
+	for _, seg := range tag.Scheme.Segments {
+		value := segmentValue(seg, tag)
+		if seg.Vocabulary == "" || value == "" {
+			continue
+		}
+		if err := r.validateVocabulary(seg.Vocabulary, value); err != nil {
+			if seg.Vocabulary == classification.Systems {
+				return fmt.Errorf("unknown system code: %s", value)
+			}
+			return fmt.Errorf("invalid %s %s: %w", seg.Name, value, err)
+		}
 	}
 	return nil
 }
+
+// validateVocabulary validates a value against a named scheme, using the
+// full ISA letter parser for the isa scheme
+func (r *Resolver) validateVocabulary(name, value string) error {
+	if name == classification.ISA {
+		isa, ok := r.isa()
+		if !ok {
+			return fmt.Errorf("unknown classification scheme: %s", name)
+		}
+		return isa.Validate(value)
+	}
+	return r.Schemes.Validate(name, value)
+}
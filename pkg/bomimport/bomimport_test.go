@@ -0,0 +1,359 @@
+package bomimport
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding/charmap"
+
+	"github.com/thornzero/udc_codec/pkg/pipeline"
+)
+
+func TestImportCSV(t *testing.T) {
+	text := "Instrument Index;;;\n" +
+		"Rev B;;;\n" +
+		"Tag No.;Service;Eng. Unit;UDC;Area\n" +
+		"POL-LT-1001;Reactor level;%;681.2;21\n" +
+		";;;;\n" +
+		"pol ft 1002;Feed flow;m3/h;681.2;21\n" +
+		"ISO-TT1003-A;Tank temperature;DEG C;;22\n" +
+		"XX;Bad tag;bar;;\n" +
+		"ISO-PT1004;Discharge pressure;furlongs;;\n"
+	data, err := charmap.Windows1252.NewEncoder().Bytes([]byte(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	filename := filepath.Join(t.TempDir(), "index.csv")
+	if err := os.WriteFile(filename, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	bom, report, err := Load(filename, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bom.ProjectName != "index" {
+		t.Errorf("Expected project name from the file name, got %q", bom.ProjectName)
+	}
+	if report.HeaderRow != 3 || report.Columns[FieldTag] != "Tag No." || report.Columns["segments.area"] != "Area" {
+		t.Errorf("Expected header in row 3, got %+v", report)
+	}
+	if report.Rows != 5 || report.Imported != 4 {
+		t.Errorf("Expected 4 of 5 rows imported, got %d of %d", report.Imported, report.Rows)
+	}
+	if len(report.Errors) != 1 || report.Errors[0].Row != 8 || report.Errors[0].Value != "XX" {
+		t.Errorf("Expected an error for row 8, got %+v", report.Errors)
+	}
+	if len(report.Warnings) != 1 || report.Warnings[0].Row != 9 {
+		t.Errorf("Expected an unknown unit warning for row 9, got %+v", report.Warnings)
+	}
+	if report.Err() == nil {
+		t.Error("Expected report to fail with row errors")
+	}
+
+	e := bom.Entries[1]
+	if e.SystemCode != "POL" || e.FunctionCode != "FT" || e.EquipmentID != "1002" || e.Unit != "m³/h" || e.Segments["area"] != "21" {
+		t.Errorf("Unexpected entry %+v", e)
+	}
+	e = bom.Entries[2]
+	if e.InstrumentID != "A" || e.Unit != "°C" || e.Description != "Tank temperature" {
+		t.Errorf("Unexpected entry %+v", e)
+	}
+}
+
+func TestImportColumns(t *testing.T) {
+	p := &Profile{
+		Name: "vendor",
+		Columns: map[string][]string{
+			FieldSystem:    {"Anlage"},
+			FieldFunction:  {"Funktion"},
+			FieldEquipment: {"Nummer"},
+			FieldTag:       {"Kennzeichen"},
+		},
+		Units: map[string]string{"Grad C": "°C"},
+	}
+	rows := [][]string{
+		{"Anlage", "Funktion", "Nummer", "Kennzeichen"},
+		{"pol", "pit", "1001.0", ""},
+		{"", "", "", "POL-FT1002"},
+		{"ISO", "FT", "1003", "POL-FT1003"},
+		{"POL", "", "", ""},
+	}
+	bom, report, err := Import(rows, Options{Profile: p, ProjectName: "alpha"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Imported != 2 || len(report.Errors) != 2 {
+		t.Fatalf("Expected 2 rows imported and 2 errors, got %+v", report)
+	}
+	if e := bom.Entries[0]; e.SystemCode != "POL" || e.FunctionCode != "PIT" || e.EquipmentID != "1001" {
+		t.Errorf("Expected spreadsheet number to be read as 1001, got %+v", e)
+	}
+	if e := report.Errors[0]; e.Row != 4 || e.Column != "Anlage" {
+		t.Errorf("Expected system column to conflict with the tag, got %+v", e)
+	}
+	if e := report.Errors[1]; e.Row != 5 || e.Message != "missing function code" {
+		t.Errorf("Expected missing function code, got %+v", e)
+	}
+
+	if _, _, err := Import([][]string{{"Foo", "Bar"}, {"1", "2"}}, Options{Profile: p}); err == nil {
+		t.Error("Expected rows without a header to be rejected")
+	}
+}
+
+func TestMatchOverlappingAliases(t *testing.T) {
+	p := &Profile{
+		Columns: map[string][]string{
+			FieldDescription: {"Text"},
+			FieldTag:         {"Tag"},
+			FieldUnit:        {"Text"},
+		},
+		Segments: map[string][]string{"area": {"Bereich"}, "zone": {"Bereich"}},
+	}
+	for i := 0; i < 20; i++ {
+		m, _ := p.match([]string{"Tag", "Text", "Bereich"})
+		if c, ok := m.fields[FieldDescription]; !ok || c.index != 1 || len(m.fields) != 2 {
+			t.Fatalf("Expected Text to map to the description, got %+v", m.fields)
+		}
+		if _, ok := m.segments["area"]; !ok || len(m.segments) != 1 {
+			t.Fatalf("Expected Bereich to map to the area segment, got %+v", m.segments)
+		}
+	}
+}
+
+func TestNormaliseUnit(t *testing.T) {
+	p := &Profile{Units: map[string]string{"Grad C": "°C"}}
+	tests := []struct {
+		unit, want string
+		known      bool
+	}{
+		{"mW", "mW", true},
+		{"MW", "MW", true},
+		{"mA", "mA", true},
+		{"MA", "MA", false},
+		{"mw", "mw", false},
+		{"m bar", "mbar", true},
+		{"KW", "kW", true},
+		{"degC", "°C", true},
+		{"grad c", "°C", true},
+	}
+	for _, tt := range tests {
+		if got, known := p.normaliseUnit(tt.unit); got != tt.want || known != tt.known {
+			t.Errorf("Expected %q to normalise to %q (known %v), got %q (%v)", tt.unit, tt.want, tt.known, got, known)
+		}
+	}
+}
+
+func TestReadXLSX(t *testing.T) {
+	parts := map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Cover" sheetId="1" r:id="rId1"/><sheet name="Index" sheetId="2" r:id="rId2"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Target="worksheets/sheet1.xml"/><Relationship Id="rId2" Target="worksheets/sheet2.xml"/></Relationships>`,
+		"xl/sharedStrings.xml":     `<sst><si><t>Tag</t></si><si><t>Description</t></si><si><r><t>Reactor </t></r><r><t>level</t></r></si></sst>`,
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData/></worksheet>`,
+		"xl/worksheets/sheet2.xml": `<worksheet><sheetData>
+<row r="2"><c r="B2" t="s"><v>0</v></c><c r="C2" t="s"><v>1</v></c></row>
+<row r="3"><c r="B3" t="inlineStr"><is><t>POL-LT1001</t></is></c><c r="C3" t="s"><v>2</v></c><c r="D3"><v>681.2</v></c></row>
+</sheetData></worksheet>`,
+	}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range parts {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := ReadXLSX(buf.Bytes(), "index")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 || rows[1][1] != "Tag" || rows[2][1] != "POL-LT1001" || rows[2][2] != "Reactor level" || rows[2][3] != "681.2" {
+		t.Errorf("Unexpected rows %q", rows)
+	}
+	if _, err := ReadXLSX(buf.Bytes(), "missing"); err == nil {
+		t.Error("Expected unknown sheet to be rejected")
+	}
+
+	bom, report, err := Import(rows, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.HeaderRow != 2 || len(bom.Entries) != 1 || bom.Entries[0].Description != "Reactor level" {
+		t.Errorf("Expected one entry below header row 2, got %+v %+v", report, bom.Entries)
+	}
+}
+
+func TestReadXLSXReadsExportedTags(t *testing.T) {
+	records := []pipeline.ExportRecord{
+		{FullTag: "POL-LT1001", SystemName: "Polyol System", Description: "Tank <A> & level", UDCCode: "681.2"},
+		{FullTag: "POL-PT1002", Description: "  padded  "},
+	}
+	exporter, err := pipeline.DefaultExporters().Exporter("xlsx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := exporter.Write(&buf, "demo", records); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := ReadXLSX(buf.Bytes(), "Tags")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 || rows[0][0] != "full_tag" || rows[1][3] != "Tank <A> & level" || rows[1][4] != "681.2" || rows[2][3] != "  padded  " {
+		t.Errorf("Unexpected rows %q", rows)
+	}
+}
+
+func TestLoadProfiles(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), ProfilesFile)
+	content := "profiles:\n  - name: vendor\n    columns:\n      tag: [Kennzeichen]\n    encoding: latin1\n"
+	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	profiles, err := LoadProfiles(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := profiles.Names(); len(names) != 2 {
+		t.Errorf("Expected default and vendor profiles, got %v", names)
+	}
+
+	content = "profiles:\n  - name: bad\n    columns:\n      colour: [Colour]\n"
+	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadProfiles(filename); err == nil {
+		t.Error("Expected unknown field to be rejected")
+	}
+}
+
+// readAll reads every entry of a BOM file with Open
+func readAll(t *testing.T, filename string, opts Options) ([]pipeline.BOMEntry, *Rows) {
+	t.Helper()
+	rows, err := Open(filename, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var entries []pipeline.BOMEntry
+	for {
+		entry, err := rows.Next()
+		if errors.Is(err, io.EOF) {
+			return entries, rows
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, entry)
+	}
+}
+
+func TestOpenYAML(t *testing.T) {
+	boms := map[string]string{
+		"indented": `# Plant index
+project_name: plant
+entries:
+  # Reactor
+  - system_code: POL
+    function_code: LT
+    equipment_id: "1001"
+    description: |
+      Reactor level
+
+      - high range
+  - system_code: POL
+    function_code: PT
+    equipment_id: "1002"
+    description: Feed pressure
+    segments:
+      area: "21"
+tag_scheme: default
+warning_policy: block
+`,
+		"flush": `project_name: plant
+entries:
+- system_code: POL
+  function_code: LT
+  equipment_id: "1001"
+  description: Reactor level
+- {system_code: POL, function_code: PT, equipment_id: "1002"}
+warning_policy: block
+`,
+		"flow": `project_name: plant
+warning_policy: block
+entries: [{system_code: POL, function_code: LT, equipment_id: "1001"}]
+`,
+	}
+	for name, text := range boms {
+		filename := filepath.Join(t.TempDir(), name+".yaml")
+		if err := os.WriteFile(filename, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+		want, err := pipeline.LoadBOM(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries, rows := readAll(t, filename, Options{})
+		if !reflect.DeepEqual(entries, want.Entries) {
+			t.Errorf("%s: expected entries %+v, got %+v", name, want.Entries, entries)
+		}
+		if rows.BOM.ProjectName != "plant" || rows.BOM.WarningPolicy != "block" || len(rows.BOM.Entries) != 0 {
+			t.Errorf("%s: expected the project settings without entries, got %+v", name, rows.BOM)
+		}
+		if rows.Report.Rows != len(want.Entries) {
+			t.Errorf("%s: expected %d rows counted, got %d", name, len(want.Entries), rows.Report.Rows)
+		}
+	}
+}
+
+func TestOpenCSVMatchesLoad(t *testing.T) {
+	var text strings.Builder
+	text.WriteString("Instrument Index;;\nTag No.;Service;Eng. Unit\n")
+	for i := 0; i < 5000; i++ {
+		fmt.Fprintf(&text, "POL-LT-%d;Level %d °C;%%\n", 1000+i, i)
+	}
+	text.WriteString("XX;Bad tag;bar\n")
+	data, err := charmap.Windows1252.NewEncoder().Bytes([]byte(text.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	filename := filepath.Join(t.TempDir(), "index.csv")
+	if err := os.WriteFile(filename, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	want, wantReport, err := Load(filename, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, rows := readAll(t, filename, Options{})
+	if !reflect.DeepEqual(entries, want.Entries) {
+		t.Errorf("Expected the streamed entries to match Load, got %d of %d", len(entries), len(want.Entries))
+	}
+	if entries[4999].Description != "Level 4999 °C" || entries[4999].SourceRow != 5002 {
+		t.Errorf("Expected the last entry decoded from row 5002, got %+v", entries[4999])
+	}
+	if rows.BOM.ProjectName != "index" || rows.Report.HeaderRow != 2 {
+		t.Errorf("Expected project index with the header in row 2, got %s, %d", rows.BOM.ProjectName, rows.Report.HeaderRow)
+	}
+	if !reflect.DeepEqual(rows.Report.Errors, wantReport.Errors) || rows.Report.Rows != wantReport.Rows {
+		t.Errorf("Expected the row errors of Load, got %+v", rows.Report.Errors)
+	}
+}
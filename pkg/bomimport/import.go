@@ -0,0 +1,411 @@
+package bomimport
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/thornzero/udc_codec/pkg/assettag"
+	"github.com/thornzero/udc_codec/pkg/pipeline"
+)
+
+// headerScanRows is how many rows are searched for the header row
+const headerScanRows = 20
+
+// RowError is a problem with one cell or row of a spreadsheet
+type RowError struct {
+	Row     int    `json:"row" yaml:"row"`
+	Column  string `json:"column,omitempty" yaml:"column,omitempty"`
+	Value   string `json:"value,omitempty" yaml:"value,omitempty"`
+	Message string `json:"message" yaml:"message"`
+}
+
+func (e RowError) Error() string {
+	if e.Column != "" {
+		return fmt.Sprintf("row %d, %s %q: %s", e.Row, e.Column, e.Value, e.Message)
+	}
+	return fmt.Sprintf("row %d: %s", e.Row, e.Message)
+}
+
+// Report describes how a spreadsheet was read
+type Report struct {
+	File      string `json:"file" yaml:"file"`
+	Format    string `json:"format" yaml:"format"`
+	Profile   string `json:"profile,omitempty" yaml:"profile,omitempty"`
+	HeaderRow int    `json:"header_row,omitempty" yaml:"header_row,omitempty"`
+	// Columns maps each mapped field to the header it was read from
+	Columns map[string]string `json:"columns,omitempty" yaml:"columns,omitempty"`
+	// Ignored lists headers that no field maps to
+	Ignored  []string   `json:"ignored,omitempty" yaml:"ignored,omitempty"`
+	Rows     int        `json:"rows" yaml:"rows"`
+	Imported int        `json:"imported" yaml:"imported"`
+	Errors   []RowError `json:"errors,omitempty" yaml:"errors,omitempty"`
+	Warnings []RowError `json:"warnings,omitempty" yaml:"warnings,omitempty"`
+}
+
+// Err returns an error summarising the rows that failed, or nil
+func (r *Report) Err() error {
+	if len(r.Errors) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d of %d rows in %s failed to import, first: %v", len(r.Errors), r.Rows, filepath.Base(r.File), r.Errors[0])
+}
+
+// Options control an import
+type Options struct {
+	Profile *Profile
+	// ProjectName names spreadsheet BOMs and YAML BOMs without a
+	// project_name; the file name without extension by default
+	ProjectName string
+	// TagSchemes resolves the profile's tag scheme; nil knows only the
+	// default scheme
+	TagSchemes *assettag.TagSchemes
+}
+
+// Load reads a BOM from a YAML, CSV or XLSX file. The report is returned
+// whenever the file could be read, even if rows failed.
+func Load(filename string, opts Options) (*pipeline.ProjectBOM, *Report, error) {
+	format, err := FormatOf(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	if opts.Profile == nil {
+		opts.Profile = DefaultProfile()
+	}
+	if format == FormatYAML {
+		bom, err := pipeline.LoadBOM(filename)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load %s: %w", filename, err)
+		}
+		if bom.ProjectName == "" {
+			bom.ProjectName = opts.ProjectName
+		}
+		return bom, &Report{File: filename, Format: format, Rows: len(bom.Entries), Imported: len(bom.Entries)}, nil
+	}
+
+	rows, err := ReadTable(filename, opts.Profile)
+	if err != nil {
+		return nil, nil, err
+	}
+	if opts.ProjectName == "" {
+		opts.ProjectName = strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+	}
+	bom, report, err := Import(rows, opts)
+	if report != nil {
+		report.File, report.Format = filename, format
+	}
+	return bom, report, err
+}
+
+// column is a mapped spreadsheet column
+type column struct {
+	index  int
+	header string
+}
+
+// mapping is the columns found for each field, segment and classification
+type mapping struct {
+	fields          map[string]column
+	segments        map[string]column
+	classifications map[string]column
+}
+
+// match maps the cells of a header row
+func (p *Profile) match(row []string) (mapping, []string) {
+	m := mapping{
+		fields:          make(map[string]column),
+		segments:        make(map[string]column),
+		classifications: make(map[string]column),
+	}
+	var ignored []string
+	// Names are tried in a fixed order, so a header listed for two fields
+	// always maps to the same one
+	fieldNames := orderedNames(p.Columns, fields)
+	segmentNames := orderedNames(p.Segments, nil)
+	classificationNames := orderedNames(p.Classifications, nil)
+	find := func(target map[string]column, aliases map[string][]string, order []string, i int, cell, h string) bool {
+		for _, name := range order {
+			for _, alias := range aliases[name] {
+				if header(alias) != h {
+					continue
+				}
+				if _, taken := target[name]; !taken {
+					target[name] = column{index: i, header: cell}
+					return true
+				}
+			}
+		}
+		return false
+	}
+	for i, cell := range row {
+		h := header(cell)
+		if h == "" {
+			continue
+		}
+		if !find(m.fields, p.Columns, fieldNames, i, cell, h) && !find(m.segments, p.Segments, segmentNames, i, cell, h) && !find(m.classifications, p.Classifications, classificationNames, i, cell, h) {
+			ignored = append(ignored, strings.TrimSpace(cell))
+		}
+	}
+	return m, ignored
+}
+
+// orderedNames returns the keys of aliases: those in order first, in that
+// order, then the rest sorted
+func orderedNames(aliases map[string][]string, order []string) []string {
+	names := make([]string, 0, len(aliases))
+	for _, name := range order {
+		if _, ok := aliases[name]; ok {
+			names = append(names, name)
+		}
+	}
+	var rest []string
+	for name := range aliases {
+		if !slices.Contains(order, name) {
+			rest = append(rest, name)
+		}
+	}
+	slices.Sort(rest)
+	return append(names, rest...)
+}
+
+// score is the number of columns a header row maps
+func (m mapping) score() int {
+	return len(m.fields) + len(m.segments) + len(m.classifications)
+}
+
+// findHeader returns the 0-based header row: the profile's fixed row, or
+// the row among the first ones that maps the most columns
+func (p *Profile) findHeader(rows [][]string) (int, mapping, []string) {
+	if p.HeaderRow > 0 {
+		if p.HeaderRow > len(rows) {
+			return -1, mapping{}, nil
+		}
+		m, ignored := p.match(rows[p.HeaderRow-1])
+		return p.HeaderRow - 1, m, ignored
+	}
+	best, bestMapping, bestIgnored := -1, mapping{}, []string(nil)
+	for i := 0; i < len(rows) && i < headerScanRows; i++ {
+		m, ignored := p.match(rows[i])
+		if m.score() > bestMapping.score() {
+			best, bestMapping, bestIgnored = i, m, ignored
+		}
+	}
+	return best, bestMapping, bestIgnored
+}
+
+// wholeNumber matches numbers spreadsheets store for integer cells, e.g. 1001.0
+var wholeNumber = regexp.MustCompile(`^(\d+)\.0+$`)
+
+// Import turns spreadsheet rows into a BOM. Rows with errors are left out and
+// reported; an error is returned only when no usable header row is found.
+func Import(rows [][]string, opts Options) (*pipeline.ProjectBOM, *Report, error) {
+	im, headerRow, err := newImporter(rows, opts)
+	if im == nil {
+		return nil, nil, err
+	}
+	if err != nil {
+		return nil, im.report, err
+	}
+	bom := &pipeline.ProjectBOM{ProjectName: opts.ProjectName, TagScheme: im.profile.TagScheme}
+	for i := headerRow + 1; i < len(rows); i++ {
+		if entry, ok := im.row(i+1, rows[i]); ok {
+			bom.Entries = append(bom.Entries, entry)
+		}
+	}
+	return bom, im.report, nil
+}
+
+// importer turns the rows below a header row into entries
+type importer struct {
+	profile *Profile
+	mapping mapping
+	scheme  *assettag.TagScheme
+	report  *Report
+}
+
+// newImporter finds the header row among the first rows and maps its
+// columns. The 0-based header row is returned with the importer; without a
+// header row the importer still holds the report. An unknown tag scheme
+// returns no importer.
+func newImporter(rows [][]string, opts Options) (*importer, int, error) {
+	p := opts.Profile
+	if p == nil {
+		p = DefaultProfile()
+	}
+	schemes := opts.TagSchemes
+	if schemes == nil {
+		schemes, _ = assettag.NewTagSchemes()
+	}
+	scheme, err := schemes.Get(p.TagScheme)
+	if err != nil {
+		return nil, -1, err
+	}
+	im := &importer{profile: p, scheme: scheme, report: &Report{Profile: p.Name, Columns: make(map[string]string)}}
+
+	headerRow, m, ignored := p.findHeader(rows)
+	_, hasTag := m.fields[FieldTag]
+	_, hasSystem := m.fields[FieldSystem]
+	_, hasFunction := m.fields[FieldFunction]
+	if headerRow < 0 || !hasTag && !(hasSystem && hasFunction) {
+		return im, -1, fmt.Errorf("no header row with a tag column or system and function columns found (profile %s)", p.Name)
+	}
+	im.mapping = m
+	im.report.HeaderRow, im.report.Ignored = headerRow+1, ignored
+	for field, c := range m.fields {
+		im.report.Columns[field] = c.header
+	}
+	for name, c := range m.segments {
+		im.report.Columns["segments."+name] = c.header
+	}
+	for name, c := range m.classifications {
+		im.report.Columns["classifications."+name] = c.header
+	}
+	return im, headerRow, nil
+}
+
+// row imports one row; rowNumber is 1-based. It reports whether the row
+// gave an entry: blank rows are skipped and failed rows reported.
+func (im *importer) row(rowNumber int, row []string) (pipeline.BOMEntry, bool) {
+	if blank(row) {
+		return pipeline.BOMEntry{}, false
+	}
+	im.report.Rows++
+	entry, errs, warnings := im.profile.entry(rowNumber, row, im.mapping, im.scheme)
+	im.report.Warnings = append(im.report.Warnings, warnings...)
+	if len(errs) > 0 {
+		im.report.Errors = append(im.report.Errors, errs...)
+		return pipeline.BOMEntry{}, false
+	}
+	im.report.Imported++
+	return entry, true
+}
+
+// blank reports whether every cell of a row is empty
+func blank(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// entry builds a BOM entry from a row; rowNumber is 1-based
+func (p *Profile) entry(rowNumber int, row []string, m mapping, scheme *assettag.TagScheme) (pipeline.BOMEntry, []RowError, []RowError) {
+	var errs, warnings []RowError
+	cell := func(c column) string {
+		if c.index >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[c.index])
+	}
+	value := func(field string) string {
+		if c, ok := m.fields[field]; ok {
+			return cell(c)
+		}
+		return ""
+	}
+	fail := func(field, value, format string, args ...any) {
+		errs = append(errs, RowError{Row: rowNumber, Column: m.fields[field].header, Value: value, Message: fmt.Sprintf(format, args...)})
+	}
+
+	entry := pipeline.BOMEntry{
+		SystemCode:   strings.ToUpper(value(FieldSystem)),
+		FunctionCode: strings.ToUpper(value(FieldFunction)),
+		EquipmentID:  value(FieldEquipment),
+		InstrumentID: strings.ToUpper(value(FieldInstrumentID)),
+		Description:  value(FieldDescription),
+		UDCCode:      value(FieldUDC),
+		Designation:  value(FieldDesignation),
+		SourceRow:    rowNumber,
+	}
+	if n := wholeNumber.FindStringSubmatch(entry.EquipmentID); n != nil {
+		entry.EquipmentID = n[1]
+	}
+	for name, c := range m.segments {
+		if v := cell(c); v != "" {
+			if entry.Segments == nil {
+				entry.Segments = make(map[string]string)
+			}
+			entry.Segments[name] = v
+		}
+	}
+	for name, c := range m.classifications {
+		if v := cell(c); v != "" {
+			if entry.Classifications == nil {
+				entry.Classifications = make(map[string]string)
+			}
+			entry.Classifications[name] = v
+		}
+	}
+
+	if raw := value(FieldTag); raw != "" {
+		tag, err := scheme.Normalise(raw)
+		if err != nil {
+			fail(FieldTag, raw, "%v", err)
+			return entry, errs, warnings
+		}
+		for _, f := range []struct {
+			field  string
+			target *string
+			parsed string
+		}{
+			{FieldSystem, &entry.SystemCode, tag.SystemCode},
+			{FieldFunction, &entry.FunctionCode, tag.FunctionCode},
+			{FieldEquipment, &entry.EquipmentID, tag.EquipmentID},
+			{FieldInstrumentID, &entry.InstrumentID, tag.InstrumentID},
+		} {
+			if *f.target != "" && f.parsed != "" && strings.TrimLeft(*f.target, "0") != strings.TrimLeft(f.parsed, "0") {
+				fail(f.field, *f.target, "does not match tag %s", tag.String())
+			}
+			if f.parsed != "" {
+				*f.target = f.parsed
+			}
+		}
+		for _, seg := range scheme.Segments {
+			if v, ok := tag.Segments[seg.Name]; ok && seg.Field == "" {
+				if entry.Segments == nil {
+					entry.Segments = make(map[string]string)
+				}
+				entry.Segments[seg.Name] = v
+			}
+		}
+	}
+
+	if entry.SystemCode == "" && !schemeLacks(scheme, assettag.FieldSystem) {
+		fail(FieldSystem, "", "missing system code")
+	}
+	if entry.FunctionCode == "" && !schemeLacks(scheme, assettag.FieldFunction) {
+		fail(FieldFunction, "", "missing function code")
+	}
+
+	if raw := value(FieldUnit); raw != "" {
+		unit, known := p.normaliseUnit(raw)
+		entry.Unit = unit
+		if !known {
+			warnings = append(warnings, RowError{Row: rowNumber, Column: m.fields[FieldUnit].header, Value: raw, Message: "unknown unit, kept as written"})
+		}
+	}
+
+	// Entries without a loop number are left for allocation
+	if len(errs) == 0 && entry.EquipmentID != "" {
+		if formatted := pipeline.GenerateTag(entry, scheme); formatted != "" {
+			if _, err := scheme.Parse(formatted); err != nil {
+				errs = append(errs, RowError{Row: rowNumber, Message: err.Error()})
+			}
+		}
+	}
+	return entry, errs, warnings
+}
+
+// schemeLacks reports whether no segment of a scheme fills a tag field
+func schemeLacks(scheme *assettag.TagScheme, field string) bool {
+	for _, seg := range scheme.Segments {
+		if seg.Field == field {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,188 @@
+package bomimport
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProfilesFile is the file in the data directory holding the column-mapping
+// profiles
+const ProfilesFile = "bom_profiles.yaml"
+
+// DefaultProfileName is the profile used when none is named
+const DefaultProfileName = "default"
+
+// BOM entry fields a column can map to
+const (
+	FieldTag          = "tag"
+	FieldSystem       = "system_code"
+	FieldFunction     = "function_code"
+	FieldEquipment    = "equipment_id"
+	FieldDescription  = "description"
+	FieldUDC          = "udc_code"
+	FieldDesignation  = "designation"
+	FieldUnit         = "unit"
+	FieldInstrumentID = "instrument_id"
+)
+
+// fields lists every field a profile may map, in report order
+var fields = []string{FieldTag, FieldSystem, FieldFunction, FieldEquipment, FieldInstrumentID, FieldDescription, FieldUDC, FieldDesignation, FieldUnit}
+
+// Profile maps the columns of a spreadsheet to BOM entry fields. Columns are
+// matched by header text, ignoring case, spacing and punctuation.
+type Profile struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description,omitempty"`
+	// Columns lists the accepted header texts for each field
+	Columns map[string][]string `yaml:"columns"`
+	// Segments lists the header texts for extra tag segments, e.g. area
+	Segments map[string][]string `yaml:"segments,omitempty"`
+	// Classifications lists the header texts for codes in further schemes
+	Classifications map[string][]string `yaml:"classifications,omitempty"`
+	// Sheet selects the XLSX worksheet by name; the first sheet by default
+	Sheet string `yaml:"sheet,omitempty"`
+	// HeaderRow fixes the 1-based header row; it is detected when 0
+	HeaderRow int `yaml:"header_row,omitempty"`
+	// Delimiter fixes the CSV delimiter; it is detected when empty
+	Delimiter string `yaml:"delimiter,omitempty"`
+	// Encoding of CSV files: utf-8, utf-16, windows-1252 or iso-8859-1.
+	// When empty, byte order marks are honoured and text that is not valid
+	// UTF-8 is read as windows-1252.
+	Encoding string `yaml:"encoding,omitempty"`
+	// Units maps unit spellings to the unit written into the BOM, on top of
+	// the built-in table
+	Units map[string]string `yaml:"units,omitempty"`
+	// TagScheme is the tag scheme of the imported BOM and of the tag column
+	TagScheme string `yaml:"tag_scheme,omitempty"`
+}
+
+// DefaultProfile returns the built-in profile, which recognises common
+// English instrument index headers
+func DefaultProfile() *Profile {
+	return &Profile{
+		Name:        DefaultProfileName,
+		Description: "Common instrument index and BOM headers",
+		Columns: map[string][]string{
+			FieldTag:          {"tag", "tag no", "tag number", "instrument tag", "full tag"},
+			FieldSystem:       {"system", "system code", "sys"},
+			FieldFunction:     {"function", "function code", "isa", "isa code", "instrument type"},
+			FieldEquipment:    {"equipment id", "equipment", "loop", "loop no", "loop number", "number"},
+			FieldInstrumentID: {"suffix", "instrument id"},
+			FieldDescription:  {"description", "service", "service description", "desc"},
+			FieldUDC:          {"udc", "udc code"},
+			FieldDesignation:  {"designation", "reference designation", "iec 81346"},
+			FieldUnit:         {"unit", "units", "eng unit", "engineering unit", "uom"},
+		},
+		Segments: map[string][]string{"area": {"area"}, "unit": {"plant unit"}},
+	}
+}
+
+// header normalises header text for matching
+func header(text string) string {
+	text = strings.ToLower(strings.TrimSpace(text))
+	text = strings.Map(func(r rune) rune {
+		switch r {
+		case '_', '-', '.', '/', ':', '#', '(', ')':
+			return ' '
+		}
+		return r
+	}, text)
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// validate checks the profile's field names and options
+func (p *Profile) validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("profile without a name")
+	}
+	for field := range p.Columns {
+		known := false
+		for _, f := range fields {
+			known = known || f == field
+		}
+		if !known {
+			return fmt.Errorf("profile %s: unknown field %q", p.Name, field)
+		}
+	}
+	if _, err := decoder(p.Encoding); err != nil {
+		return fmt.Errorf("profile %s: %w", p.Name, err)
+	}
+	if len([]rune(p.Delimiter)) > 1 {
+		return fmt.Errorf("profile %s: delimiter must be a single character", p.Name)
+	}
+	return nil
+}
+
+// Profiles holds the column-mapping profiles by name
+type Profiles struct {
+	profiles map[string]*Profile
+}
+
+// NewProfiles creates a set holding the default profile and the given ones
+func NewProfiles(profiles ...*Profile) (*Profiles, error) {
+	set := &Profiles{profiles: map[string]*Profile{DefaultProfileName: DefaultProfile()}}
+	for _, p := range profiles {
+		if err := p.validate(); err != nil {
+			return nil, err
+		}
+		set.profiles[p.Name] = p
+	}
+	return set, nil
+}
+
+// LoadProfiles loads profiles from a YAML file. A profile named "default"
+// replaces the built-in one.
+func LoadProfiles(filename string) (*Profiles, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filename, err)
+	}
+	var file struct {
+		Profiles []*Profile `yaml:"profiles"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+	set, err := NewProfiles(file.Profiles...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", filename, err)
+	}
+	return set, nil
+}
+
+// LoadProfilesDir loads bom_profiles.yaml from the data directory, falling
+// back to the default profile alone when the file is missing
+func LoadProfilesDir(dataDir string) (*Profiles, error) {
+	filename := filepath.Join(dataDir, ProfilesFile)
+	if _, err := os.Stat(filename); err != nil {
+		return NewProfiles()
+	}
+	return LoadProfiles(filename)
+}
+
+// Get returns a profile by name; an empty name selects the default profile
+func (ps *Profiles) Get(name string) (*Profile, error) {
+	if name == "" {
+		name = DefaultProfileName
+	}
+	p, ok := ps.profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown BOM import profile: %s", name)
+	}
+	return p, nil
+}
+
+// Names returns the profile names in sorted order
+func (ps *Profiles) Names() []string {
+	names := make([]string, 0, len(ps.profiles))
+	for name := range ps.profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
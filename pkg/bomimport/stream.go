@@ -0,0 +1,398 @@
+package bomimport
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+	"gopkg.in/yaml.v3"
+
+	"github.com/thornzero/udc_codec/pkg/pipeline"
+)
+
+// sniffSize is how much of a stream is read ahead to detect its encoding
+// and delimiter
+const sniffSize = 64 << 10
+
+// Rows reads the entries of a BOM file one at a time, so files of any size
+// are imported in constant memory. YAML and CSV are read as they are
+// consumed; XLSX workbooks are compressed and read whole.
+type Rows struct {
+	// BOM holds the project settings of the file; Entries stays empty
+	BOM *pipeline.ProjectBOM
+	// Report counts the rows read so far, with their errors and warnings
+	Report *Report
+
+	next  func() (pipeline.BOMEntry, error)
+	close func() error
+}
+
+// Open starts reading the entries of a YAML, CSV or XLSX BOM file
+func Open(filename string, opts Options) (*Rows, error) {
+	format, err := FormatOf(filename)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Profile == nil {
+		opts.Profile = DefaultProfile()
+	}
+	var rows *Rows
+	switch format {
+	case FormatYAML:
+		rows, err = openYAML(filename)
+	case FormatCSV:
+		rows, err = openCSV(filename, opts)
+	default:
+		rows, err = openTable(filename, opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if rows.BOM.ProjectName == "" {
+		rows.BOM.ProjectName = opts.ProjectName
+	}
+	if rows.BOM.ProjectName == "" {
+		rows.BOM.ProjectName = strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+	}
+	rows.Report.File, rows.Report.Format = filename, format
+	return rows, nil
+}
+
+// Next returns the next entry, or io.EOF after the last one. Rows that
+// fail to import are skipped and recorded in the report.
+func (r *Rows) Next() (pipeline.BOMEntry, error) {
+	return r.next()
+}
+
+// Close releases the file
+func (r *Rows) Close() error {
+	if r.close == nil {
+		return nil
+	}
+	return r.close()
+}
+
+// openTable imports a spreadsheet in memory and hands out its entries
+func openTable(filename string, opts Options) (*Rows, error) {
+	table, err := ReadTable(filename, opts.Profile)
+	if err != nil {
+		return nil, err
+	}
+	bom, report, err := Import(table, opts)
+	if err != nil {
+		return nil, err
+	}
+	entries := bom.Entries
+	bom.Entries = nil
+	return &Rows{BOM: bom, Report: report, next: func() (pipeline.BOMEntry, error) {
+		if len(entries) == 0 {
+			return pipeline.BOMEntry{}, io.EOF
+		}
+		entry := entries[0]
+		entries = entries[1:]
+		return entry, nil
+	}}, nil
+}
+
+// openCSV reads delimited text row by row. The header row is searched in
+// the first rows as by ReadCSV and Import.
+func openCSV(filename string, opts Options) (*Rows, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filename, err)
+	}
+	p := opts.Profile
+	text, err := decodeReader(f, p.Encoding)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	r := csv.NewReader(text)
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	if p.Delimiter != "" {
+		r.Comma, _ = utf8.DecodeRuneInString(p.Delimiter)
+	} else {
+		head, _ := text.Peek(sniffSize)
+		r.Comma = sniffDelimiter(head)
+	}
+
+	// Buffer the rows the header row may be among
+	scan := max(headerScanRows, p.HeaderRow)
+	var buffered [][]string
+	for len(buffered) < scan {
+		row, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+		buffered = append(buffered, row)
+	}
+	im, headerRow, err := newImporter(buffered, opts)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	number := headerRow + 1
+	bom := &pipeline.ProjectBOM{TagScheme: p.TagScheme}
+	next := func() (pipeline.BOMEntry, error) {
+		for {
+			var row []string
+			if number < len(buffered) {
+				row = buffered[number]
+			} else {
+				buffered = nil
+				var err error
+				if row, err = r.Read(); err == io.EOF {
+					return pipeline.BOMEntry{}, io.EOF
+				} else if err != nil {
+					return pipeline.BOMEntry{}, fmt.Errorf("failed to read CSV: %w", err)
+				}
+			}
+			number++
+			if entry, ok := im.row(number, row); ok {
+				return entry, nil
+			}
+		}
+	}
+	return &Rows{BOM: bom, Report: im.report, next: next, close: f.Close}, nil
+}
+
+// decodeReader converts a stream to UTF-8 like decode. Without a named
+// encoding it is detected from the start of the stream.
+func decodeReader(r io.Reader, name string) (*bufio.Reader, error) {
+	enc, err := decoder(name)
+	if err != nil {
+		return nil, err
+	}
+	br := bufio.NewReaderSize(r, sniffSize)
+	if enc == nil {
+		head, _ := br.Peek(sniffSize)
+		switch {
+		case bytes.HasPrefix(head, []byte{0xEF, 0xBB, 0xBF}):
+			enc = unicode.UTF8BOM
+		case bytes.HasPrefix(head, []byte{0xFF, 0xFE}), bytes.HasPrefix(head, []byte{0xFE, 0xFF}):
+			enc = unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM)
+		case utf8.Valid(trimPartialRune(head)):
+			return br, nil
+		default:
+			enc = charmap.Windows1252
+		}
+	}
+	return bufio.NewReaderSize(enc.NewDecoder().Reader(br), sniffSize), nil
+}
+
+// trimPartialRune drops a rune cut off at the end of a buffer
+func trimPartialRune(b []byte) []byte {
+	start := len(b) - 1
+	for start > 0 && len(b)-start < utf8.UTFMax && !utf8.RuneStart(b[start]) {
+		start--
+	}
+	if start >= 0 && !utf8.FullRune(b[start:]) {
+		return b[:start]
+	}
+	return b
+}
+
+// openYAML reads the entries of a YAML BOM one list item at a time. The
+// project settings are read first in a pass over the other top-level
+// keys. An entries list in flow style is loaded whole.
+func openYAML(filename string) (*Rows, error) {
+	header, block, err := readYAMLHeader(filename)
+	if err != nil {
+		return nil, err
+	}
+	bom := &pipeline.ProjectBOM{}
+	if err := yaml.Unmarshal(header, bom); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+	report := &Report{}
+
+	if !block {
+		full, err := pipeline.LoadBOM(filename)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s: %w", filename, err)
+		}
+		entries := full.Entries
+		return &Rows{BOM: bom, Report: report, next: func() (pipeline.BOMEntry, error) {
+			if len(entries) == 0 {
+				return pipeline.BOMEntry{}, io.EOF
+			}
+			entry := entries[0]
+			entries = entries[1:]
+			report.Rows++
+			report.Imported++
+			return entry, nil
+		}}, nil
+	}
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filename, err)
+	}
+	items := &yamlItems{r: bufio.NewReader(f), indent: -1}
+	if err := items.skipTo("entries:"); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to read %s: %w", filename, err)
+	}
+	next := func() (pipeline.BOMEntry, error) {
+		text, err := items.next()
+		if err != nil {
+			return pipeline.BOMEntry{}, err
+		}
+		var entries []pipeline.BOMEntry
+		if err := yaml.Unmarshal(text, &entries); err != nil || len(entries) != 1 {
+			if err == nil {
+				err = errors.New("not a single entry")
+			}
+			return pipeline.BOMEntry{}, fmt.Errorf("failed to parse entry %d of %s: %w", report.Rows+1, filename, err)
+		}
+		report.Rows++
+		report.Imported++
+		return entries[0], nil
+	}
+	return &Rows{BOM: bom, Report: report, next: next, close: f.Close}, nil
+}
+
+// readYAMLHeader returns the top-level keys of a YAML BOM other than
+// entries, and whether the entries list is in block style
+func readYAMLHeader(filename string) ([]byte, bool, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read %s: %w", filename, err)
+	}
+	defer f.Close()
+
+	var header bytes.Buffer
+	r := bufio.NewReader(f)
+	inEntries, block := false, false
+	for {
+		line, err := r.ReadString('\n')
+		if line != "" {
+			topLevel := !startsIndented(line) && !isComment(line)
+			if topLevel && !(inEntries && strings.HasPrefix(line, "-")) {
+				inEntries = strings.HasPrefix(line, "entries:")
+				if inEntries {
+					rest := strings.TrimSpace(strings.TrimPrefix(line, "entries:"))
+					block = rest == "" || strings.HasPrefix(rest, "#")
+				}
+			}
+			if !inEntries {
+				header.WriteString(line)
+			}
+		}
+		if err == io.EOF {
+			return header.Bytes(), block, nil
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to read %s: %w", filename, err)
+		}
+	}
+}
+
+// yamlItems splits a block sequence into the text of its items
+type yamlItems struct {
+	r *bufio.Reader
+	// indent is the column of the item dashes, -1 until the first item
+	indent int
+	// pending is a line read ahead: the start of the next item
+	pending string
+	done    bool
+}
+
+// skipTo reads up to and including the top-level line starting with key
+func (y *yamlItems) skipTo(key string) error {
+	for {
+		line, err := y.r.ReadString('\n')
+		if strings.HasPrefix(line, key) {
+			return nil
+		}
+		if err == io.EOF {
+			return fmt.Errorf("no %s key", strings.TrimSuffix(key, ":"))
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// next returns the next item as a one-item sequence at column 0, or io.EOF
+func (y *yamlItems) next() ([]byte, error) {
+	var item bytes.Buffer
+	if y.pending != "" {
+		item.WriteString(y.pending[y.indent:])
+		y.pending = ""
+	}
+	for !y.done {
+		line, err := y.r.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if err == io.EOF {
+			y.done = true
+		}
+		if line == "" {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		content := strings.TrimSpace(line)
+		switch {
+		case content == "":
+			item.WriteString("\n")
+			continue
+		case y.indent < 0 && strings.HasPrefix(content, "#"):
+			continue
+		case y.indent < 0:
+			if !isItem(content) {
+				y.done = true
+				continue
+			}
+			y.indent = indent
+		case indent > y.indent:
+			item.WriteString(line[y.indent:])
+			continue
+		case strings.HasPrefix(content, "#"):
+			continue
+		case indent < y.indent || !isItem(content):
+			// The sequence ended; the rest of the file is header
+			y.done = true
+			continue
+		}
+		if item.Len() > 0 && strings.TrimSpace(item.String()) != "" {
+			y.pending = line
+			return item.Bytes(), nil
+		}
+		item.Reset()
+		item.WriteString(line[y.indent:])
+	}
+	if strings.TrimSpace(item.String()) == "" {
+		return nil, io.EOF
+	}
+	return item.Bytes(), nil
+}
+
+// isItem reports whether trimmed text starts a sequence item
+func isItem(content string) bool {
+	return content == "-" || strings.HasPrefix(content, "- ")
+}
+
+func startsIndented(line string) bool {
+	return strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") || strings.TrimSpace(line) == ""
+}
+
+func isComment(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), "#")
+}
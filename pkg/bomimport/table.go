@@ -0,0 +1,314 @@
+package bomimport
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// Spreadsheet formats
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+	FormatYAML = "yaml"
+)
+
+// FormatOf returns the format of a file by its extension
+func FormatOf(filename string) (string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv", ".txt", ".tsv":
+		return FormatCSV, nil
+	case ".xlsx", ".xlsm":
+		return FormatXLSX, nil
+	case ".yaml", ".yml":
+		return FormatYAML, nil
+	}
+	return "", fmt.Errorf("unsupported BOM file type: %s", filepath.Base(filename))
+}
+
+// ReadTable reads the rows of a CSV or XLSX file as text
+func ReadTable(filename string, p *Profile) ([][]string, error) {
+	format, err := FormatOf(filename)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filename, err)
+	}
+	switch format {
+	case FormatCSV:
+		return ReadCSV(data, p)
+	case FormatXLSX:
+		return ReadXLSX(data, p.Sheet)
+	}
+	return nil, fmt.Errorf("%s is not a spreadsheet", filepath.Base(filename))
+}
+
+// decoder returns the decoder for a named encoding; nil means detect
+func decoder(name string) (encoding.Encoding, error) {
+	switch strings.ToLower(strings.ReplaceAll(name, "_", "-")) {
+	case "":
+		return nil, nil
+	case "utf-8", "utf8":
+		return unicode.UTF8BOM, nil
+	case "utf-16", "utf16":
+		return unicode.UTF16(unicode.LittleEndian, unicode.UseBOM), nil
+	case "windows-1252", "cp1252":
+		return charmap.Windows1252, nil
+	case "iso-8859-1", "latin1", "latin-1":
+		return charmap.ISO8859_1, nil
+	}
+	return nil, fmt.Errorf("unsupported encoding %q", name)
+}
+
+// decode converts text to UTF-8, detecting the encoding if none is given
+func decode(data []byte, name string) ([]byte, error) {
+	enc, err := decoder(name)
+	if err != nil {
+		return nil, err
+	}
+	if enc == nil {
+		switch {
+		case bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}):
+			enc = unicode.UTF8BOM
+		case bytes.HasPrefix(data, []byte{0xFF, 0xFE}), bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+			enc = unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM)
+		case utf8.Valid(data):
+			return data, nil
+		default:
+			enc = charmap.Windows1252
+		}
+	}
+	decoded, err := enc.NewDecoder().Bytes(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode text: %w", err)
+	}
+	return decoded, nil
+}
+
+// ReadCSV reads delimited text, detecting the delimiter from the first lines
+// unless the profile fixes it
+func ReadCSV(data []byte, p *Profile) ([][]string, error) {
+	text, err := decode(data, p.Encoding)
+	if err != nil {
+		return nil, err
+	}
+	r := csv.NewReader(bytes.NewReader(text))
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	if p.Delimiter != "" {
+		r.Comma, _ = utf8.DecodeRuneInString(p.Delimiter)
+	} else {
+		r.Comma = sniffDelimiter(text)
+	}
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV: %w", err)
+	}
+	return rows, nil
+}
+
+// sniffDelimiter picks the most frequent of comma, semicolon, tab and pipe
+// in the first lines
+func sniffDelimiter(text []byte) rune {
+	lines := bytes.SplitN(text, []byte("\n"), 10)
+	best, bestCount := ',', 0
+	for _, d := range []rune{',', ';', '\t', '|'} {
+		count := 0
+		for _, line := range lines {
+			count += bytes.Count(line, []byte(string(d)))
+		}
+		if count > bestCount {
+			best, bestCount = d, count
+		}
+	}
+	return best
+}
+
+// XLSX parts read by ReadXLSX
+type (
+	xlsxWorkbook struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+			RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	xlsxRelationships struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	xlsxText struct {
+		Text string `xml:"t"`
+		Runs []struct {
+			Text string `xml:"t"`
+		} `xml:"r"`
+	}
+	xlsxSharedStrings struct {
+		Items []xlsxText `xml:"si"`
+	}
+	xlsxSheet struct {
+		Rows []struct {
+			Index int `xml:"r,attr"`
+			Cells []struct {
+				Ref    string    `xml:"r,attr"`
+				Type   string    `xml:"t,attr"`
+				Value  string    `xml:"v"`
+				Inline *xlsxText `xml:"is"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+)
+
+// String joins plain and rich text
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.Text
+	}
+	var b strings.Builder
+	for _, r := range t.Runs {
+		b.WriteString(r.Text)
+	}
+	return b.String()
+}
+
+// ReadXLSX reads the cell text of a worksheet, the first one when sheet is
+// empty. Numbers are returned as stored, booleans as TRUE or FALSE.
+func ReadXLSX(data []byte, sheet string) ([][]string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open XLSX: %w", err)
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	decodeXML := func(name string, v any) error {
+		f, ok := files[name]
+		if !ok {
+			return fmt.Errorf("failed to read XLSX: missing %s", name)
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("failed to read XLSX %s: %w", name, err)
+		}
+		defer rc.Close()
+		if err := xml.NewDecoder(rc).Decode(v); err != nil && err != io.EOF {
+			return fmt.Errorf("failed to parse XLSX %s: %w", name, err)
+		}
+		return nil
+	}
+
+	var wb xlsxWorkbook
+	if err := decodeXML("xl/workbook.xml", &wb); err != nil {
+		return nil, err
+	}
+	var rels xlsxRelationships
+	if err := decodeXML("xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+	rid := ""
+	for _, s := range wb.Sheets {
+		if sheet == "" || strings.EqualFold(s.Name, sheet) {
+			rid = s.RID
+			break
+		}
+	}
+	if rid == "" {
+		return nil, fmt.Errorf("failed to read XLSX: no worksheet %q", sheet)
+	}
+	target := ""
+	for _, r := range rels.Relationships {
+		if r.ID == rid {
+			target = r.Target
+		}
+	}
+	if strings.HasPrefix(target, "/") {
+		target = strings.TrimPrefix(target, "/")
+	} else {
+		target = path.Join("xl", target)
+	}
+
+	var shared xlsxSharedStrings
+	if _, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := decodeXML("xl/sharedStrings.xml", &shared); err != nil {
+			return nil, err
+		}
+	}
+	var ws xlsxSheet
+	if err := decodeXML(target, &ws); err != nil {
+		return nil, err
+	}
+
+	var rows [][]string
+	for i, r := range ws.Rows {
+		index := r.Index
+		if index == 0 {
+			index = i + 1
+		}
+		for len(rows) < index {
+			rows = append(rows, nil)
+		}
+		var row []string
+		for j, c := range r.Cells {
+			col := j
+			if c.Ref != "" {
+				if col, err = columnIndex(c.Ref); err != nil {
+					return nil, err
+				}
+			}
+			for len(row) <= col {
+				row = append(row, "")
+			}
+			switch c.Type {
+			case "s":
+				n, err := strconv.Atoi(c.Value)
+				if err != nil || n < 0 || n >= len(shared.Items) {
+					return nil, fmt.Errorf("failed to read XLSX cell %s: bad shared string %q", c.Ref, c.Value)
+				}
+				row[col] = shared.Items[n].String()
+			case "inlineStr":
+				if c.Inline != nil {
+					row[col] = c.Inline.String()
+				}
+			case "b":
+				row[col] = strings.ToUpper(strconv.FormatBool(c.Value == "1"))
+			default:
+				row[col] = c.Value
+			}
+		}
+		rows[index-1] = row
+	}
+	return rows, nil
+}
+
+// columnIndex returns the 0-based column of a cell reference such as AB12
+func columnIndex(ref string) (int, error) {
+	col := 0
+	for i, r := range ref {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			col = col*26 + int(r-'A'+1)
+		case r >= '0' && r <= '9' && i > 0:
+			return col - 1, nil
+		default:
+			return 0, fmt.Errorf("failed to read XLSX: bad cell reference %q", ref)
+		}
+	}
+	return 0, fmt.Errorf("failed to read XLSX: bad cell reference %q", ref)
+}
@@ -0,0 +1,76 @@
+package bomimport
+
+import "strings"
+
+// units maps common unit spellings, lower-cased without spaces, to the unit
+// written into the BOM
+var units = map[string]string{
+	"degc": "°C", "°c": "°C", "celsius": "°C", "c°": "°C",
+	"degf": "°F", "°f": "°F",
+	"k": "K", "kelvin": "K",
+	"bar": "bar", "barg": "barg", "bar(g)": "barg", "bara": "bara", "bar(a)": "bara",
+	"pa": "Pa", "kpa": "kPa", "psi": "psi", "psig": "psig", "psia": "psia",
+	"m3/h": "m³/h", "m³/h": "m³/h", "m3/hr": "m³/h", "m^3/h": "m³/h", "cum/h": "m³/h",
+	"l/min": "l/min", "lpm": "l/min", "l/h": "l/h", "l/s": "l/s",
+	"kg/h": "kg/h", "kg/hr": "kg/h", "t/h": "t/h", "kg/s": "kg/s",
+	"%": "%", "pct": "%", "percent": "%",
+	"mm": "mm", "m": "m", "cm": "cm",
+	"rpm": "rpm", "1/min": "rpm", "min-1": "rpm",
+	"a": "A", "amp": "A", "v": "V", "kv": "kV",
+	"w": "W", "kw": "kW", "hz": "Hz",
+	"ph": "pH", "ppm": "ppm", "kg": "kg", "t": "t",
+}
+
+// prefixedUnits maps units whose SI prefix changes with case, such as mW
+// and MW, written without spaces. They only match with the case as
+// written.
+var prefixedUnits = map[string]string{
+	"mA": "mA",
+	"mV": "mV", "MV": "MV",
+	"mW": "mW", "MW": "MW",
+	"mbar": "mbar", "MPa": "MPa",
+}
+
+// caseSensitive holds the lower-cased spellings of prefixedUnits, which
+// never match case-insensitively
+var caseSensitive = func() map[string]bool {
+	keys := make(map[string]bool, len(prefixedUnits))
+	for spelling := range prefixedUnits {
+		keys[strings.ToLower(spelling)] = true
+	}
+	return keys
+}()
+
+// unitKey removes the spaces from a unit spelling
+func unitKey(unit string) string {
+	return strings.Join(strings.Fields(unit), "")
+}
+
+// normaliseUnit returns the BOM spelling of a unit, checking the profile's
+// table before the built-in one. Spellings match regardless of case unless
+// case decides the SI prefix, as for mW and MW. Unknown units are returned
+// unchanged with ok false.
+func (p *Profile) normaliseUnit(unit string) (string, bool) {
+	key := unitKey(unit)
+	lower := strings.ToLower(key)
+	for spelling, canonical := range p.Units {
+		if unitKey(spelling) == key {
+			return canonical, true
+		}
+	}
+	if canonical, ok := prefixedUnits[key]; ok {
+		return canonical, true
+	}
+	if caseSensitive[lower] {
+		return strings.TrimSpace(unit), false
+	}
+	for spelling, canonical := range p.Units {
+		if strings.ToLower(unitKey(spelling)) == lower {
+			return canonical, true
+		}
+	}
+	if canonical, ok := units[lower]; ok {
+		return canonical, true
+	}
+	return strings.TrimSpace(unit), false
+}
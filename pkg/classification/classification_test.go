@@ -0,0 +1,193 @@
+package classification
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testISA() *ISAScheme {
+	letters := &ISALetters{
+		FirstLetters:      map[string]string{"P": "Pressure", "L": "Level", "F": "Flow"},
+		VariableModifiers: map[string]string{"D": "Differential"},
+		ReadoutFunctions:  map[string]string{"I": "Indicating"},
+		OutputFunctions:   map[string]string{"T": "Transmitter", "C": "Controller", "S": "Switch"},
+		FunctionModifiers: map[string]string{"H": "High"},
+	}
+	return NewISAScheme(letters, map[string]string{"PIT": "Pressure Indication Transmitter", "PI": "Pressure Indication"})
+}
+
+func TestRegistry(t *testing.T) {
+	systems := NewTable(Systems, map[string]string{"POL": "Polyol System"})
+	r := NewRegistry(systems, testISA())
+
+	names := r.Names()
+	if len(names) != 2 || names[0] != ISA || names[1] != Systems {
+		t.Errorf("Expected [isa systems], got %v", names)
+	}
+	if title, ok := r.Lookup(Systems, "POL"); !ok || title != "Polyol System" {
+		t.Errorf("Expected Polyol System, got %q", title)
+	}
+	if _, ok := r.Lookup("missing", "POL"); ok {
+		t.Error("Expected lookup in unknown scheme to fail")
+	}
+	if err := r.Validate("missing", "POL"); err == nil {
+		t.Error("Expected validation against unknown scheme to fail")
+	}
+	if err := r.Validate(Systems, "XYZ"); err == nil {
+		t.Error("Expected unknown system code to fail validation")
+	}
+
+	r.Register(NewTable(Systems, map[string]string{"ISO": "Isocyanate System"}))
+	if _, ok := r.Lookup(Systems, "POL"); ok {
+		t.Error("Expected registering a scheme to replace the previous one")
+	}
+}
+
+func TestISAScheme(t *testing.T) {
+	s := testISA()
+
+	for _, code := range []string{"PIT", "PDIT", "LT", "FIC", "PSH"} {
+		if err := s.Validate(code); err != nil {
+			t.Errorf("Expected %s to be valid, got %v", code, err)
+		}
+	}
+	for _, code := range []string{"", "QT", "PQ"} {
+		if err := s.Validate(code); err == nil {
+			t.Errorf("Expected %q to be invalid", code)
+		}
+	}
+
+	if title, ok := s.Lookup("P"); !ok || title != "Pressure" {
+		t.Errorf("Expected Pressure, got %q", title)
+	}
+	children, ok := s.Children("PI")
+	if !ok || len(children) != 1 || children[0].Code != "PIT" {
+		t.Errorf("Expected PIT as child of PI, got %v", children)
+	}
+	ancestry, ok := s.Ancestry("PIT")
+	if !ok || len(ancestry) != 3 || ancestry[0].Code != "P" || ancestry[2].Code != "PIT" {
+		t.Errorf("Expected P > PI > PIT, got %v", ancestry)
+	}
+}
+
+func TestTree(t *testing.T) {
+	tree, err := NewTree(IEC81346, []*TreeNode{
+		{Code: "B", Title: "Sensing", Children: []*TreeNode{
+			{Code: "BP", Title: "Sensing a pressure"},
+			{Code: "BT", Title: "Sensing a temperature"},
+		}},
+		{Code: "Q", Title: "Controlled switching"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tree.Validate("BP"); err != nil {
+		t.Errorf("Expected BP to be valid, got %v", err)
+	}
+	if err := tree.Validate("BX"); err == nil {
+		t.Error("Expected BX to be invalid")
+	}
+	children, ok := tree.Children("B")
+	if !ok || len(children) != 2 {
+		t.Errorf("Expected 2 children of B, got %v", children)
+	}
+	ancestry, ok := tree.Ancestry("BT")
+	if !ok || len(ancestry) != 2 || ancestry[0].Code != "B" {
+		t.Errorf("Expected B > BT, got %v", ancestry)
+	}
+	results := tree.Search("pressure")
+	if len(results) != 1 || results[0].Code != "BP" {
+		t.Errorf("Expected BP in search results, got %v", results)
+	}
+
+	if _, err := NewTree(IEC81346, []*TreeNode{{Code: "B"}, {Code: "B"}}); err == nil {
+		t.Error("Expected duplicate codes to be rejected")
+	}
+}
+
+func TestLoadRegistry(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		SystemsFile:    "POL: Polyol System\n",
+		ISALettersFile: "first_letters:\n  P: Pressure\noutput_functions:\n  T: Transmitter\n",
+		ISANamedFile:   "PT: Pressure Transmitter\n",
+		IEC81346File:   "- code: B\n  title: Sensing\n  children:\n    - code: BP\n      title: Sensing a pressure\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r, err := LoadRegistry(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := r.Names()
+	if len(names) != 3 {
+		t.Errorf("Expected 3 schemes, got %v", names)
+	}
+	if _, ok := r.Get(UDC); ok {
+		t.Error("Expected no UDC scheme without a UDC data file")
+	}
+	if err := r.Validate(IEC81346, "BP"); err != nil {
+		t.Errorf("Expected BP to be valid, got %v", err)
+	}
+	if err := r.Validate(ISA, "PT"); err != nil {
+		t.Errorf("Expected PT to be valid, got %v", err)
+	}
+}
+
+func TestLoadRegistryAddendums(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		SystemsFile:                          "POL: Polyol System\n",
+		"81346_systems_addendum_a.yaml":      "POL: Polyol Storage\nCAT: Catalyst System\n",
+		"81346_systems_addendum_b.yaml":      "CAT: Catalyst Dosing\n",
+		ISALettersFile:                       "first_letters:\n  P: Pressure\noutput_functions:\n  T: Transmitter\n",
+		"isa_letters_addendum_a.yaml":        "first_letters:\n  N: Noise\n",
+		IEC81346File:                         "- code: B\n  title: Sensing\n  children:\n    - code: BP\n      title: Sensing a pressure\n",
+		"iec81346_2_classes_addendum_a.yaml": "- code: B\n  children:\n    - code: BZ\n      title: Sensing a local variable\n- code: K\n  title: Processing signals\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r, err := LoadRegistry(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if title, _ := r.Lookup(Systems, "POL"); title != "Polyol Storage" {
+		t.Errorf("Expected addendum to override the POL title, got %q", title)
+	}
+	if title, _ := r.Lookup(Systems, "CAT"); title != "Catalyst Dosing" {
+		t.Errorf("Expected later addendum to win, got %q", title)
+	}
+	if err := r.Validate(ISA, "NT"); err != nil {
+		t.Errorf("Expected addendum letter N to be valid, got %v", err)
+	}
+	for _, code := range []string{"BP", "BZ", "K"} {
+		if err := r.Validate(IEC81346, code); err != nil {
+			t.Errorf("Expected %s to be valid, got %v", code, err)
+		}
+	}
+	if title, _ := r.Lookup(IEC81346, "B"); title != "Sensing" {
+		t.Errorf("Expected title of B to be kept, got %q", title)
+	}
+}
+
+func TestLoadRegistryData(t *testing.T) {
+	r, err := LoadRegistry(filepath.Join("..", "..", "data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{UDC, ISA, Systems, IEC81346, ECLASS} {
+		if _, ok := r.Get(name); !ok {
+			t.Errorf("Expected %s scheme to be loaded from the data directory", name)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package classification
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ISALetters holds the ISA-5.1 identification letter tables
+type ISALetters struct {
+	FirstLetters      map[string]string `yaml:"first_letters"`
+	VariableModifiers map[string]string `yaml:"variable_modifiers"`
+	ReadoutFunctions  map[string]string `yaml:"readout_functions"`
+	OutputFunctions   map[string]string `yaml:"output_functions"`
+	FunctionModifiers map[string]string `yaml:"function_modifiers"`
+}
+
+// LoadISALetters loads the ISA-5.1 letter tables from a YAML file
+func LoadISALetters(filename string) (*ISALetters, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filename, err)
+	}
+	var letters ISALetters
+	if err := yaml.Unmarshal(data, &letters); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+	if len(letters.FirstLetters) == 0 {
+		return nil, fmt.Errorf("%s: no first letters defined", filename)
+	}
+	return &letters, nil
+}
+
+// succeeding reports whether a letter may follow the first letter
+func (l *ISALetters) succeeding(letter string) bool {
+	for _, table := range []map[string]string{l.VariableModifiers, l.ReadoutFunctions, l.OutputFunctions, l.FunctionModifiers} {
+		if _, ok := table[letter]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ISAScheme is the ISA-5.1 function identifier scheme. Named identifiers
+// such as PIT come from isa_prefix.yaml; any other combination is checked
+// against the letter tables.
+type ISAScheme struct {
+	Letters *ISALetters
+	Named   map[string]string
+}
+
+// NewISAScheme builds the ISA scheme from letter tables and named identifiers
+func NewISAScheme(letters *ISALetters, named map[string]string) *ISAScheme {
+	if named == nil {
+		named = make(map[string]string)
+	}
+	return &ISAScheme{Letters: letters, Named: named}
+}
+
+// LoadISA loads the ISA letter tables and the named identifier list
+func LoadISA(lettersFile, namedFile string) (*ISAScheme, error) {
+	letters, err := LoadISALetters(lettersFile)
+	if err != nil {
+		return nil, err
+	}
+	named, err := loadMap(namedFile)
+	if err != nil {
+		return nil, err
+	}
+	return NewISAScheme(letters, named), nil
+}
+
+func (s *ISAScheme) Name() string {
+	return ISA
+}
+
+// Lookup returns the name of an identifier, or of a first letter on its own
+func (s *ISAScheme) Lookup(code string) (string, bool) {
+	if title, ok := s.Named[code]; ok {
+		return title, true
+	}
+	if len(code) == 1 {
+		title, ok := s.Letters.FirstLetters[code]
+		return title, ok
+	}
+	return "", false
+}
+
+func (s *ISAScheme) Validate(code string) error {
+	if _, ok := s.Named[code]; ok {
+		return nil
+	}
+	if code == "" {
+		return fmt.Errorf("empty ISA function code")
+	}
+	if _, ok := s.Letters.FirstLetters[code[:1]]; !ok {
+		return fmt.Errorf("unknown ISA first letter %s in %s", code[:1], code)
+	}
+	for _, r := range code[1:] {
+		if !s.Letters.succeeding(string(r)) {
+			return fmt.Errorf("unknown ISA succeeding letter %c in %s", r, code)
+		}
+	}
+	return nil
+}
+
+// Children lists the named identifiers one letter longer than a code
+func (s *ISAScheme) Children(code string) ([]Class, bool) {
+	if err := s.Validate(code); err != nil {
+		return nil, false
+	}
+	children := []Class{}
+	for named, title := range s.Named {
+		if len(named) == len(code)+1 && strings.HasPrefix(named, code) {
+			children = append(children, Class{Code: named, Title: title})
+		}
+	}
+	sortClasses(children)
+	return children, true
+}
+
+// Search matches first letters and named identifiers by title
+func (s *ISAScheme) Search(term string) []Class {
+	term = strings.ToLower(term)
+	var results []Class
+	for code, title := range s.Letters.FirstLetters {
+		if strings.Contains(strings.ToLower(title), term) {
+			results = append(results, Class{Code: code, Title: title})
+		}
+	}
+	for code, title := range s.Named {
+		if strings.Contains(strings.ToLower(title), term) {
+			results = append(results, Class{Code: code, Title: title})
+		}
+	}
+	sortClasses(results)
+	return results
+}
+
+// Ancestry returns the first letter and any named prefixes of a code
+func (s *ISAScheme) Ancestry(code string) ([]Class, bool) {
+	if err := s.Validate(code); err != nil {
+		return nil, false
+	}
+	var path []Class
+	for i := 1; i <= len(code); i++ {
+		if title, ok := s.Lookup(code[:i]); ok {
+			path = append(path, Class{Code: code[:i], Title: title})
+		} else if i == len(code) {
+			path = append(path, Class{Code: code})
+		}
+	}
+	return path, true
+}
+
+func sortClasses(classes []Class) {
+	sort.Slice(classes, func(i, j int) bool {
+		return classes[i].Code < classes[j].Code
+	})
+}
@@ -0,0 +1,218 @@
+package classification
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/thornzero/udc_codec/pkg/config"
+	"github.com/thornzero/udc_codec/pkg/dictionary"
+	"github.com/thornzero/udc_codec/pkg/udc"
+)
+
+// Reference data files read by LoadRegistry
+const (
+	UDCFile        = "udc_full.yaml"
+	ISALettersFile = "isa_letters.yaml"
+	ISANamedFile   = "isa_prefix.yaml"
+	SystemsFile    = "81346_systems.yaml"
+	IEC81346File   = "iec81346_2_classes.yaml"
+	ECLASSFile     = "eclass_classes.yaml"
+)
+
+// LoadRegistry loads every built-in scheme whose data files exist in dataDir,
+// using the dictionary of the configured language for UDC searches
+func LoadRegistry(dataDir string) (*Registry, error) {
+	return LoadRegistryLang(dataDir, config.Load().Language)
+}
+
+// LoadRegistryLang loads every built-in scheme whose data files exist in
+// dataDir, using the dictionary of a language for UDC searches. Local
+// addendum files such as 81346_systems_addendum_site.yaml are layered over
+// the system, ISA and class tables in name order; they may add codes and
+// override titles.
+func LoadRegistryLang(dataDir, language string) (*Registry, error) {
+	r := NewRegistry()
+	path := func(name string) string {
+		return filepath.Join(dataDir, name)
+	}
+
+	if exists(path(UDCFile)) {
+		codec, err := udc.LoadCodec(path(UDCFile))
+		if err != nil {
+			return nil, err
+		}
+		dict, err := dictionary.Load(dataDir, language)
+		if err != nil {
+			return nil, err
+		}
+		codec.SetExpander(dict)
+		r.Register(NewUDCScheme(codec))
+	}
+
+	if exists(path(ISALettersFile)) {
+		letters, err := loadLayeredISALetters(path(ISALettersFile))
+		if err != nil {
+			return nil, err
+		}
+		var named map[string]string
+		if exists(path(ISANamedFile)) {
+			if named, err = loadLayeredMap(path(ISANamedFile)); err != nil {
+				return nil, err
+			}
+		}
+		r.Register(NewISAScheme(letters, named))
+	}
+
+	if exists(path(SystemsFile)) {
+		systems, err := loadLayeredMap(path(SystemsFile))
+		if err != nil {
+			return nil, err
+		}
+		r.Register(NewTable(Systems, systems))
+	}
+
+	for name, file := range map[string]string{IEC81346: IEC81346File, ECLASS: ECLASSFile} {
+		if !exists(path(file)) {
+			continue
+		}
+		tree, err := loadLayeredTree(name, path(file))
+		if err != nil {
+			return nil, err
+		}
+		r.Register(tree)
+	}
+
+	return r, nil
+}
+
+// exists reports whether a file is present
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return !errors.Is(err, fs.ErrNotExist)
+}
+
+// Addendums returns the local addendum files layered over a data file, e.g.
+// 81346_systems_addendum_site.yaml over 81346_systems.yaml, in name order
+func Addendums(filename string) ([]string, error) {
+	base := strings.TrimSuffix(filename, filepath.Ext(filename))
+	files, err := filepath.Glob(base + "_addendum_*.yaml")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list addendums of %s: %w", filename, err)
+	}
+	return files, nil
+}
+
+// loadLayeredMap reads a YAML map of strings with its addendums merged over it
+func loadLayeredMap(filename string) (map[string]string, error) {
+	entries, err := loadMap(filename)
+	if err != nil {
+		return nil, err
+	}
+	if entries == nil {
+		entries = make(map[string]string)
+	}
+	layers, err := Addendums(filename)
+	if err != nil {
+		return nil, err
+	}
+	for _, layer := range layers {
+		overrides, err := loadMap(layer)
+		if err != nil {
+			return nil, err
+		}
+		for code, title := range overrides {
+			entries[code] = title
+		}
+	}
+	return entries, nil
+}
+
+// loadLayeredISALetters reads the ISA letter tables with their addendums
+// merged over them table by table
+func loadLayeredISALetters(filename string) (*ISALetters, error) {
+	letters, err := LoadISALetters(filename)
+	if err != nil {
+		return nil, err
+	}
+	layers, err := Addendums(filename)
+	if err != nil {
+		return nil, err
+	}
+	for _, layer := range layers {
+		data, err := os.ReadFile(layer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", layer, err)
+		}
+		var overrides ISALetters
+		if err := yaml.Unmarshal(data, &overrides); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", layer, err)
+		}
+		letters.FirstLetters = mergeMap(letters.FirstLetters, overrides.FirstLetters)
+		letters.VariableModifiers = mergeMap(letters.VariableModifiers, overrides.VariableModifiers)
+		letters.ReadoutFunctions = mergeMap(letters.ReadoutFunctions, overrides.ReadoutFunctions)
+		letters.OutputFunctions = mergeMap(letters.OutputFunctions, overrides.OutputFunctions)
+		letters.FunctionModifiers = mergeMap(letters.FunctionModifiers, overrides.FunctionModifiers)
+	}
+	return letters, nil
+}
+
+// mergeMap copies overrides into a map, creating it if needed
+func mergeMap(entries, overrides map[string]string) map[string]string {
+	if entries == nil && len(overrides) > 0 {
+		entries = make(map[string]string, len(overrides))
+	}
+	for k, v := range overrides {
+		entries[k] = v
+	}
+	return entries
+}
+
+// loadLayeredTree reads a class tree with its addendums merged over it. An
+// addendum node with an existing code overrides its title, if given, and
+// merges its children; other nodes are added at their level.
+func loadLayeredTree(name, filename string) (*Tree, error) {
+	roots, err := readTreeNodes(filename)
+	if err != nil {
+		return nil, err
+	}
+	layers, err := Addendums(filename)
+	if err != nil {
+		return nil, err
+	}
+	for _, layer := range layers {
+		nodes, err := readTreeNodes(layer)
+		if err != nil {
+			return nil, err
+		}
+		roots = mergeTreeNodes(roots, nodes)
+	}
+	return NewTree(name, roots)
+}
+
+// mergeTreeNodes merges a layer of nodes into a level of a tree
+func mergeTreeNodes(nodes, layer []*TreeNode) []*TreeNode {
+	for _, n := range layer {
+		var existing *TreeNode
+		for _, candidate := range nodes {
+			if candidate.Code == n.Code {
+				existing = candidate
+				break
+			}
+		}
+		if existing == nil {
+			nodes = append(nodes, n)
+			continue
+		}
+		if n.Title != "" {
+			existing.Title = n.Title
+		}
+		existing.Children = mergeTreeNodes(existing.Children, n.Children)
+	}
+	return nodes
+}
@@ -0,0 +1,108 @@
+package classification
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Names of the built-in schemes
+const (
+	UDC      = "udc"
+	ISA      = "isa"
+	IEC81346 = "iec81346"
+	Systems  = "systems"
+	ECLASS   = "eclass"
+)
+
+// Class is a single entry in a classification scheme
+type Class struct {
+	Code  string `json:"code" yaml:"code"`
+	Title string `json:"title" yaml:"title"`
+}
+
+// Scheme is a classification system that codes can be looked up in and validated against
+type Scheme interface {
+	// Name returns the name the scheme is registered under
+	Name() string
+	// Lookup returns the title of a code
+	Lookup(code string) (string, bool)
+	// Validate returns an error if a code is not valid in the scheme
+	Validate(code string) error
+	// Children returns the classes directly below a code
+	Children(code string) ([]Class, bool)
+	// Search returns the classes whose title contains a term
+	Search(term string) []Class
+	// Ancestry returns the classes from the top of the scheme down to a code
+	Ancestry(code string) ([]Class, bool)
+}
+
+// Registry holds the schemes available to resolvers, validators and the
+// API. It is safe for concurrent use.
+type Registry struct {
+	mu      sync.RWMutex
+	schemes map[string]Scheme
+}
+
+// NewRegistry creates a registry holding the given schemes
+func NewRegistry(schemes ...Scheme) *Registry {
+	r := &Registry{schemes: make(map[string]Scheme)}
+	for _, s := range schemes {
+		r.Register(s)
+	}
+	return r
+}
+
+// Register adds a scheme, replacing any scheme registered under the same name
+func (r *Registry) Register(s Scheme) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.schemes[s.Name()] = s
+}
+
+// Get returns the scheme registered under a name
+func (r *Registry) Get(name string) (Scheme, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	s, ok := r.schemes[name]
+	return s, ok
+}
+
+// Names returns the registered scheme names in sorted order
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.schemes))
+	for name := range r.schemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Scheme returns the scheme registered under a name, or an error if there is none
+func (r *Registry) Scheme(name string) (Scheme, error) {
+	s, ok := r.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown classification scheme: %s", name)
+	}
+	return s, nil
+}
+
+// Lookup returns the title of a code in the named scheme
+func (r *Registry) Lookup(name, code string) (string, bool) {
+	s, ok := r.Get(name)
+	if !ok {
+		return "", false
+	}
+	return s.Lookup(code)
+}
+
+// Validate validates a code against the named scheme
+func (r *Registry) Validate(name, code string) error {
+	s, err := r.Scheme(name)
+	if err != nil {
+		return err
+	}
+	return s.Validate(code)
+}
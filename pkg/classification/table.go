@@ -0,0 +1,99 @@
+package classification
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Table is a flat scheme read from a YAML map of code to title,
+// such as the project system list in 81346_systems.yaml
+type Table struct {
+	name    string
+	entries map[string]string
+}
+
+// NewTable builds a table scheme from a map of code to title
+func NewTable(name string, entries map[string]string) *Table {
+	if entries == nil {
+		entries = make(map[string]string)
+	}
+	return &Table{name: name, entries: entries}
+}
+
+// LoadTable loads a table scheme from a YAML file
+func LoadTable(name, filename string) (*Table, error) {
+	entries, err := loadMap(filename)
+	if err != nil {
+		return nil, err
+	}
+	return NewTable(name, entries), nil
+}
+
+// loadMap reads a YAML map of strings
+func loadMap(filename string) (map[string]string, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filename, err)
+	}
+	var entries map[string]string
+	if err := yaml.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+	return entries, nil
+}
+
+func (t *Table) Name() string {
+	return t.name
+}
+
+func (t *Table) Lookup(code string) (string, bool) {
+	title, ok := t.entries[code]
+	return title, ok
+}
+
+func (t *Table) Validate(code string) error {
+	if _, ok := t.entries[code]; !ok {
+		return fmt.Errorf("unknown %s code: %s", t.name, code)
+	}
+	return nil
+}
+
+// Children always returns an empty list for known codes as tables are flat
+func (t *Table) Children(code string) ([]Class, bool) {
+	if _, ok := t.entries[code]; !ok {
+		return nil, false
+	}
+	return []Class{}, true
+}
+
+func (t *Table) Search(term string) []Class {
+	term = strings.ToLower(term)
+	var results []Class
+	for code, title := range t.entries {
+		if strings.Contains(strings.ToLower(title), term) {
+			results = append(results, Class{Code: code, Title: title})
+		}
+	}
+	sortClasses(results)
+	return results
+}
+
+func (t *Table) Ancestry(code string) ([]Class, bool) {
+	title, ok := t.entries[code]
+	if !ok {
+		return nil, false
+	}
+	return []Class{{Code: code, Title: title}}, true
+}
+
+// Entries returns a copy of the table's code to title map
+func (t *Table) Entries() map[string]string {
+	entries := make(map[string]string, len(t.entries))
+	for code, title := range t.entries {
+		entries[code] = title
+	}
+	return entries
+}
@@ -0,0 +1,142 @@
+package classification
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TreeNode is a class in a hierarchical scheme file
+type TreeNode struct {
+	Code     string      `yaml:"code"`
+	Title    string      `yaml:"title"`
+	Children []*TreeNode `yaml:"children,omitempty"`
+}
+
+// Tree is a hierarchical scheme read from a nested YAML class list,
+// used for the IEC 81346-2 class tables and ECLASS
+type Tree struct {
+	name    string
+	roots   []*TreeNode
+	flat    map[string]*TreeNode
+	parents map[string]*TreeNode
+}
+
+// NewTree builds a tree scheme from nested class nodes
+func NewTree(name string, roots []*TreeNode) (*Tree, error) {
+	t := &Tree{
+		name:    name,
+		roots:   roots,
+		flat:    make(map[string]*TreeNode),
+		parents: make(map[string]*TreeNode),
+	}
+	if err := t.index(roots, nil); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// LoadTree loads a tree scheme from a YAML file
+func LoadTree(name, filename string) (*Tree, error) {
+	roots, err := readTreeNodes(filename)
+	if err != nil {
+		return nil, err
+	}
+	return NewTree(name, roots)
+}
+
+// readTreeNodes reads a nested YAML class list
+func readTreeNodes(filename string) ([]*TreeNode, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filename, err)
+	}
+	var roots []*TreeNode
+	if err := yaml.Unmarshal(data, &roots); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+	return roots, nil
+}
+
+func (t *Tree) index(nodes []*TreeNode, parent *TreeNode) error {
+	for _, n := range nodes {
+		if _, exists := t.flat[n.Code]; exists {
+			return fmt.Errorf("%s: duplicate code %s", t.name, n.Code)
+		}
+		t.flat[n.Code] = n
+		if parent != nil {
+			t.parents[n.Code] = parent
+		}
+		if err := t.index(n.Children, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *Tree) Name() string {
+	return t.name
+}
+
+func (t *Tree) Lookup(code string) (string, bool) {
+	n, ok := t.flat[code]
+	if !ok {
+		return "", false
+	}
+	return n.Title, true
+}
+
+func (t *Tree) Validate(code string) error {
+	if _, ok := t.flat[code]; !ok {
+		return fmt.Errorf("unknown %s code: %s", t.name, code)
+	}
+	return nil
+}
+
+func (t *Tree) Children(code string) ([]Class, bool) {
+	n, ok := t.flat[code]
+	if !ok {
+		return nil, false
+	}
+	return treeClasses(n.Children), true
+}
+
+func (t *Tree) Search(term string) []Class {
+	term = strings.ToLower(term)
+	var results []Class
+	for _, n := range t.flat {
+		if strings.Contains(strings.ToLower(n.Title), term) {
+			results = append(results, Class{Code: n.Code, Title: n.Title})
+		}
+	}
+	sortClasses(results)
+	return results
+}
+
+func (t *Tree) Ancestry(code string) ([]Class, bool) {
+	n, ok := t.flat[code]
+	if !ok {
+		return nil, false
+	}
+	var path []Class
+	for n != nil {
+		path = append([]Class{{Code: n.Code, Title: n.Title}}, path...)
+		n = t.parents[n.Code]
+	}
+	return path, true
+}
+
+// Roots returns the top-level classes of the scheme
+func (t *Tree) Roots() []Class {
+	return treeClasses(t.roots)
+}
+
+func treeClasses(nodes []*TreeNode) []Class {
+	classes := make([]Class, 0, len(nodes))
+	for _, n := range nodes {
+		classes = append(classes, Class{Code: n.Code, Title: n.Title})
+	}
+	return classes
+}
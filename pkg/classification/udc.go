@@ -0,0 +1,67 @@
+package classification
+
+import (
+	"github.com/thornzero/udc_codec/pkg/udc"
+)
+
+// UDCScheme exposes a UDC codec as a classification scheme
+type UDCScheme struct {
+	Codec *udc.Codec
+}
+
+// NewUDCScheme wraps a loaded UDC codec
+func NewUDCScheme(codec *udc.Codec) *UDCScheme {
+	return &UDCScheme{Codec: codec}
+}
+
+func (s *UDCScheme) Name() string {
+	return UDC
+}
+
+func (s *UDCScheme) Lookup(code string) (string, bool) {
+	return s.Codec.Lookup(code)
+}
+
+// Validate accepts single codes and composite expressions such as 621.3:681.5(075)
+func (s *UDCScheme) Validate(code string) error {
+	return s.Codec.Validate(code)
+}
+
+func (s *UDCScheme) Children(code string) ([]Class, bool) {
+	children, ok := s.Codec.Children(code)
+	if !ok {
+		return nil, false
+	}
+	return udcClasses(children), true
+}
+
+func (s *UDCScheme) Search(term string) []Class {
+	return udcClasses(s.Codec.Search(term))
+}
+
+func (s *UDCScheme) Ancestry(code string) ([]Class, bool) {
+	ancestry, ok := s.Codec.Ancestry(code)
+	if !ok {
+		return nil, false
+	}
+	return udcClasses(ancestry), true
+}
+
+// udcClasses converts UDC nodes to classes
+func udcClasses(nodes []*udc.Node) []Class {
+	classes := make([]Class, 0, len(nodes))
+	for _, n := range nodes {
+		classes = append(classes, Class{Code: n.Code, Title: n.Title})
+	}
+	return classes
+}
+
+// UDCCodec returns the codec behind the registry's udc scheme, if loaded
+func (r *Registry) UDCCodec() (*udc.Codec, bool) {
+	scheme, _ := r.Get(UDC)
+	s, ok := scheme.(*UDCScheme)
+	if !ok {
+		return nil, false
+	}
+	return s.Codec, true
+}
@@ -12,6 +12,8 @@ type Config struct {
 	DBPath  string
 	DataDir string
 	Port    string
+	// Language selects the dictionary and localised text to use
+	Language string
 }
 
 var (
@@ -26,9 +28,10 @@ func Load() *Config {
 		_ = godotenv.Load()
 
 		cfg = &Config{
-			DBPath:  getEnv("DB_PATH", "tags.db"),
-			DataDir: getEnv("DATA_DIR", "data"),
-			Port:    getEnv("SERVER_PORT", "8080"),
+			DBPath:   getEnv("DB_PATH", "tags.db"),
+			DataDir:  getEnv("DATA_DIR", "data"),
+			Port:     getEnv("SERVER_PORT", "8080"),
+			Language: getEnv("DEFAULT_LANGUAGE", "en"),
 		}
 	})
 	return cfg
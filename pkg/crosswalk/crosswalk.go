@@ -0,0 +1,308 @@
+package crosswalk
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/thornzero/udc_codec/pkg/classification"
+)
+
+// Schemes that crosswalks commonly map UDC codes to, besides those in the
+// classification registry
+const (
+	UNSPSC   = "unspsc"
+	IEC61360 = "iec61360"
+)
+
+// Equivalence describes how a mapped code relates to the UDC code
+type Equivalence string
+
+const (
+	Exact    Equivalence = "exact"
+	Broader  Equivalence = "broader"
+	Narrower Equivalence = "narrower"
+	Related  Equivalence = "related"
+)
+
+// Valid reports whether e is one of the known equivalence types
+func (e Equivalence) Valid() bool {
+	switch e {
+	case Exact, Broader, Narrower, Related:
+		return true
+	}
+	return false
+}
+
+// Inverse returns the equivalence seen from the other side of a mapping
+func (e Equivalence) Inverse() Equivalence {
+	switch e {
+	case Broader:
+		return Narrower
+	case Narrower:
+		return Broader
+	}
+	return e
+}
+
+// compose returns the equivalence of a chain of two mappings
+func compose(a, b Equivalence) Equivalence {
+	switch {
+	case a == Exact:
+		return b
+	case b == Exact:
+		return a
+	case a == b && a != Related:
+		return a
+	}
+	return Related
+}
+
+// Mapping links a UDC code to a code in another scheme. Equivalence states
+// how the other code relates to the UDC code, so "broader" means the
+// target class is wider than the UDC class.
+type Mapping struct {
+	UDC         string      `yaml:"udc" json:"udc"`
+	Scheme      string      `yaml:"scheme" json:"scheme"`
+	Code        string      `yaml:"code" json:"code"`
+	Equivalence Equivalence `yaml:"equivalence" json:"equivalence"`
+	Note        string      `yaml:"note,omitempty" json:"note,omitempty"`
+}
+
+// key identifies a mapping regardless of its equivalence
+func (m Mapping) key() string {
+	return m.UDC + "\x00" + m.Scheme + "\x00" + m.Code
+}
+
+// validate checks the required fields of a mapping
+func (m Mapping) validate() error {
+	if m.UDC == "" || m.Scheme == "" || m.Code == "" {
+		return fmt.Errorf("mapping requires udc, scheme and code: %+v", m)
+	}
+	if m.Scheme == classification.UDC {
+		return fmt.Errorf("mapping for %s cannot target the udc scheme", m.UDC)
+	}
+	if !m.Equivalence.Valid() {
+		return fmt.Errorf("invalid equivalence %q for %s -> %s:%s", m.Equivalence, m.UDC, m.Scheme, m.Code)
+	}
+	return nil
+}
+
+// Translation is a code reached by translating through a crosswalk.
+// Equivalence states how the translated code relates to the source code.
+type Translation struct {
+	Scheme      string      `yaml:"scheme" json:"scheme"`
+	Code        string      `yaml:"code" json:"code"`
+	Equivalence Equivalence `yaml:"equivalence" json:"equivalence"`
+}
+
+// Crosswalk indexes many-to-many mappings between UDC and other schemes
+type Crosswalk struct {
+	mappings []Mapping
+	byUDC    map[string][]Mapping
+	byTarget map[string][]Mapping
+	sources  map[string]string
+}
+
+// New creates a crosswalk from a list of mappings
+func New(mappings ...Mapping) (*Crosswalk, error) {
+	cw := &Crosswalk{
+		byUDC:    make(map[string][]Mapping),
+		byTarget: make(map[string][]Mapping),
+		sources:  make(map[string]string),
+	}
+	if err := cw.add("", mappings); err != nil {
+		return nil, err
+	}
+	return cw, nil
+}
+
+// Load reads every crosswalk_*.yaml file in the data directory, including
+// local crosswalk_addendum_*.yaml files
+func Load(dataDir string) (*Crosswalk, error) {
+	cw, _ := New()
+	files, err := ListFiles(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		mappings, err := readFile(filepath.Join(dataDir, file))
+		if err != nil {
+			return nil, err
+		}
+		if err := cw.add(file, mappings); err != nil {
+			return nil, err
+		}
+	}
+	return cw, nil
+}
+
+// ListFiles returns the crosswalk files in the data directory
+func ListFiles(dataDir string) ([]string, error) {
+	entries, err := os.ReadDir(dataDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read data directory: %w", err)
+	}
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasPrefix(entry.Name(), "crosswalk_") && strings.HasSuffix(entry.Name(), ".yaml") {
+			files = append(files, entry.Name())
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// readFile reads a list of mappings from a YAML file
+func readFile(filename string) ([]Mapping, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filename, err)
+	}
+	var mappings []Mapping
+	if err := yaml.Unmarshal(data, &mappings); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+	return mappings, nil
+}
+
+// add validates and indexes mappings loaded from a source file
+func (cw *Crosswalk) add(source string, mappings []Mapping) error {
+	for _, m := range mappings {
+		if err := m.validate(); err != nil {
+			if source != "" {
+				return fmt.Errorf("%s: %w", source, err)
+			}
+			return err
+		}
+		if existing, ok := cw.sources[m.key()]; ok {
+			return fmt.Errorf("duplicate mapping %s -> %s:%s in %s (already defined in %s)", m.UDC, m.Scheme, m.Code, source, existing)
+		}
+		cw.sources[m.key()] = source
+		cw.mappings = append(cw.mappings, m)
+		cw.byUDC[m.UDC] = append(cw.byUDC[m.UDC], m)
+		target := targetKey(m.Scheme, m.Code)
+		cw.byTarget[target] = append(cw.byTarget[target], m)
+	}
+	return nil
+}
+
+func targetKey(scheme, code string) string {
+	return scheme + ":" + code
+}
+
+// Mappings returns every mapping in load order
+func (cw *Crosswalk) Mappings() []Mapping {
+	return append([]Mapping(nil), cw.mappings...)
+}
+
+// Source returns the file a mapping was loaded from
+func (cw *Crosswalk) Source(m Mapping) string {
+	return cw.sources[m.key()]
+}
+
+// Schemes returns the schemes that UDC codes are mapped to
+func (cw *Crosswalk) Schemes() []string {
+	seen := make(map[string]bool)
+	var schemes []string
+	for _, m := range cw.mappings {
+		if !seen[m.Scheme] {
+			seen[m.Scheme] = true
+			schemes = append(schemes, m.Scheme)
+		}
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// FromUDC returns the codes a UDC code maps to, limited to one scheme
+// unless scheme is empty
+func (cw *Crosswalk) FromUDC(udcCode, scheme string) []Translation {
+	var results []Translation
+	for _, m := range cw.byUDC[udcCode] {
+		if scheme == "" || m.Scheme == scheme {
+			results = append(results, Translation{Scheme: m.Scheme, Code: m.Code, Equivalence: m.Equivalence})
+		}
+	}
+	sortTranslations(results)
+	return results
+}
+
+// ToUDC returns the UDC codes a code in another scheme maps to
+func (cw *Crosswalk) ToUDC(scheme, code string) []Translation {
+	var results []Translation
+	for _, m := range cw.byTarget[targetKey(scheme, code)] {
+		results = append(results, Translation{Scheme: classification.UDC, Code: m.UDC, Equivalence: m.Equivalence.Inverse()})
+	}
+	sortTranslations(results)
+	return results
+}
+
+// Translate maps a code from one scheme to another. Translations between
+// two non-UDC schemes go through UDC and combine both equivalences.
+func (cw *Crosswalk) Translate(from, code, to string) []Translation {
+	switch {
+	case from == classification.UDC:
+		return cw.FromUDC(code, to)
+	case to == classification.UDC:
+		return cw.ToUDC(from, code)
+	}
+
+	best := make(map[string]Translation)
+	for _, viaUDC := range cw.ToUDC(from, code) {
+		for _, t := range cw.FromUDC(viaUDC.Code, to) {
+			if t.Scheme == from && t.Code == code {
+				continue
+			}
+			t.Equivalence = compose(viaUDC.Equivalence, t.Equivalence)
+			key := targetKey(t.Scheme, t.Code)
+			if existing, ok := best[key]; !ok || rank(t.Equivalence) < rank(existing.Equivalence) {
+				best[key] = t
+			}
+		}
+	}
+	results := make([]Translation, 0, len(best))
+	for _, t := range best {
+		results = append(results, t)
+	}
+	sortTranslations(results)
+	return results
+}
+
+// rank orders equivalences from closest to loosest
+func rank(e Equivalence) int {
+	switch e {
+	case Exact:
+		return 0
+	case Broader, Narrower:
+		return 1
+	}
+	return 2
+}
+
+func sortTranslations(results []Translation) {
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Scheme != results[j].Scheme {
+			return results[i].Scheme < results[j].Scheme
+		}
+		return results[i].Code < results[j].Code
+	})
+}
+
+// String formats a translation as scheme:code (equivalence)
+func (t Translation) String() string {
+	return fmt.Sprintf("%s:%s (%s)", t.Scheme, t.Code, t.Equivalence)
+}
+
+// Join formats a list of translations separated by semicolons
+func Join(translations []Translation) string {
+	parts := make([]string, 0, len(translations))
+	for _, t := range translations {
+		parts = append(parts, t.String())
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,111 @@
+package crosswalk
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testCrosswalk(t *testing.T) *Crosswalk {
+	t.Helper()
+	cw, err := New(
+		Mapping{UDC: "621.6", Scheme: "eclass", Code: "36-41", Equivalence: Narrower},
+		Mapping{UDC: "621.6", Scheme: UNSPSC, Code: "40151500", Equivalence: Narrower},
+		Mapping{UDC: "681.2", Scheme: "eclass", Code: "27-20", Equivalence: Exact},
+		Mapping{UDC: "681.2", Scheme: UNSPSC, Code: "41110000", Equivalence: Broader},
+		Mapping{UDC: "681.5", Scheme: UNSPSC, Code: "41110000", Equivalence: Related},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cw
+}
+
+func TestTranslate(t *testing.T) {
+	cw := testCrosswalk(t)
+
+	from := cw.Translate("udc", "621.6", "")
+	if len(from) != 2 || from[0].Scheme != "eclass" || from[1].Scheme != UNSPSC {
+		t.Errorf("Expected eclass and unspsc mappings for 621.6, got %v", from)
+	}
+
+	to := cw.Translate(UNSPSC, "41110000", "udc")
+	if len(to) != 2 {
+		t.Fatalf("Expected 2 UDC codes for 41110000, got %v", to)
+	}
+	if to[0].Code != "681.2" || to[0].Equivalence != Narrower {
+		t.Errorf("Expected 681.2 to be narrower than 41110000, got %v", to[0])
+	}
+	if to[1].Code != "681.5" || to[1].Equivalence != Related {
+		t.Errorf("Expected 681.5 to be related to 41110000, got %v", to[1])
+	}
+
+	via := cw.Translate("eclass", "27-20", UNSPSC)
+	if len(via) != 1 || via[0].Code != "41110000" || via[0].Equivalence != Broader {
+		t.Errorf("Expected 27-20 to translate to broader 41110000, got %v", via)
+	}
+
+	related := cw.Translate("eclass", "36-41", UNSPSC)
+	if len(related) != 1 || related[0].Equivalence != Related {
+		t.Errorf("Expected sibling classes to be related, got %v", related)
+	}
+}
+
+func TestNewRejectsInvalidMappings(t *testing.T) {
+	if _, err := New(Mapping{UDC: "621.6", Scheme: "eclass", Code: "36-41", Equivalence: "similar"}); err == nil {
+		t.Error("Expected error for unknown equivalence")
+	}
+	if _, err := New(Mapping{UDC: "621.6", Scheme: "udc", Code: "621", Equivalence: Broader}); err == nil {
+		t.Error("Expected error for mapping to the udc scheme")
+	}
+	m := Mapping{UDC: "621.6", Scheme: "eclass", Code: "36-41", Equivalence: Exact}
+	if _, err := New(m, m); err == nil {
+		t.Error("Expected error for duplicate mapping")
+	}
+}
+
+func TestManager(t *testing.T) {
+	dir := t.TempDir()
+	base := "- udc: \"681.2\"\n  scheme: eclass\n  code: \"27-20\"\n  equivalence: exact\n"
+	if err := os.WriteFile(filepath.Join(dir, "crosswalk_eclass.yaml"), []byte(base), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewManager(dir)
+	mapping := Mapping{UDC: "681.2", Scheme: IEC61360, Code: "AAA001", Equivalence: Related}
+	if err := m.Add("site", []Mapping{mapping}); err != nil {
+		t.Fatalf("Failed to add mapping: %v", err)
+	}
+	if err := m.Add("", []Mapping{{UDC: "681.2", Scheme: "eclass", Code: "27-20", Equivalence: Broader}}); err == nil {
+		t.Error("Expected error when adding a mapping defined in another file")
+	}
+
+	files, err := m.ListAddendums()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0] != "crosswalk_addendum_site.yaml" {
+		t.Errorf("Expected crosswalk_addendum_site.yaml, got %v", files)
+	}
+
+	cw, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cw.FromUDC("681.2", ""); len(got) != 2 {
+		t.Errorf("Expected 2 mappings for 681.2, got %v", got)
+	}
+	if source := cw.Source(mapping); source != "crosswalk_addendum_site.yaml" {
+		t.Errorf("Expected mapping source crosswalk_addendum_site.yaml, got %s", source)
+	}
+
+	if err := m.Remove("site", mapping); err != nil {
+		t.Fatalf("Failed to remove mapping: %v", err)
+	}
+	if err := m.Remove("site", mapping); err == nil {
+		t.Error("Expected error when removing a missing mapping")
+	}
+	if err := m.DeleteAddendum("site"); err != nil {
+		t.Fatalf("Failed to delete crosswalk file: %v", err)
+	}
+}
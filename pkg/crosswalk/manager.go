@@ -0,0 +1,125 @@
+package crosswalk
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Manager provides functions for managing local crosswalk addendum files
+type Manager struct {
+	dataDir string
+}
+
+// NewManager creates a new crosswalk manager for the given data directory
+func NewManager(dataDir string) *Manager {
+	return &Manager{dataDir: dataDir}
+}
+
+// Filename applies the crosswalk_addendum_*.yaml naming convention
+func Filename(filename string) string {
+	if filename == "" {
+		filename = "default"
+	}
+	if !strings.HasPrefix(filename, "crosswalk_addendum_") {
+		filename = "crosswalk_addendum_" + filename
+	}
+	if !strings.HasSuffix(filename, ".yaml") {
+		filename = filename + ".yaml"
+	}
+	return filename
+}
+
+// Add adds mappings to a local crosswalk file, creating it if it doesn't exist
+// If filename is empty, uses the default crosswalk addendum file
+func (m *Manager) Add(filename string, mappings []Mapping) error {
+	filename = Filename(filename)
+	path := filepath.Join(m.dataDir, filename)
+
+	existing, err := m.read(path)
+	if err != nil {
+		return err
+	}
+
+	// Reject mappings already defined in any crosswalk file
+	cw, err := Load(m.dataDir)
+	if err != nil {
+		return fmt.Errorf("failed to load crosswalks: %w", err)
+	}
+	if err := cw.add(filename, mappings); err != nil {
+		return err
+	}
+
+	return m.write(path, append(existing, mappings...))
+}
+
+// Remove removes a mapping from a local crosswalk file
+func (m *Manager) Remove(filename string, mapping Mapping) error {
+	filename = Filename(filename)
+	path := filepath.Join(m.dataDir, filename)
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("crosswalk file not found: %s", filename)
+	}
+
+	existing, err := m.read(path)
+	if err != nil {
+		return err
+	}
+	kept := existing[:0]
+	for _, e := range existing {
+		if e.key() != mapping.key() {
+			kept = append(kept, e)
+		}
+	}
+	if len(kept) == len(existing) {
+		return fmt.Errorf("mapping %s -> %s:%s not found in %s", mapping.UDC, mapping.Scheme, mapping.Code, filename)
+	}
+	return m.write(path, kept)
+}
+
+// ListAddendums returns a list of all local crosswalk files
+func (m *Manager) ListAddendums() ([]string, error) {
+	files, err := ListFiles(m.dataDir)
+	if err != nil {
+		return nil, err
+	}
+	var addendums []string
+	for _, file := range files {
+		if strings.HasPrefix(file, "crosswalk_addendum_") {
+			addendums = append(addendums, file)
+		}
+	}
+	return addendums, nil
+}
+
+// DeleteAddendum deletes a local crosswalk file
+func (m *Manager) DeleteAddendum(filename string) error {
+	return os.Remove(filepath.Join(m.dataDir, Filename(filename)))
+}
+
+// read loads the mappings in a crosswalk file, if it exists
+func (m *Manager) read(path string) ([]Mapping, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, nil
+	}
+	mappings, err := readFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read existing crosswalk: %w", err)
+	}
+	return mappings, nil
+}
+
+// write saves mappings to a crosswalk file
+func (m *Manager) write(path string, mappings []Mapping) error {
+	data, err := yaml.Marshal(mappings)
+	if err != nil {
+		return fmt.Errorf("failed to marshal crosswalk data: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write crosswalk file: %w", err)
+	}
+	return nil
+}
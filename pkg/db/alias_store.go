@@ -0,0 +1,201 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// TagAlias is another name a tag is known by, such as its legacy tag
+type TagAlias struct {
+	Alias  string `db:"alias" json:"alias" yaml:"alias"`
+	TagID  int64  `db:"tag_id" json:"tag_id" yaml:"tag_id"`
+	Source string `db:"source" json:"source,omitempty" yaml:"source,omitempty"`
+}
+
+// TagRename renames a tag, replacing its tag fields with those of New and
+// keeping the old name as an alias
+type TagRename struct {
+	Old    string
+	New    TagRecord
+	Source string
+}
+
+const tagColumns = `id, full_tag, system_code, equipment_id, instrument_id, function_code, udc_code, description`
+
+// ListTags returns every tag ordered by full tag
+func (s *Store) ListTags() ([]TagRecord, error) {
+	rows, err := s.DB.Query(`SELECT ` + tagColumns + ` FROM tags ORDER BY full_tag`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []TagRecord
+	for rows.Next() {
+		var t TagRecord
+		if err := rows.Scan(&t.ID, &t.FullTag, &t.SystemCode, &t.EquipmentID, &t.InstrumentID, &t.FunctionCode, &t.UDCCode, &t.Description); err != nil {
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+	return tags, rows.Err()
+}
+
+// ResolveTag looks up a tag by its full tag or, failing that, by an alias
+func (s *Store) ResolveTag(name string) (*TagRecord, error) {
+	t, err := s.LookupTag(name)
+	if err != sql.ErrNoRows {
+		return t, err
+	}
+	row := s.DB.QueryRow(`SELECT t.id, t.full_tag, t.system_code, t.equipment_id, t.instrument_id, t.function_code, t.udc_code, t.description
+		FROM tags t JOIN tag_aliases a ON a.tag_id = t.id WHERE a.alias = ?`, name)
+	var alias TagRecord
+	if err := row.Scan(&alias.ID, &alias.FullTag, &alias.SystemCode, &alias.EquipmentID, &alias.InstrumentID, &alias.FunctionCode, &alias.UDCCode, &alias.Description); err != nil {
+		return nil, err
+	}
+	return &alias, nil
+}
+
+// AddAlias records another name for a tag
+func (s *Store) AddAlias(alias TagAlias) error {
+	_, err := s.DB.Exec(`INSERT INTO tag_aliases (alias, tag_id, source) VALUES (?, ?, ?)`, alias.Alias, alias.TagID, alias.Source)
+	return err
+}
+
+// ListAliases returns the aliases of a tag
+func (s *Store) ListAliases(tagID int64) ([]TagAlias, error) {
+	rows, err := s.DB.Query(`SELECT alias, tag_id, source FROM tag_aliases WHERE tag_id = ? ORDER BY alias`, tagID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var aliases []TagAlias
+	for rows.Next() {
+		var a TagAlias
+		if err := rows.Scan(&a.Alias, &a.TagID, &a.Source); err != nil {
+			return nil, err
+		}
+		aliases = append(aliases, a)
+	}
+	return aliases, rows.Err()
+}
+
+// RenameTags applies renames in a single transaction. The UDC code and
+// description of each tag are kept; either every rename is applied or none.
+// Tags are first moved to temporary names so renames may swap or chain
+// names (A to B while B becomes C).
+func (s *Store) RenameTags(renames []TagRename) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	ids := make([]int64, len(renames))
+	for i, r := range renames {
+		if err := tx.QueryRow(`SELECT id FROM tags WHERE full_tag = ?`, r.Old).Scan(&ids[i]); err != nil {
+			return fmt.Errorf("failed to find tag %s: %w", r.Old, err)
+		}
+		if _, err := tx.Exec(`UPDATE tags SET full_tag = ? WHERE id = ?`, fmt.Sprintf("~rename:%d", ids[i]), ids[i]); err != nil {
+			return fmt.Errorf("failed to rename tag %s: %w", r.Old, err)
+		}
+	}
+	for i, r := range renames {
+		n := r.New
+		if _, err := tx.Exec(`UPDATE tags SET full_tag = ?, system_code = ?, equipment_id = ?, instrument_id = ?, function_code = ? WHERE id = ?`,
+			n.FullTag, n.SystemCode, n.EquipmentID, n.InstrumentID, n.FunctionCode, ids[i]); err != nil {
+			return fmt.Errorf("failed to rename tag %s to %s: %w", r.Old, n.FullTag, err)
+		}
+		if _, err := tx.Exec(`INSERT INTO tag_aliases (alias, tag_id, source) VALUES (?, ?, ?)
+			ON CONFLICT(alias) DO UPDATE SET tag_id = excluded.tag_id, source = excluded.source`, r.Old, ids[i], r.Source); err != nil {
+			return fmt.Errorf("failed to keep alias %s: %w", r.Old, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// MergeTags folds duplicate tags into one record named target.FullTag. The
+// record already called that is kept, or else the first of merged is renamed
+// to it, taking the tag fields of target as RenameTags does. Every other tag
+// is deleted and kept as an alias of the target, its aliases move across,
+// and an empty UDC code or description on the target is filled from the
+// merged tags.
+func (s *Store) MergeTags(target TagRecord, merged []string, source string) (*TagRecord, error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	load := func(name string) (*TagRecord, error) {
+		var t TagRecord
+		err := tx.QueryRow(`SELECT `+tagColumns+` FROM tags WHERE full_tag = ?`, name).
+			Scan(&t.ID, &t.FullTag, &t.SystemCode, &t.EquipmentID, &t.InstrumentID, &t.FunctionCode, &t.UDCCode, &t.Description)
+		if err != nil {
+			return nil, err
+		}
+		return &t, nil
+	}
+
+	keep, err := load(target.FullTag)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	var others []*TagRecord
+	for _, name := range merged {
+		if name == target.FullTag {
+			continue
+		}
+		t, err := load(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find tag %s: %w", name, err)
+		}
+		if keep == nil {
+			keep = t
+			continue
+		}
+		others = append(others, t)
+	}
+	if keep == nil {
+		return nil, fmt.Errorf("failed to merge into %s: no tags to merge", target.FullTag)
+	}
+
+	for _, t := range others {
+		if keep.UDCCode == "" {
+			keep.UDCCode = t.UDCCode
+		}
+		if keep.Description == "" {
+			keep.Description = t.Description
+		}
+		if _, err := tx.Exec(`UPDATE tag_aliases SET tag_id = ? WHERE tag_id = ?`, keep.ID, t.ID); err != nil {
+			return nil, fmt.Errorf("failed to move aliases of %s: %w", t.FullTag, err)
+		}
+		if _, err := tx.Exec(`DELETE FROM tags WHERE id = ?`, t.ID); err != nil {
+			return nil, fmt.Errorf("failed to merge %s: %w", t.FullTag, err)
+		}
+	}
+
+	if keep.FullTag != target.FullTag {
+		others = append(others, &TagRecord{FullTag: keep.FullTag})
+		keep.FullTag = target.FullTag
+		keep.SystemCode = target.SystemCode
+		keep.EquipmentID = target.EquipmentID
+		keep.InstrumentID = target.InstrumentID
+		keep.FunctionCode = target.FunctionCode
+	}
+	if _, err := tx.Exec(`UPDATE tags SET full_tag = ?, system_code = ?, equipment_id = ?, instrument_id = ?, function_code = ?, udc_code = ?, description = ? WHERE id = ?`,
+		keep.FullTag, keep.SystemCode, keep.EquipmentID, keep.InstrumentID, keep.FunctionCode, keep.UDCCode, keep.Description, keep.ID); err != nil {
+		return nil, fmt.Errorf("failed to merge into %s: %w", target.FullTag, err)
+	}
+	for _, t := range others {
+		if _, err := tx.Exec(`INSERT INTO tag_aliases (alias, tag_id, source) VALUES (?, ?, ?)
+			ON CONFLICT(alias) DO UPDATE SET tag_id = excluded.tag_id, source = excluded.source`, t.FullTag, keep.ID, source); err != nil {
+			return nil, fmt.Errorf("failed to keep alias %s: %w", t.FullTag, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return keep, nil
+}
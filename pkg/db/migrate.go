@@ -8,6 +8,29 @@ func (s *Store) Migrate() error {
 		full_bom_file TEXT,
 		validated BOOLEAN
 	);
+	CREATE TABLE IF NOT EXISTS tags (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		full_tag TEXT UNIQUE,
+		system_code TEXT,
+		equipment_id TEXT,
+		instrument_id TEXT,
+		function_code TEXT,
+		udc_code TEXT,
+		description TEXT
+	);
+	CREATE TABLE IF NOT EXISTS tag_aliases (
+		alias TEXT PRIMARY KEY,
+		tag_id INTEGER NOT NULL REFERENCES tags(id),
+		source TEXT
+	);
+	CREATE TABLE IF NOT EXISTS tag_numbers (
+		pool TEXT NOT NULL,
+		number INTEGER NOT NULL,
+		status TEXT NOT NULL,
+		tag TEXT,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (pool, number)
+	);
 	`)
 	return err
 }
@@ -0,0 +1,174 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Tag number reservation states. Released numbers stay recorded so they are
+// not handed out again unless the pool allows reuse.
+const (
+	NumberReserved = "reserved"
+	NumberReleased = "released"
+)
+
+// ErrRangeExhausted is returned when a pool has no free number left
+var ErrRangeExhausted = errors.New("no free number left in range")
+
+// NumberReservation is a loop number held in a numbering pool
+type NumberReservation struct {
+	Pool      string    `json:"pool" yaml:"pool"`
+	Number    int       `json:"number" yaml:"number"`
+	Status    string    `json:"status" yaml:"status"`
+	Tag       string    `json:"tag,omitempty" yaml:"tag,omitempty"`
+	UpdatedAt time.Time `json:"updated_at" yaml:"updated_at"`
+}
+
+// ReserveNextNumber reserves the lowest free number between min and max in
+// a pool, recording the tag that tag returns for it. With reuse, released
+// numbers count as free; reused reports whether one was taken.
+func (s *Store) ReserveNextNumber(pool string, min, max int, reuse bool, tag func(number int) string) (int, bool, error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`SELECT number, status FROM tag_numbers WHERE pool = ? AND number BETWEEN ? AND ? ORDER BY number`, pool, min, max)
+	if err != nil {
+		return 0, false, err
+	}
+	next, released := min, false
+	for rows.Next() {
+		var number int
+		var status string
+		if err := rows.Scan(&number, &status); err != nil {
+			rows.Close()
+			return 0, false, err
+		}
+		if number > next {
+			break
+		}
+		if reuse && status == NumberReleased {
+			released = true
+			break
+		}
+		next = number + 1
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, false, err
+	}
+	if next > max {
+		return 0, false, fmt.Errorf("%w %d-%d of %s", ErrRangeExhausted, min, max, pool)
+	}
+
+	if released {
+		_, err = tx.Exec(`UPDATE tag_numbers SET status = ?, tag = ?, updated_at = CURRENT_TIMESTAMP WHERE pool = ? AND number = ?`, NumberReserved, tag(next), pool, next)
+	} else {
+		_, err = tx.Exec(`INSERT INTO tag_numbers (pool, number, status, tag) VALUES (?, ?, ?, ?)`, pool, next, NumberReserved, tag(next))
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to reserve %d in %s: %w", next, pool, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, false, fmt.Errorf("failed to reserve %d in %s: %w", next, pool, err)
+	}
+	return next, released, nil
+}
+
+// ReserveNumber reserves a specific number, e.g. one already used by an
+// existing tag. It reports whether the number was free and whether it had
+// been released before; a number that is already reserved is left as it is.
+func (s *Store) ReserveNumber(pool string, number int, tag string) (reserved, reused bool, err error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return false, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var status string
+	switch err := tx.QueryRow(`SELECT status FROM tag_numbers WHERE pool = ? AND number = ?`, pool, number).Scan(&status); {
+	case err == sql.ErrNoRows:
+		_, err = tx.Exec(`INSERT INTO tag_numbers (pool, number, status, tag) VALUES (?, ?, ?, ?)`, pool, number, NumberReserved, tag)
+		if err != nil {
+			return false, false, fmt.Errorf("failed to reserve %d in %s: %w", number, pool, err)
+		}
+	case err != nil:
+		return false, false, fmt.Errorf("failed to reserve %d in %s: %w", number, pool, err)
+	case status == NumberReleased:
+		_, err = tx.Exec(`UPDATE tag_numbers SET status = ?, tag = ?, updated_at = CURRENT_TIMESTAMP WHERE pool = ? AND number = ?`, NumberReserved, tag, pool, number)
+		if err != nil {
+			return false, false, fmt.Errorf("failed to reserve %d in %s: %w", number, pool, err)
+		}
+		reused = true
+	default:
+		return false, false, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return false, false, fmt.Errorf("failed to reserve %d in %s: %w", number, pool, err)
+	}
+	return true, reused, nil
+}
+
+// UndoNumber takes back a reservation that was never used, e.g. one made by
+// a pipeline run that failed. A reused number is released again; any other
+// is forgotten, so it is the next free number once more.
+func (s *Store) UndoNumber(pool string, number int, reused bool) error {
+	var result sql.Result
+	var err error
+	if reused {
+		result, err = s.DB.Exec(`UPDATE tag_numbers SET status = ?, updated_at = CURRENT_TIMESTAMP WHERE pool = ? AND number = ? AND status = ?`,
+			NumberReleased, pool, number, NumberReserved)
+	} else {
+		result, err = s.DB.Exec(`DELETE FROM tag_numbers WHERE pool = ? AND number = ? AND status = ?`, pool, number, NumberReserved)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to undo %d in %s: %w", number, pool, err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("failed to undo %d in %s: %w", number, pool, sql.ErrNoRows)
+	}
+	return nil
+}
+
+// ReleaseNumber marks a reserved number as released
+func (s *Store) ReleaseNumber(pool string, number int) error {
+	result, err := s.DB.Exec(`UPDATE tag_numbers SET status = ?, updated_at = CURRENT_TIMESTAMP WHERE pool = ? AND number = ? AND status = ?`,
+		NumberReleased, pool, number, NumberReserved)
+	if err != nil {
+		return fmt.Errorf("failed to release %d in %s: %w", number, pool, err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("failed to release %d in %s: %w", number, pool, sql.ErrNoRows)
+	}
+	return nil
+}
+
+// ListNumbers returns the reservations of a pool, or of every pool when
+// pool is empty
+func (s *Store) ListNumbers(pool string) ([]NumberReservation, error) {
+	query := `SELECT pool, number, status, COALESCE(tag, ''), updated_at FROM tag_numbers`
+	var args []any
+	if pool != "" {
+		query += ` WHERE pool = ?`
+		args = append(args, pool)
+	}
+	rows, err := s.DB.Query(query+` ORDER BY pool, number`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var numbers []NumberReservation
+	for rows.Next() {
+		var n NumberReservation
+		if err := rows.Scan(&n.Pool, &n.Number, &n.Status, &n.Tag, &n.UpdatedAt); err != nil {
+			return nil, err
+		}
+		numbers = append(numbers, n)
+	}
+	return numbers, rows.Err()
+}
@@ -11,13 +11,15 @@ type ProjectRecord struct {
 	Validated   bool
 }
 
+// InsertProject records a project, replacing the BOM file and validation
+// state of a new revision of a known one
 func (s *Store) InsertProject(project ProjectRecord) (int64, error) {
-	result, err := s.DB.Exec(`INSERT INTO projects (project_name, full_bom_file, validated) VALUES (?, ?, ?)`,
-		project.ProjectName, project.FullBOMFile, project.Validated)
-	if err != nil {
-		return 0, err
-	}
-	return result.LastInsertId()
+	var id int64
+	err := s.DB.QueryRow(`INSERT INTO projects (project_name, full_bom_file, validated) VALUES (?, ?, ?)
+		ON CONFLICT(project_name) DO UPDATE SET full_bom_file = excluded.full_bom_file, validated = excluded.validated
+		RETURNING id`,
+		project.ProjectName, project.FullBOMFile, project.Validated).Scan(&id)
+	return id, err
 }
 
 func (s *Store) GetAllProjects() ([]ProjectRecord, error) {
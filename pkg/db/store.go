@@ -2,6 +2,7 @@ package db
 
 import (
 	"database/sql"
+	"strings"
 
 	_ "modernc.org/sqlite"
 )
@@ -10,8 +11,17 @@ type Store struct {
 	DB *sql.DB
 }
 
+// connectionOptions make concurrent writers wait for the database lock and
+// take it when a transaction begins, so read-then-write transactions such as
+// tag number allocation cannot interleave
+const connectionOptions = "_pragma=busy_timeout(10000)&_txlock=immediate"
+
 func OpenDB(path string) (*Store, error) {
-	db, err := sql.Open("sqlite", path)
+	dsn := path
+	if !strings.Contains(path, "?") {
+		dsn += "?" + connectionOptions
+	}
+	db, err := sql.Open("sqlite", dsn)
 	if err != nil {
 		return nil, err
 	}
@@ -35,3 +45,42 @@ func (s *Store) LookupTag(fulltag string) (*TagRecord, error) {
 	}
 	return &t, nil
 }
+
+// CountTagsByUDC returns the number of tags classified under each UDC code
+func (s *Store) CountTagsByUDC() (map[string]int, error) {
+	rows, err := s.DB.Query(`SELECT udc_code, COUNT(*) FROM tags WHERE udc_code != '' GROUP BY udc_code`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var code string
+		var count int
+		if err := rows.Scan(&code, &count); err != nil {
+			return nil, err
+		}
+		counts[code] = count
+	}
+	return counts, rows.Err()
+}
+
+// ListClassifiedTags returns the tags that have both a UDC code and a description
+func (s *Store) ListClassifiedTags() ([]TagRecord, error) {
+	rows, err := s.DB.Query(`SELECT id, full_tag, system_code, equipment_id, instrument_id, function_code, udc_code, description FROM tags WHERE udc_code != '' AND description != ''`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []TagRecord
+	for rows.Next() {
+		var t TagRecord
+		if err := rows.Scan(&t.ID, &t.FullTag, &t.SystemCode, &t.EquipmentID, &t.InstrumentID, &t.FunctionCode, &t.UDCCode, &t.Description); err != nil {
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+	return tags, rows.Err()
+}
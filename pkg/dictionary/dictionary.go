@@ -0,0 +1,290 @@
+package dictionary
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultLanguage is used when no language is configured
+const DefaultLanguage = "en"
+
+// maxVariants caps the number of spellings Variants generates for one term
+const maxVariants = 32
+
+// Expansions is a list of phrases a term expands to. In YAML it may be
+// written as a single string or a list.
+type Expansions []string
+
+func (e *Expansions) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*e = Expansions{value.Value}
+		return nil
+	}
+	var list []string
+	if err := value.Decode(&list); err != nil {
+		return err
+	}
+	*e = list
+	return nil
+}
+
+// File is the content of a dictionary YAML file. Abbreviations expand one
+// way (xmtr -> transmitter); each synonym group lists interchangeable terms.
+type File struct {
+	Language      string                `yaml:"language,omitempty"`
+	Abbreviations map[string]Expansions `yaml:"abbreviations,omitempty"`
+	Synonyms      [][]string            `yaml:"synonyms,omitempty"`
+}
+
+// Dictionary expands industrial abbreviations and synonyms for one language
+type Dictionary struct {
+	language   string
+	expansions map[string][]string
+	canonical  map[string]string
+	sources    []string
+}
+
+// New creates an empty dictionary for a language
+func New(language string) *Dictionary {
+	return &Dictionary{
+		language:   language,
+		expansions: make(map[string][]string),
+		canonical:  make(map[string]string),
+	}
+}
+
+// Filename returns the name of the main dictionary file for a language
+func Filename(language string) string {
+	return fmt.Sprintf("dictionary_%s.yaml", language)
+}
+
+// Load reads dictionary_<language>.yaml from the data directory followed by
+// every local dictionary_addendum_*.yaml file for that language. Local files
+// without a language apply to all languages.
+func Load(dataDir, language string) (*Dictionary, error) {
+	if language == "" {
+		language = DefaultLanguage
+	}
+	d := New(language)
+
+	main := filepath.Join(dataDir, Filename(language))
+	if _, err := os.Stat(main); err == nil {
+		if err := d.loadFile(main); err != nil {
+			return nil, err
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read %s: %w", main, err)
+	}
+
+	addendums, err := NewManager(dataDir).ListAddendums()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range addendums {
+		if err := d.loadFile(filepath.Join(dataDir, name)); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// readFile reads a dictionary file
+func readFile(filename string) (*File, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filename, err)
+	}
+	var f File
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+	return &f, nil
+}
+
+// loadFile merges a dictionary file if it is for the dictionary's language
+func (d *Dictionary) loadFile(filename string) error {
+	f, err := readFile(filename)
+	if err != nil {
+		return err
+	}
+	if f.Language != "" && f.Language != d.language {
+		return nil
+	}
+	d.Add(f)
+	d.sources = append(d.sources, filepath.Base(filename))
+	return nil
+}
+
+// Add merges the abbreviations and synonym groups of a file
+func (d *Dictionary) Add(f *File) {
+	for term, expansions := range f.Abbreviations {
+		key := normalise(term)
+		for _, e := range expansions {
+			d.addExpansion(key, normalise(e))
+		}
+		if len(expansions) == 1 {
+			d.canonical[key] = normalise(expansions[0])
+		}
+	}
+	for _, group := range f.Synonyms {
+		if len(group) == 0 {
+			continue
+		}
+		first := normalise(group[0])
+		for _, a := range group {
+			a = normalise(a)
+			if a != first {
+				d.canonical[a] = first
+			}
+			for _, b := range group {
+				if b = normalise(b); a != b {
+					d.addExpansion(a, b)
+				}
+			}
+		}
+	}
+}
+
+func (d *Dictionary) addExpansion(term, expansion string) {
+	if term == "" || expansion == "" {
+		return
+	}
+	for _, existing := range d.expansions[term] {
+		if existing == expansion {
+			return
+		}
+	}
+	d.expansions[term] = append(d.expansions[term], expansion)
+}
+
+// Language returns the dictionary's language
+func (d *Dictionary) Language() string {
+	return d.language
+}
+
+// Sources returns the files the dictionary was loaded from
+func (d *Dictionary) Sources() []string {
+	return d.sources
+}
+
+// Terms returns every term with expansions, sorted
+func (d *Dictionary) Terms() []string {
+	terms := make([]string, 0, len(d.expansions))
+	for term := range d.expansions {
+		terms = append(terms, term)
+	}
+	sort.Strings(terms)
+	return terms
+}
+
+// Lookup returns the expansions of a single term
+func (d *Dictionary) Lookup(term string) ([]string, bool) {
+	expansions, ok := d.expansions[normalise(term)]
+	return expansions, ok
+}
+
+// Canonical returns the preferred form of a term: the expansion of an
+// unambiguous abbreviation or the first term of its synonym group
+func (d *Dictionary) Canonical(term string) string {
+	term = normalise(term)
+	if c, ok := d.canonical[term]; ok {
+		return c
+	}
+	return term
+}
+
+// Normalise rewrites text into lower-case words with every abbreviation
+// and synonym replaced by its canonical form, so that differently worded
+// descriptions and legacy tags compare equal
+func (d *Dictionary) Normalise(text string) string {
+	words := words(text)
+	var out []string
+	for i := 0; i < len(words); i++ {
+		if i+1 < len(words) {
+			if c, ok := d.canonical[words[i]+" "+words[i+1]]; ok {
+				out = append(out, c)
+				i++
+				continue
+			}
+		}
+		out = append(out, d.Canonical(words[i]))
+	}
+	return strings.Join(out, " ")
+}
+
+// Expand appends the expansions of each token and of each pair of adjacent
+// tokens, so multi-word terms such as "diaphragm pp" are matched. Expanded
+// phrases are split into words.
+func (d *Dictionary) Expand(tokens []string) []string {
+	expanded := append([]string(nil), tokens...)
+	add := func(term string) {
+		for _, e := range d.expansions[term] {
+			expanded = append(expanded, strings.Fields(e)...)
+		}
+	}
+	for i, t := range tokens {
+		t = strings.ToLower(t)
+		add(t)
+		if i+1 < len(tokens) {
+			add(t + " " + strings.ToLower(tokens[i+1]))
+		}
+	}
+	return expanded
+}
+
+// Variants returns a search term followed by the spellings produced by
+// replacing its words with their expansions
+func (d *Dictionary) Variants(term string) []string {
+	words := words(term)
+	if len(words) == 0 {
+		return []string{term}
+	}
+
+	variants := []string{""}
+	for _, w := range words {
+		options := append([]string{w}, d.expansions[w]...)
+		var next []string
+		for _, v := range variants {
+			for _, o := range options {
+				if len(next) >= maxVariants {
+					break
+				}
+				next = append(next, strings.TrimSpace(v+" "+o))
+			}
+		}
+		variants = next
+	}
+
+	// Whole-phrase expansions such as "diaphragm pp"
+	variants = append(variants, d.expansions[strings.Join(words, " ")]...)
+
+	seen := map[string]bool{strings.ToLower(term): true}
+	result := []string{term}
+	for _, v := range variants {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// words splits text into lower-case words
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// normalise lower-cases a term and collapses its whitespace and punctuation
+func normalise(term string) string {
+	return strings.Join(words(term), " ")
+}
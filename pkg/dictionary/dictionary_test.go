@@ -0,0 +1,156 @@
+package dictionary
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testDictionary() *Dictionary {
+	d := New("en")
+	d.Add(&File{
+		Abbreviations: map[string]Expansions{
+			"xmtr":         {"transmitter"},
+			"pt":           {"pressure transmitter"},
+			"diaphragm pp": {"diaphragm pump"},
+		},
+		Synonyms: [][]string{{"pump", "pp"}, {"motor", "drive"}},
+	})
+	return d
+}
+
+func TestExpand(t *testing.T) {
+	d := testDictionary()
+	got := d.Expand([]string{"pt", "xmtr"})
+	want := []string{"pt", "xmtr", "pressure", "transmitter", "transmitter"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	got = d.Expand([]string{"diaphragm", "pp"})
+	want = []string{"diaphragm", "pp", "diaphragm", "pump", "pump"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestVariants(t *testing.T) {
+	d := testDictionary()
+	got := d.Variants("Drive")
+	want := []string{"Drive", "motor"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	got = d.Variants("diaphragm pp")
+	if got[0] != "diaphragm pp" || !contains(got, "diaphragm pump") {
+		t.Errorf("Expected diaphragm pump among variants, got %v", got)
+	}
+
+	if got := d.Variants("valve"); len(got) != 1 {
+		t.Errorf("Expected only the term itself for unknown words, got %v", got)
+	}
+}
+
+func TestNormalise(t *testing.T) {
+	d := testDictionary()
+	if got := d.Normalise("Diaphragm PP, PT"); got != "diaphragm pump pressure transmitter" {
+		t.Errorf("Expected canonical form, got %q", got)
+	}
+	if got := d.Normalise("Drive XMTR"); got != "motor transmitter" {
+		t.Errorf("Expected synonym replaced by first term of its group, got %q", got)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "dictionary_en.yaml", "language: en\nabbreviations:\n  xmtr: transmitter\n")
+	writeFile(t, dir, "dictionary_de.yaml", "language: de\nabbreviations:\n  mu: messumformer\n")
+	writeFile(t, dir, "dictionary_addendum_site.yaml", "abbreviations:\n  fcv: [flow control valve]\n")
+	writeFile(t, dir, "dictionary_addendum_werk.yaml", "language: de\nabbreviations:\n  kh: kugelhahn\n")
+
+	en, err := Load(dir, "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := en.Lookup("xmtr"); !ok {
+		t.Error("Expected xmtr from dictionary_en.yaml")
+	}
+	if _, ok := en.Lookup("fcv"); !ok {
+		t.Error("Expected fcv from a local file without a language")
+	}
+	if _, ok := en.Lookup("kh"); ok {
+		t.Error("Expected German local file to be skipped for en")
+	}
+	if len(en.Sources()) != 2 {
+		t.Errorf("Expected 2 source files, got %v", en.Sources())
+	}
+
+	de, err := Load(dir, "de")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, term := range []string{"mu", "kh", "fcv"} {
+		if _, ok := de.Lookup(term); !ok {
+			t.Errorf("Expected %s in German dictionary", term)
+		}
+	}
+
+	empty, err := Load(t.TempDir(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if empty.Language() != DefaultLanguage || len(empty.Terms()) != 0 {
+		t.Errorf("Expected empty %s dictionary, got %s with %v", DefaultLanguage, empty.Language(), empty.Terms())
+	}
+}
+
+func TestManager(t *testing.T) {
+	dir := t.TempDir()
+	m := NewManager(dir)
+
+	if err := m.AddAbbreviation("site", "en", "FCV", []string{"flow control valve"}); err != nil {
+		t.Fatalf("Failed to add abbreviation: %v", err)
+	}
+	if err := m.AddSynonyms("site", "en", []string{"hose", "tubing"}); err != nil {
+		t.Fatalf("Failed to add synonyms: %v", err)
+	}
+	if err := m.AddSynonyms("site", "en", []string{"hose"}); err == nil {
+		t.Error("Expected error for a synonym group with one term")
+	}
+	if err := m.AddAbbreviation("site", "de", "kh", []string{"kugelhahn"}); err == nil {
+		t.Error("Expected error when adding to a file of another language")
+	}
+
+	d, err := Load(dir, "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := d.Lookup("fcv"); !reflect.DeepEqual(got, []string{"flow control valve"}) {
+		t.Errorf("Expected fcv expansion, got %v", got)
+	}
+	if got := d.Canonical("tubing"); got != "hose" {
+		t.Errorf("Expected hose as canonical form of tubing, got %q", got)
+	}
+
+	if err := m.DeleteAddendum("site"); err != nil {
+		t.Fatalf("Failed to delete dictionary file: %v", err)
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package dictionary
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Manager provides functions for managing local dictionary files, which sit
+// in the data directory next to the UDC addendums
+type Manager struct {
+	dataDir string
+}
+
+// NewManager creates a new dictionary manager for the given data directory
+func NewManager(dataDir string) *Manager {
+	return &Manager{dataDir: dataDir}
+}
+
+// AddendumFilename applies the dictionary_addendum_*.yaml naming convention
+func AddendumFilename(filename string) string {
+	if filename == "" {
+		filename = "default"
+	}
+	if !strings.HasPrefix(filename, "dictionary_addendum_") {
+		filename = "dictionary_addendum_" + filename
+	}
+	if !strings.HasSuffix(filename, ".yaml") {
+		filename = filename + ".yaml"
+	}
+	return filename
+}
+
+// AddAbbreviation adds an abbreviation to a local dictionary file, creating
+// it for the given language if it doesn't exist
+func (m *Manager) AddAbbreviation(filename, language, term string, expansions []string) error {
+	return m.update(filename, language, func(f *File) error {
+		if f.Abbreviations == nil {
+			f.Abbreviations = make(map[string]Expansions)
+		}
+		key := normalise(term)
+		if key == "" || len(expansions) == 0 {
+			return fmt.Errorf("abbreviation requires a term and at least one expansion")
+		}
+		f.Abbreviations[key] = append(f.Abbreviations[key], expansions...)
+		return nil
+	})
+}
+
+// AddSynonyms adds a group of interchangeable terms to a local dictionary file
+func (m *Manager) AddSynonyms(filename, language string, group []string) error {
+	return m.update(filename, language, func(f *File) error {
+		if len(group) < 2 {
+			return fmt.Errorf("synonym group requires at least two terms")
+		}
+		f.Synonyms = append(f.Synonyms, group)
+		return nil
+	})
+}
+
+// ListAddendums returns a list of all local dictionary files
+func (m *Manager) ListAddendums() ([]string, error) {
+	files, err := os.ReadDir(m.dataDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read data directory: %w", err)
+	}
+	var addendums []string
+	for _, file := range files {
+		if !file.IsDir() && strings.HasPrefix(file.Name(), "dictionary_addendum_") && strings.HasSuffix(file.Name(), ".yaml") {
+			addendums = append(addendums, file.Name())
+		}
+	}
+	return addendums, nil
+}
+
+// DeleteAddendum deletes a local dictionary file
+func (m *Manager) DeleteAddendum(filename string) error {
+	return os.Remove(filepath.Join(m.dataDir, AddendumFilename(filename)))
+}
+
+// update applies a change to a local dictionary file and saves it
+func (m *Manager) update(filename, language string, change func(f *File) error) error {
+	path := filepath.Join(m.dataDir, AddendumFilename(filename))
+
+	f := &File{Language: language}
+	if _, err := os.Stat(path); err == nil {
+		if f, err = readFile(path); err != nil {
+			return fmt.Errorf("failed to read existing dictionary: %w", err)
+		}
+		if language != "" && f.Language != "" && f.Language != language {
+			return fmt.Errorf("%s is a %s dictionary, not %s", filepath.Base(path), f.Language, language)
+		}
+	}
+	if err := change(f); err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(f)
+	if err != nil {
+		return fmt.Errorf("failed to marshal dictionary data: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write dictionary file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,289 @@
+package duplicates
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/thornzero/udc_codec/pkg/assettag"
+	"github.com/thornzero/udc_codec/pkg/db"
+	"github.com/thornzero/udc_codec/pkg/pipeline"
+)
+
+// Kinds of collision, from most to least certain
+const (
+	// Exact is the same tag written identically more than once
+	Exact = "exact"
+	// Variant is different spellings of the same canonical tag, e.g.
+	// POL-LT1001 and pol lt 1001
+	Variant = "variant"
+	// Fuzzy is tags whose canonical forms differ only by look-alike
+	// characters (0/O, 1/I, 5/S, 8/B, 2/Z) or two swapped adjacent
+	// characters of which one is a letter. Swapped digits are not reported,
+	// as 1001 and 1010 are usually different loops.
+	Fuzzy = "fuzzy"
+)
+
+// SourceDB marks occurrences read from the tag DB
+const SourceDB = "db"
+
+// Occurrence is one place a tag appears
+type Occurrence struct {
+	Tag     string `json:"tag" yaml:"tag"`
+	Project string `json:"project,omitempty" yaml:"project,omitempty"`
+	Source  string `json:"source" yaml:"source"`
+	Row     int    `json:"row,omitempty" yaml:"row,omitempty"`
+}
+
+// Group is a set of occurrences that collide
+type Group struct {
+	Kind string `json:"kind" yaml:"kind"`
+	// Canonical is the normalised tag; for fuzzy groups the most common
+	// canonical form among the occurrences
+	Canonical    string       `json:"canonical" yaml:"canonical"`
+	CrossProject bool         `json:"cross_project" yaml:"cross_project"`
+	Occurrences  []Occurrence `json:"occurrences" yaml:"occurrences"`
+}
+
+// DBTags returns the distinct tags of a group's occurrences in the tag DB
+func (g Group) DBTags() []string {
+	seen := make(map[string]bool)
+	var tags []string
+	for _, o := range g.Occurrences {
+		if o.Source == SourceDB && !seen[o.Tag] {
+			seen[o.Tag] = true
+			tags = append(tags, o.Tag)
+		}
+	}
+	return tags
+}
+
+// Report is the review report of a duplicate scan
+type Report struct {
+	Scheme  string  `json:"scheme" yaml:"scheme"`
+	Scanned int     `json:"scanned" yaml:"scanned"`
+	Groups  []Group `json:"groups" yaml:"groups"`
+	// Unparsed lists tags that do not fit the scheme even loosely; they
+	// are still compared character by character
+	Unparsed []Occurrence `json:"unparsed,omitempty" yaml:"unparsed,omitempty"`
+}
+
+// Detector finds duplicate tags under a tag scheme
+type Detector struct {
+	Scheme *assettag.TagScheme
+}
+
+// New creates a detector normalising tags with a scheme
+func New(scheme *assettag.TagScheme) *Detector {
+	return &Detector{Scheme: scheme}
+}
+
+// keyed is an occurrence with its canonical form
+type keyed struct {
+	Occurrence
+	key string
+}
+
+// Detect groups colliding occurrences
+func (d *Detector) Detect(occurrences []Occurrence) *Report {
+	report := &Report{Scheme: d.Scheme.Name, Scanned: len(occurrences)}
+
+	byKey := make(map[string][]Occurrence)
+	var keys []string
+	for _, o := range occurrences {
+		key := compact(o.Tag)
+		if tag, err := d.Scheme.Normalise(o.Tag); err == nil {
+			key = tag.String()
+		} else {
+			report.Unparsed = append(report.Unparsed, o)
+		}
+		if _, ok := byKey[key]; !ok {
+			keys = append(keys, key)
+		}
+		byKey[key] = append(byKey[key], o)
+	}
+
+	for _, key := range keys {
+		group := byKey[key]
+		if len(group) < 2 {
+			continue
+		}
+		kind := Exact
+		for _, o := range group[1:] {
+			if strings.TrimSpace(o.Tag) != strings.TrimSpace(group[0].Tag) {
+				kind = Variant
+				break
+			}
+		}
+		report.Groups = append(report.Groups, newGroup(kind, key, group))
+	}
+
+	for _, keys := range fuzzyClusters(keys) {
+		canonical := keys[0]
+		var group []Occurrence
+		for _, key := range keys {
+			if len(byKey[key]) > len(byKey[canonical]) {
+				canonical = key
+			}
+			group = append(group, byKey[key]...)
+		}
+		report.Groups = append(report.Groups, newGroup(Fuzzy, canonical, group))
+	}
+
+	rank := map[string]int{Exact: 0, Variant: 1, Fuzzy: 2}
+	sort.SliceStable(report.Groups, func(i, j int) bool {
+		a, b := report.Groups[i], report.Groups[j]
+		if a.Kind != b.Kind {
+			return rank[a.Kind] < rank[b.Kind]
+		}
+		return a.Canonical < b.Canonical
+	})
+	return report
+}
+
+// newGroup builds a group, noting whether it spans projects
+func newGroup(kind, canonical string, occurrences []Occurrence) Group {
+	projects := make(map[string]bool)
+	for _, o := range occurrences {
+		if o.Project != "" {
+			projects[o.Project] = true
+		}
+	}
+	return Group{Kind: kind, Canonical: canonical, CrossProject: len(projects) > 1, Occurrences: occurrences}
+}
+
+// compact upper-cases a tag and drops everything but letters and digits
+func compact(tag string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToUpper(r)
+		}
+		return -1
+	}, tag)
+}
+
+// lookalikes maps letters to the digits they are mistaken for
+var lookalikes = strings.NewReplacer("O", "0", "I", "1", "S", "5", "B", "8", "Z", "2")
+
+// fuzzyClusters links keys that differ by look-alike characters or by a
+// swapped letter pair and returns each linked set of two or more keys, in
+// order of first appearance
+func fuzzyClusters(keys []string) [][]string {
+	parent := make(map[string]string, len(keys))
+	var find func(string) string
+	find = func(k string) string {
+		if parent[k] != k {
+			parent[k] = find(parent[k])
+		}
+		return parent[k]
+	}
+	union := func(a, b string) {
+		if ra, rb := find(a), find(b); ra != rb {
+			parent[rb] = ra
+		}
+	}
+
+	byFolded := make(map[string]string)
+	byCompact := make(map[string]string)
+	for _, k := range keys {
+		parent[k] = k
+		c := compact(k)
+		folded := lookalikes.Replace(c)
+		if other, ok := byFolded[folded]; ok {
+			union(other, k)
+		} else {
+			byFolded[folded] = k
+		}
+		byCompact[c] = k
+	}
+	for _, k := range keys {
+		c := []byte(compact(k))
+		for i := 0; i+1 < len(c); i++ {
+			if c[i] == c[i+1] || (isDigit(c[i]) && isDigit(c[i+1])) {
+				continue
+			}
+			c[i], c[i+1] = c[i+1], c[i]
+			if other, ok := byCompact[string(c)]; ok {
+				union(other, k)
+			}
+			c[i], c[i+1] = c[i+1], c[i]
+		}
+	}
+
+	members := make(map[string][]string)
+	var roots []string
+	for _, k := range keys {
+		r := find(k)
+		if _, ok := members[r]; !ok {
+			roots = append(roots, r)
+		}
+		members[r] = append(members[r], k)
+	}
+	var clusters [][]string
+	for _, r := range roots {
+		if len(members[r]) > 1 {
+			clusters = append(clusters, members[r])
+		}
+	}
+	return clusters
+}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+// FromStore lists every tag in the tag DB as an occurrence
+func FromStore(store *db.Store) ([]Occurrence, error) {
+	tags, err := store.ListTags()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+	occurrences := make([]Occurrence, len(tags))
+	for i, t := range tags {
+		occurrences[i] = Occurrence{Tag: t.FullTag, Source: SourceDB}
+	}
+	return occurrences, nil
+}
+
+// FromBOM lists the tags of a BOM's entries, formatted with the BOM's tag
+// scheme, as occurrences with their row numbers
+func FromBOM(bom *pipeline.ProjectBOM, source string, scheme *assettag.TagScheme) []Occurrence {
+	occurrences := make([]Occurrence, 0, len(bom.Entries))
+	for i, entry := range bom.Entries {
+		occurrences = append(occurrences, Occurrence{
+			Tag:     pipeline.GenerateTag(entry, scheme),
+			Project: bom.ProjectName,
+			Source:  source,
+			Row:     i + 1,
+		})
+	}
+	return occurrences
+}
+
+// Merge folds the tag DB records of a group into its canonical tag, keeping
+// the other spellings as aliases
+func (d *Detector) Merge(store *db.Store, g Group) (*db.TagRecord, error) {
+	tags := g.DBTags()
+	if len(tags) == 0 {
+		return nil, fmt.Errorf("no tags of %s are in the tag database", g.Canonical)
+	}
+	return d.MergeTags(store, g.Canonical, tags, "merge:"+g.Kind)
+}
+
+// MergeTags folds tags in the tag DB into target, which must fit the
+// detector's scheme so the merged record gets its system, function and
+// numbers
+func (d *Detector) MergeTags(store *db.Store, target string, tags []string, source string) (*db.TagRecord, error) {
+	tag, err := d.Scheme.Parse(target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse merge target %s: %w", target, err)
+	}
+	return store.MergeTags(db.TagRecord{
+		FullTag:      target,
+		SystemCode:   tag.SystemCode,
+		FunctionCode: tag.FunctionCode,
+		EquipmentID:  tag.EquipmentID,
+		InstrumentID: tag.InstrumentID,
+	}, tags, source)
+}
@@ -0,0 +1,121 @@
+package duplicates
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/thornzero/udc_codec/pkg/assettag"
+	"github.com/thornzero/udc_codec/pkg/db"
+)
+
+func TestDetect(t *testing.T) {
+	d := New(assettag.DefaultTagScheme())
+	report := d.Detect([]Occurrence{
+		{Tag: "POL-LT1001", Project: "alpha", Source: "alpha.yaml", Row: 1},
+		{Tag: "POL-LT1001", Project: "alpha", Source: "alpha.yaml", Row: 4},
+		{Tag: "pol lt 1001", Project: "beta", Source: "beta.yaml", Row: 2},
+		{Tag: "POL-FT2001", Source: SourceDB},
+		{Tag: "POL-FT2OO1", Project: "beta", Source: "beta.yaml", Row: 3},
+		{Tag: "ISO-PT3001", Source: SourceDB},
+		{Tag: "ISO-TP3001", Source: SourceDB},
+		{Tag: "ISO-PT3010", Source: SourceDB},
+	})
+
+	if report.Scanned != 8 {
+		t.Errorf("Expected 8 scanned tags, got %d", report.Scanned)
+	}
+	if len(report.Unparsed) != 1 || report.Unparsed[0].Tag != "POL-FT2OO1" {
+		t.Errorf("Expected POL-FT2OO1 to be unparsed, got %+v", report.Unparsed)
+	}
+	if len(report.Groups) != 3 {
+		t.Fatalf("Expected 3 groups, got %+v", report.Groups)
+	}
+
+	if g := report.Groups[0]; g.Kind != Variant || g.Canonical != "POL-LT1001" || len(g.Occurrences) != 3 || !g.CrossProject {
+		t.Errorf("Expected a cross-project variant group for POL-LT1001, got %+v", g)
+	}
+	if g := report.Groups[1]; g.Kind != Fuzzy || g.Canonical != "ISO-PT3001" || len(g.Occurrences) != 2 {
+		t.Errorf("Expected swapped letters to be a fuzzy match, got %+v", g)
+	}
+	if g := report.Groups[2]; g.Kind != Fuzzy || g.Canonical != "POL-FT2001" || len(g.Occurrences) != 2 {
+		t.Errorf("Expected letter O for zero to be a fuzzy match, got %+v", g)
+	}
+}
+
+func TestDetectExact(t *testing.T) {
+	d := New(assettag.DefaultTagScheme())
+	report := d.Detect([]Occurrence{
+		{Tag: "POL-LT1001", Project: "alpha", Source: "alpha.yaml", Row: 1},
+		{Tag: "POL-LT1001", Project: "alpha", Source: "alpha.yaml", Row: 2},
+		{Tag: "POL-LT1002", Project: "alpha", Source: "alpha.yaml", Row: 3},
+	})
+	if len(report.Groups) != 1 {
+		t.Fatalf("Expected 1 group, got %+v", report.Groups)
+	}
+	if g := report.Groups[0]; g.Kind != Exact || g.CrossProject {
+		t.Errorf("Expected an exact group within one project, got %+v", g)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	store, err := db.OpenDB(filepath.Join(t.TempDir(), "tags.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range []db.TagRecord{
+		{FullTag: "POLLT1001", Description: "Reactor level"},
+		{FullTag: "pol-lt-1001", UDCCode: "681.2"},
+	} {
+		if err := store.InsertTag(&r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	occurrences, err := FromStore(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	detector := New(assettag.DefaultTagScheme())
+	report := detector.Detect(occurrences)
+	if len(report.Groups) != 1 || report.Groups[0].Kind != Variant {
+		t.Fatalf("Expected one variant group, got %+v", report.Groups)
+	}
+
+	merged, err := detector.Merge(store, report.Groups[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if merged.FullTag != "POL-LT1001" || merged.UDCCode != "681.2" || merged.Description != "Reactor level" {
+		t.Errorf("Expected merged tag to combine both records, got %+v", merged)
+	}
+	stored, err := store.LookupTag("POL-LT1001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.SystemCode != "POL" || stored.FunctionCode != "LT" || stored.EquipmentID != "1001" {
+		t.Errorf("Expected the renamed record to take the target's tag fields, got %+v", stored)
+	}
+	tags, err := store.ListTags()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tags) != 1 {
+		t.Errorf("Expected 1 tag after merge, got %d", len(tags))
+	}
+	for _, alias := range []string{"POLLT1001", "pol-lt-1001"} {
+		tag, err := store.ResolveTag(alias)
+		if err != nil || tag.ID != merged.ID {
+			t.Errorf("Expected %s to resolve to the merged tag, got %+v (%v)", alias, tag, err)
+		}
+	}
+	aliases, err := store.ListAliases(merged.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(aliases) != 2 || aliases[0].Source != "merge:variant" {
+		t.Errorf("Expected 2 merge aliases, got %+v", aliases)
+	}
+}
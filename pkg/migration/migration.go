@@ -0,0 +1,272 @@
+package migration
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/thornzero/udc_codec/pkg/assettag"
+	"github.com/thornzero/udc_codec/pkg/db"
+	"github.com/thornzero/udc_codec/pkg/dictionary"
+)
+
+// Migrator rewrites legacy tags into a tag scheme with ordered rules
+type Migrator struct {
+	// Resolver, when set, validates every new tag against the
+	// classification schemes
+	Resolver *assettag.Resolver
+
+	rules  *Rules
+	scheme *assettag.TagScheme
+	dict   *dictionary.Dictionary
+	tables map[string]map[string]string
+}
+
+// New prepares a migrator. Lookup table keys are matched exactly first and
+// then through the dictionary, so a table entry for "polyol" also matches
+// "POLY" when the dictionary lists it as an abbreviation. dict may be nil.
+func New(rules *Rules, scheme *assettag.TagScheme, dict *dictionary.Dictionary) (*Migrator, error) {
+	if dict == nil {
+		dict = dictionary.New(dictionary.DefaultLanguage)
+	}
+	m := &Migrator{rules: rules, scheme: scheme, dict: dict, tables: make(map[string]map[string]string)}
+	for name, table := range rules.Tables {
+		normalised := make(map[string]string, len(table))
+		for key, value := range table {
+			normalised[dict.Normalise(key)] = value
+		}
+		m.tables[name] = normalised
+	}
+
+	funcs := template.FuncMap{"lookup": m.lookup}
+	for name, f := range templateFuncs {
+		funcs[name] = f
+	}
+	seen := make(map[string]bool)
+	for _, r := range rules.Rules {
+		if seen[r.ID] {
+			return nil, fmt.Errorf("duplicate migration rule id: %s", r.ID)
+		}
+		seen[r.ID] = true
+		if err := r.compile(funcs); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// lookup finds a value in a lookup table
+func (m *Migrator) lookup(table, key string) (string, error) {
+	t, ok := m.rules.Tables[table]
+	if !ok {
+		return "", fmt.Errorf("unknown lookup table: %s", table)
+	}
+	if value, ok := t[key]; ok {
+		return value, nil
+	}
+	if value, ok := m.tables[table][m.dict.Normalise(key)]; ok {
+		return value, nil
+	}
+	return "", fmt.Errorf("no entry for %q in lookup table %s", key, table)
+}
+
+// Result is the outcome of migrating one legacy tag
+type Result struct {
+	Old    string        `json:"old" yaml:"old"`
+	New    string        `json:"new,omitempty" yaml:"new,omitempty"`
+	Rule   string        `json:"rule,omitempty" yaml:"rule,omitempty"`
+	Reason string        `json:"reason,omitempty" yaml:"reason,omitempty"`
+	Tag    *assettag.Tag `json:"-" yaml:"-"`
+}
+
+// Migrate rewrites a single tag with the first rule that matches and
+// produces a tag valid under the scheme. Rules that match but fail, for
+// example on a missing lookup entry, fall through to the next rule; the
+// first failure is reported when no rule succeeds.
+func (m *Migrator) Migrate(legacy string) Result {
+	result := Result{Old: legacy}
+	tag := strings.ToUpper(strings.TrimSpace(legacy))
+	for _, r := range m.rules.Rules {
+		data := r.match(tag)
+		if data == nil {
+			continue
+		}
+		newTag, err := m.apply(r, data)
+		if err != nil {
+			if result.Reason == "" {
+				result.Rule = r.ID
+				result.Reason = err.Error()
+			}
+			continue
+		}
+		return Result{Old: legacy, New: m.scheme.Format(newTag), Rule: r.ID, Tag: newTag}
+	}
+	if result.Reason == "" {
+		result.Reason = "no rule matches"
+	}
+	return result
+}
+
+// apply builds and checks the new tag for a matching rule
+func (m *Migrator) apply(r *Rule, data map[string]any) (*assettag.Tag, error) {
+	var tag *assettag.Tag
+	if r.tmpl != nil {
+		text, err := execute(r.tmpl, data)
+		if err != nil {
+			return nil, err
+		}
+		if tag, err = m.scheme.Parse(text); err != nil {
+			return nil, err
+		}
+	} else {
+		values := make(map[string]string, len(r.segments))
+		for name, t := range r.segments {
+			value, err := execute(t, data)
+			if err != nil {
+				return nil, err
+			}
+			values[name] = value
+		}
+		var err error
+		if tag, err = m.scheme.Parse(m.scheme.Format(m.scheme.NewTag(values))); err != nil {
+			return nil, err
+		}
+	}
+	if m.Resolver != nil {
+		if err := m.Resolver.ValidateTag(tag); err != nil {
+			return nil, err
+		}
+	}
+	return tag, nil
+}
+
+// Conflict is a new tag that cannot be applied: several legacy tags map to
+// it, or another tag already has that name
+type Conflict struct {
+	New    string   `json:"new" yaml:"new"`
+	Old    []string `json:"old" yaml:"old"`
+	Reason string   `json:"reason" yaml:"reason"`
+}
+
+// Report is the dry-run result of a migration
+type Report struct {
+	Scheme    string     `json:"scheme" yaml:"scheme"`
+	Mapped    []Result   `json:"mapped" yaml:"mapped"`
+	Unchanged []string   `json:"unchanged,omitempty" yaml:"unchanged,omitempty"`
+	Conflicts []Conflict `json:"conflicts,omitempty" yaml:"conflicts,omitempty"`
+	Unmapped  []Result   `json:"unmapped,omitempty" yaml:"unmapped,omitempty"`
+}
+
+// Plan migrates a set of legacy tags without changing anything. existing
+// lists the other tags already in use, e.g. every tag in the DB; a new tag
+// that collides with one of them, or with the new tag of another legacy
+// tag, is reported as a conflict instead of being mapped. A legacy tag that
+// is itself mapped does not block its name; one left behind by a conflict
+// does.
+func (m *Migrator) Plan(legacy []string, existing []string) *Report {
+	report := &Report{Scheme: m.scheme.Name}
+	byNew := make(map[string][]Result)
+	var order []string
+	for _, old := range legacy {
+		r := m.Migrate(old)
+		switch {
+		case r.New == "":
+			report.Unmapped = append(report.Unmapped, r)
+		case r.New == old:
+			report.Unchanged = append(report.Unchanged, old)
+		default:
+			if _, ok := byNew[r.New]; !ok {
+				order = append(order, r.New)
+			}
+			byNew[r.New] = append(byNew[r.New], r)
+		}
+	}
+
+	conflicts := make(map[string]string)
+	for _, newTag := range order {
+		if results := byNew[newTag]; len(results) > 1 {
+			conflicts[newTag] = fmt.Sprintf("%d legacy tags map to %s", len(results), newTag)
+		}
+	}
+	// A conflicted tag keeps its old name, which may block another rename in
+	// turn, so repeat until no more conflicts turn up
+	for {
+		inUse := make(map[string]bool)
+		for _, tag := range existing {
+			inUse[tag] = true
+		}
+		for _, newTag := range order {
+			if _, conflicted := conflicts[newTag]; !conflicted {
+				delete(inUse, byNew[newTag][0].Old)
+			}
+		}
+		for _, tag := range report.Unchanged {
+			inUse[tag] = true
+		}
+
+		changed := false
+		for _, newTag := range order {
+			if _, conflicted := conflicts[newTag]; !conflicted && inUse[newTag] {
+				conflicts[newTag] = fmt.Sprintf("%s already exists", newTag)
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+
+	for _, newTag := range order {
+		results := byNew[newTag]
+		reason, conflicted := conflicts[newTag]
+		if !conflicted {
+			report.Mapped = append(report.Mapped, results[0])
+			continue
+		}
+		c := Conflict{New: newTag, Reason: reason}
+		for _, r := range results {
+			c.Old = append(c.Old, r.Old)
+		}
+		report.Conflicts = append(report.Conflicts, c)
+	}
+
+	sort.Slice(report.Mapped, func(i, j int) bool { return report.Mapped[i].Old < report.Mapped[j].Old })
+	return report
+}
+
+// PlanStore plans the migration of every tag in the tag DB
+func (m *Migrator) PlanStore(store *db.Store) (*Report, error) {
+	tags, err := store.ListTags()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+	names := make([]string, len(tags))
+	for i, t := range tags {
+		names[i] = t.FullTag
+	}
+	return m.Plan(names, names), nil
+}
+
+// Apply renames the mapped tags of a report in the tag DB, keeping each
+// legacy tag as an alias. Conflicts and unmapped tags are left untouched.
+func Apply(store *db.Store, report *Report) error {
+	renames := make([]db.TagRename, 0, len(report.Mapped))
+	for _, r := range report.Mapped {
+		renames = append(renames, db.TagRename{
+			Old: r.Old,
+			New: db.TagRecord{
+				FullTag:      r.New,
+				SystemCode:   r.Tag.SystemCode,
+				FunctionCode: r.Tag.FunctionCode,
+				EquipmentID:  r.Tag.EquipmentID,
+				InstrumentID: r.Tag.InstrumentID,
+			},
+			Source: "migration:" + r.Rule,
+		})
+	}
+	if err := store.RenameTags(renames); err != nil {
+		return fmt.Errorf("failed to apply migration: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,235 @@
+package migration
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/thornzero/udc_codec/pkg/assettag"
+	"github.com/thornzero/udc_codec/pkg/classification"
+	"github.com/thornzero/udc_codec/pkg/db"
+	"github.com/thornzero/udc_codec/pkg/dictionary"
+)
+
+func newMigrator(t *testing.T) *Migrator {
+	t.Helper()
+	rules, err := LoadRulesDir(filepath.Join("..", "..", "data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dict := dictionary.New("en")
+	dict.Add(&dictionary.File{Abbreviations: map[string]dictionary.Expansions{"poly": {"polyol"}}})
+	m, err := New(rules, assettag.DefaultTagScheme(), dict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestMigrate(t *testing.T) {
+	m := newMigrator(t)
+
+	tests := []struct {
+		old, new, rule string
+	}{
+		{"10-LT-001", "POL-LT0001", "area-loop"},
+		{"20FT12a", "ISO-FT0012-A", "area-loop"},
+		{"30-LT-001", "CAT-LT0001", "area-loop"},
+		{"CATALYST/PT/12", "CAT-PT0012", "system-name"},
+		{"POLYOL/PT/1001", "POL-PT1001", "system-name"},
+		{"poly_pt_7", "POL-PT0007", "system-name"},
+		{"POLLT1001", "POL-LT1001", "unseparated"},
+		{"CAT-FT3005-A", "CAT-FT3005-A", "unseparated"},
+	}
+	for _, tt := range tests {
+		r := m.Migrate(tt.old)
+		if r.New != tt.new || r.Rule != tt.rule {
+			t.Errorf("Expected %s -> %s by %s, got %+v", tt.old, tt.new, tt.rule, r)
+		}
+	}
+
+	r := m.Migrate("99-LT-001")
+	if r.New != "" || r.Rule != "area-loop" || r.Reason == "" {
+		t.Errorf("Expected missing lookup to be reported against area-loop, got %+v", r)
+	}
+	if r := m.Migrate("???"); r.Reason != "no rule matches" {
+		t.Errorf("Expected no rule to match, got %+v", r)
+	}
+}
+
+func TestRulesTablesUseKnownSystems(t *testing.T) {
+	dataDir := filepath.Join("..", "..", "data")
+	rules, err := LoadRulesDir(dataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	schemes, err := classification.LoadRegistryLang(dataDir, "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every shipped lookup table maps legacy names onto system codes
+	for name, table := range rules.Tables {
+		for key, code := range table {
+			if _, ok := schemes.Lookup(classification.Systems, code); !ok {
+				t.Errorf("Expected %s[%s] to be a known system code, got %s", name, key, code)
+			}
+		}
+	}
+}
+
+func TestRulesValidation(t *testing.T) {
+	bad := []*Rules{
+		{Rules: []*Rule{{Match: ".*", Template: "x"}}},
+		{Rules: []*Rule{{ID: "a", Match: "("}}},
+		{Rules: []*Rule{{ID: "a", Match: ".*"}}},
+		{Rules: []*Rule{{ID: "a", Match: ".*", Template: "{{"}}},
+		{Rules: []*Rule{{ID: "a", Match: ".*", Template: "x"}, {ID: "a", Match: ".*", Template: "y"}}},
+	}
+	for i, rules := range bad {
+		if _, err := New(rules, assettag.DefaultTagScheme(), nil); err == nil {
+			t.Errorf("Expected rules %d to be rejected", i)
+		}
+	}
+}
+
+func TestPlanAndApply(t *testing.T) {
+	m := newMigrator(t)
+
+	store, err := db.OpenDB(filepath.Join(t.TempDir(), "tags.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	for _, tag := range []string{"10-LT-001", "10LT1", "POL-PT0007", "POLYOL/PT/7", "20-FT-012", "POLLT1001", "99-XX-1"} {
+		if err := store.InsertTag(&db.TagRecord{FullTag: tag, UDCCode: "681.2", Description: "legacy " + tag}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report, err := m.PlanStore(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Mapped) != 2 || report.Mapped[0].Old != "20-FT-012" || report.Mapped[1].Old != "POLLT1001" {
+		t.Errorf("Expected 20-FT-012 and POLLT1001 to be mapped, got %+v", report.Mapped)
+	}
+	if len(report.Conflicts) != 2 {
+		t.Fatalf("Expected 2 conflicts, got %+v", report.Conflicts)
+	}
+	if c := report.Conflicts[0]; c.New != "POL-LT0001" || len(c.Old) != 2 {
+		t.Errorf("Expected two legacy tags for POL-LT0001, got %+v", c)
+	}
+	if c := report.Conflicts[1]; c.New != "POL-PT0007" || c.Old[0] != "POLYOL/PT/7" {
+		t.Errorf("Expected POLYOL/PT/7 to collide with the existing POL-PT0007, got %+v", c)
+	}
+	if len(report.Unmapped) != 1 || report.Unmapped[0].Old != "99-XX-1" {
+		t.Errorf("Expected 99-XX-1 to be unmapped, got %+v", report.Unmapped)
+	}
+	if len(report.Unchanged) != 1 || report.Unchanged[0] != "POL-PT0007" {
+		t.Errorf("Expected POL-PT0007 to be unchanged, got %v", report.Unchanged)
+	}
+
+	// Nothing is written until the report is applied
+	if _, err := store.LookupTag("ISO-FT0012"); err == nil {
+		t.Error("Expected dry run to leave the DB untouched")
+	}
+	if err := Apply(store, report); err != nil {
+		t.Fatal(err)
+	}
+
+	tag, err := store.ResolveTag("20-FT-012")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tag.FullTag != "ISO-FT0012" || tag.SystemCode != "ISO" || tag.FunctionCode != "FT" || tag.UDCCode != "681.2" {
+		t.Errorf("Expected legacy alias to resolve to the renamed tag, got %+v", tag)
+	}
+	aliases, err := store.ListAliases(tag.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(aliases) != 1 || aliases[0].Source != "migration:area-loop" {
+		t.Errorf("Expected alias from area-loop, got %+v", aliases)
+	}
+}
+
+func TestPlanChainedConflict(t *testing.T) {
+	rules := &Rules{
+		Tables: map[string]map[string]string{"renames": {
+			"POL-LT0001": "POL-LT0002",
+			"POL-LT0003": "POL-LT0002",
+			"POL-LT0004": "POL-LT0001",
+		}},
+		Rules: []*Rule{{ID: "table", Match: "^(?P<old>.+)$", Template: `{{ lookup "renames" .old }}`}},
+	}
+	m, err := New(rules, assettag.DefaultTagScheme(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// POL-LT0001 keeps its name because of the POL-LT0002 conflict, so
+	// POL-LT0004 cannot take it
+	tags := []string{"POL-LT0001", "POL-LT0003", "POL-LT0004"}
+	report := m.Plan(tags, tags)
+	if len(report.Mapped) != 0 {
+		t.Errorf("Expected nothing to be mapped, got %+v", report.Mapped)
+	}
+	if len(report.Conflicts) != 2 {
+		t.Fatalf("Expected 2 conflicts, got %+v", report.Conflicts)
+	}
+	if c := report.Conflicts[1]; c.New != "POL-LT0001" || c.Old[0] != "POL-LT0004" || c.Reason != "POL-LT0001 already exists" {
+		t.Errorf("Expected POL-LT0004 to collide with the conflicted POL-LT0001, got %+v", c)
+	}
+}
+
+func TestRenameTagsSwap(t *testing.T) {
+	store, err := db.OpenDB(filepath.Join(t.TempDir(), "tags.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	for _, tag := range []string{"A", "B"} {
+		if err := store.InsertTag(&db.TagRecord{FullTag: tag, Description: tag}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = store.RenameTags([]db.TagRename{
+		{Old: "A", New: db.TagRecord{FullTag: "B"}},
+		{Old: "B", New: db.TagRecord{FullTag: "A"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tag, _ := store.LookupTag("A"); tag == nil || tag.Description != "B" {
+		t.Errorf("Expected tags to be swapped, got %+v", tag)
+	}
+
+	// A failing rename rolls back the whole batch
+	err = store.RenameTags([]db.TagRename{
+		{Old: "A", New: db.TagRecord{FullTag: "C"}},
+		{Old: "missing", New: db.TagRecord{FullTag: "D"}},
+	})
+	if err == nil {
+		t.Fatal("Expected rename of a missing tag to fail")
+	}
+	if _, err := store.LookupTag("C"); err == nil {
+		t.Error("Expected rename to be rolled back")
+	}
+}
+
+func TestLoadRulesMissing(t *testing.T) {
+	if _, err := LoadRules(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("Expected error for missing rules file")
+	}
+	bad := filepath.Join(t.TempDir(), "bad.yaml")
+	if err := os.WriteFile(bad, []byte("rules: ["), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadRules(bad); err == nil {
+		t.Error("Expected error for invalid YAML")
+	}
+}
@@ -0,0 +1,145 @@
+package migration
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RulesFile is the default migration rules file in the data directory
+const RulesFile = "tag_migration.yaml"
+
+// Rule rewrites legacy tags matching a regular expression. Named groups of
+// the match are available to the templates as fields (.area) and as
+// positional groups (index .Groups 1). A rule produces either the whole new
+// tag from Template or one value per tag scheme segment from Segments.
+type Rule struct {
+	ID          string            `yaml:"id" json:"id"`
+	Description string            `yaml:"description,omitempty" json:"description,omitempty"`
+	Match       string            `yaml:"match" json:"match"`
+	Template    string            `yaml:"template,omitempty" json:"template,omitempty"`
+	Segments    map[string]string `yaml:"segments,omitempty" json:"segments,omitempty"`
+
+	re       *regexp.Regexp
+	tmpl     *template.Template
+	segments map[string]*template.Template
+}
+
+// Rules is a migration rule file: the target tag scheme, lookup tables and
+// the rules, tried in order
+type Rules struct {
+	Scheme string                       `yaml:"scheme,omitempty" json:"scheme,omitempty"`
+	Tables map[string]map[string]string `yaml:"tables,omitempty" json:"tables,omitempty"`
+	Rules  []*Rule                      `yaml:"rules" json:"rules"`
+}
+
+// LoadRules loads migration rules from a YAML file
+func LoadRules(filename string) (*Rules, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filename, err)
+	}
+	var rules Rules
+	if err := yaml.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+	return &rules, nil
+}
+
+// LoadRulesDir loads the default rules file from the data directory
+func LoadRulesDir(dataDir string) (*Rules, error) {
+	return LoadRules(filepath.Join(dataDir, RulesFile))
+}
+
+// compile checks a rule and prepares its expression and templates
+func (r *Rule) compile(funcs template.FuncMap) error {
+	if r.ID == "" {
+		return fmt.Errorf("migration rule without id")
+	}
+	if (r.Template == "") == (len(r.Segments) == 0) {
+		return fmt.Errorf("migration rule %s must have either a template or segments", r.ID)
+	}
+	re, err := regexp.Compile(r.Match)
+	if err != nil {
+		return fmt.Errorf("invalid match in migration rule %s: %w", r.ID, err)
+	}
+	r.re = re
+
+	parse := func(name, text string) (*template.Template, error) {
+		t, err := template.New(name).Funcs(funcs).Option("missingkey=error").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template in migration rule %s: %w", r.ID, err)
+		}
+		return t, nil
+	}
+	if r.Template != "" {
+		if r.tmpl, err = parse(r.ID, r.Template); err != nil {
+			return err
+		}
+		return nil
+	}
+	r.segments = make(map[string]*template.Template, len(r.Segments))
+	for name, text := range r.Segments {
+		if r.segments[name], err = parse(r.ID+"."+name, text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// match returns the template data for a tag, or nil if the rule does not match
+func (r *Rule) match(tag string) map[string]any {
+	groups := r.re.FindStringSubmatch(tag)
+	if groups == nil {
+		return nil
+	}
+	data := map[string]any{"Tag": tag, "Groups": groups}
+	for i, name := range r.re.SubexpNames() {
+		if name != "" {
+			data[name] = groups[i]
+		}
+	}
+	return data
+}
+
+// execute runs a template against the match data. Errors from template
+// functions, such as a missing lookup entry, are returned without the
+// template position wrapped around them.
+func execute(t *template.Template, data map[string]any) (string, error) {
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		for errors.Unwrap(err) != nil {
+			err = errors.Unwrap(err)
+		}
+		return "", err
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// templateFuncs are the helpers available to rule templates besides lookup
+var templateFuncs = template.FuncMap{
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"trim":  strings.TrimSpace,
+	// pad zero-pads a number to width digits
+	"pad": func(width int, value string) string {
+		if len(value) >= width {
+			return value
+		}
+		return strings.Repeat("0", width-len(value)) + value
+	},
+	// unpad strips leading zeros, keeping at least one digit
+	"unpad": func(value string) string {
+		if n, err := strconv.Atoi(value); err == nil {
+			return strconv.Itoa(n)
+		}
+		return value
+	},
+}
@@ -0,0 +1,136 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/thornzero/udc_codec/pkg/allocator"
+	"github.com/thornzero/udc_codec/pkg/assettag"
+)
+
+// Allocation is a loop number assigned to a BOM entry
+type Allocation struct {
+	Index  int    `yaml:"index" json:"index"`
+	Tag    string `yaml:"tag" json:"tag"`
+	Number string `yaml:"number" json:"number"`
+	// Existing marks a number the entry already had, reserved so it is not
+	// handed out
+	Existing bool `yaml:"existing,omitempty" json:"existing,omitempty"`
+	// Reused marks a number that had been released before
+	Reused bool `yaml:"reused,omitempty" json:"reused,omitempty"`
+}
+
+// entryRequest describes an entry to the allocator. Areas come from the
+// entry's area segment.
+func entryRequest(entry BOMEntry, tag string) allocator.Request {
+	return allocator.Request{
+		System:   entry.SystemCode,
+		Function: entry.FunctionCode,
+		Area:     entry.Segments["area"],
+		Tag:      tag,
+	}
+}
+
+// AllocateNumbers assigns loop numbers to the BOM entries without an
+// equipment ID. The numbers the BOM already uses are reserved first so they
+// are not handed out again; numbers outside the configured ranges are left
+// alone. Each number is recorded with the tag it completes. The result lists
+// every number reserved, so a failed run can take them back with
+// CancelNumbers. If allocation fails, the numbers reserved so far are taken
+// back.
+func AllocateNumbers(bom *ProjectBOM, a *allocator.Allocator, scheme *assettag.TagScheme) ([]Allocation, error) {
+	var allocations []Allocation
+	fail := func(err error) ([]Allocation, error) {
+		if cancelErr := CancelNumbers(bom, a, allocations); cancelErr != nil {
+			return nil, errors.Join(err, cancelErr)
+		}
+		return nil, err
+	}
+	for i, entry := range bom.Entries {
+		if entry.EquipmentID == "" {
+			continue
+		}
+		tag := GenerateTag(entry, scheme)
+		res, err := a.Hold(entryRequest(entry, tag), entry.EquipmentID)
+		if err != nil && !errors.Is(err, allocator.ErrOutOfRange) {
+			return fail(fmt.Errorf("failed to reserve %s: %w", tag, err))
+		}
+		if res != nil {
+			allocations = append(allocations, Allocation{Index: i, Tag: tag, Number: res.Number, Existing: true, Reused: res.Reused})
+		}
+	}
+
+	for i := range bom.Entries {
+		entry := &bom.Entries[i]
+		if entry.EquipmentID != "" {
+			continue
+		}
+		res, err := a.Next(entryRequest(*entry, ""), func(number string) string {
+			numbered := *entry
+			numbered.EquipmentID = number
+			return GenerateTag(numbered, scheme)
+		})
+		if err != nil {
+			return fail(fmt.Errorf("failed to allocate a number for row %d: %w", i+1, err))
+		}
+		entry.EquipmentID = res.Number
+		allocations = append(allocations, Allocation{Index: i, Tag: res.Request.Tag, Number: res.Number, Reused: res.Reused})
+	}
+	return allocations, nil
+}
+
+// CancelNumbers takes back the numbers of allocations that were never used,
+// e.g. when the run that reserved them fails, so they are handed out again.
+// Allocated entries lose their equipment ID again.
+func CancelNumbers(bom *ProjectBOM, a *allocator.Allocator, allocations []Allocation) error {
+	var errs []error
+	for _, al := range allocations {
+		entry := &bom.Entries[al.Index]
+		res := &allocator.Reservation{Request: entryRequest(*entry, al.Tag), Number: al.Number, Reused: al.Reused}
+		if err := a.Cancel(res); err != nil {
+			errs = append(errs, fmt.Errorf("failed to cancel %s: %w", al.Tag, err))
+		}
+		if !al.Existing {
+			entry.EquipmentID = ""
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// CarryNumbers gives the entries without an equipment ID the number of the
+// same instrument in a previous revision of the BOM, so uploading a BOM
+// again keeps the loop numbers allocated the first time. Entries match as
+// in DiffBOM: same system and function and the same designation or
+// description. It returns how many numbers were carried over.
+func CarryNumbers(previous, bom *ProjectBOM, scheme *assettag.TagScheme) int {
+	taken := make(map[string]bool)
+	for _, entry := range bom.Entries {
+		if entry.EquipmentID != "" {
+			taken[GenerateTag(entry, scheme)] = true
+		}
+	}
+	used := make([]bool, len(previous.Entries))
+	carried := 0
+	for j := range bom.Entries {
+		entry := &bom.Entries[j]
+		if entry.EquipmentID != "" {
+			continue
+		}
+		for i, before := range previous.Entries {
+			if used[i] || before.EquipmentID == "" || !sameInstrument(before, *entry) {
+				continue
+			}
+			numbered := *entry
+			numbered.EquipmentID = before.EquipmentID
+			tag := GenerateTag(numbered, scheme)
+			if taken[tag] {
+				continue
+			}
+			entry.EquipmentID = before.EquipmentID
+			taken[tag], used[i] = true, true
+			carried++
+			break
+		}
+	}
+	return carried
+}
@@ -0,0 +1,316 @@
+package pipeline
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/thornzero/udc_codec/pkg/assettag"
+	"gopkg.in/yaml.v3"
+)
+
+// ChangeKind says how an entry differs between two revisions of a BOM
+type ChangeKind string
+
+const (
+	ChangeAdded      ChangeKind = "added"
+	ChangeRemoved    ChangeKind = "removed"
+	ChangeModified   ChangeKind = "modified"
+	ChangeRenumbered ChangeKind = "renumbered"
+)
+
+// changeKinds lists the kinds in the order summaries show them
+var changeKinds = []ChangeKind{ChangeAdded, ChangeRemoved, ChangeModified, ChangeRenumbered}
+
+// diffFields lists the entry fields compared before segments and
+// classifications, in the order changes list them
+var diffFields = []string{"system_code", "function_code", "equipment_id", "instrument_id", "udc_code", "description", "unit", "designation"}
+
+// FieldChange is one field of an entry that differs between revisions
+type FieldChange struct {
+	Field string `json:"field" yaml:"field"`
+	Old   string `json:"old" yaml:"old"`
+	New   string `json:"new" yaml:"new"`
+}
+
+// Classification reports whether the field holds a classification code
+func (f FieldChange) Classification() bool {
+	return f.Field == "udc_code" || strings.HasPrefix(f.Field, "classifications.")
+}
+
+// Change is one entry added, removed, modified or renumbered
+type Change struct {
+	Kind ChangeKind `json:"kind" yaml:"kind"`
+	// Tag is the tag in the new revision, or the old one for removed entries
+	Tag string `json:"tag" yaml:"tag"`
+	// OldTag is the tag a renumbered entry had before
+	OldTag string `json:"old_tag,omitempty" yaml:"old_tag,omitempty"`
+	// Row is the row in the new revision, or in the old one for removed entries
+	Row         int           `json:"row" yaml:"row"`
+	Description string        `json:"description,omitempty" yaml:"description,omitempty"`
+	Fields      []FieldChange `json:"fields,omitempty" yaml:"fields,omitempty"`
+}
+
+// Reclassified reports whether the change alters a classification code
+func (c Change) Reclassified() bool {
+	return slices.ContainsFunc(c.Fields, FieldChange.Classification)
+}
+
+// BOMDiff is the change list between two revisions of a project's BOM
+type BOMDiff struct {
+	Project   string   `json:"project" yaml:"project"`
+	Changes   []Change `json:"changes" yaml:"changes"`
+	Unchanged int      `json:"unchanged" yaml:"unchanged"`
+}
+
+// Count returns the number of changes of a kind
+func (d *BOMDiff) Count(kind ChangeKind) int {
+	n := 0
+	for _, c := range d.Changes {
+		if c.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+// Reclassified returns the number of entries whose classification changed
+func (d *BOMDiff) Reclassified() int {
+	n := 0
+	for _, c := range d.Changes {
+		if c.Reclassified() {
+			n++
+		}
+	}
+	return n
+}
+
+// Empty reports whether the revisions have the same entries
+func (d *BOMDiff) Empty() bool {
+	return len(d.Changes) == 0
+}
+
+// Summary returns the change counts as text, e.g. "2 added, 0 removed, 1
+// modified, 0 renumbered (1 reclassified), 10 unchanged"
+func (d *BOMDiff) Summary() string {
+	counts := make([]string, len(changeKinds))
+	for i, kind := range changeKinds {
+		counts[i] = fmt.Sprintf("%d %s", d.Count(kind), kind)
+	}
+	summary := strings.Join(counts, ", ")
+	if n := d.Reclassified(); n > 0 {
+		summary += fmt.Sprintf(" (%d reclassified)", n)
+	}
+	return summary + fmt.Sprintf(", %d unchanged", d.Unchanged)
+}
+
+// DiffBOM compares two revisions of a BOM. Entries are matched by tag; an
+// entry whose tag changed but whose system, function and description or
+// designation did not is reported as renumbered. Changes follow the rows of
+// the new revision, with removed entries last. A nil scheme formats tags
+// with the default tag scheme.
+func DiffBOM(old, new *ProjectBOM, scheme *assettag.TagScheme) *BOMDiff {
+	if scheme == nil {
+		scheme = defaultTagScheme
+	}
+	diff := &BOMDiff{Project: new.ProjectName}
+
+	// Match entries by tag, pairing repeated tags in order
+	oldRows := make(map[string][]int)
+	for i, entry := range old.Entries {
+		tag := GenerateTag(entry, scheme)
+		oldRows[tag] = append(oldRows[tag], i)
+	}
+	matched := make([]bool, len(old.Entries))
+	changes := make([]*Change, len(new.Entries))
+	var added []int
+	for j, entry := range new.Entries {
+		tag := GenerateTag(entry, scheme)
+		rows := oldRows[tag]
+		if len(rows) == 0 {
+			added = append(added, j)
+			continue
+		}
+		i := rows[0]
+		oldRows[tag] = rows[1:]
+		matched[i] = true
+		fields := compareEntries(old.Entries[i], entry)
+		if len(fields) == 0 {
+			diff.Unchanged++
+			continue
+		}
+		changes[j] = &Change{Kind: ChangeModified, Tag: tag, Row: entryRow(j, entry), Description: entry.Description, Fields: fields}
+	}
+
+	// Pair the remaining entries that describe the same instrument
+	for _, j := range added {
+		entry := new.Entries[j]
+		change := &Change{Kind: ChangeAdded, Tag: GenerateTag(entry, scheme), Row: entryRow(j, entry), Description: entry.Description}
+		for i, before := range old.Entries {
+			if matched[i] || !sameInstrument(before, entry) {
+				continue
+			}
+			matched[i] = true
+			change.Kind = ChangeRenumbered
+			change.OldTag = GenerateTag(before, scheme)
+			change.Fields = compareEntries(before, entry)
+			break
+		}
+		changes[j] = change
+	}
+
+	for _, c := range changes {
+		if c != nil {
+			diff.Changes = append(diff.Changes, *c)
+		}
+	}
+	for i, entry := range old.Entries {
+		if !matched[i] {
+			diff.Changes = append(diff.Changes, Change{Kind: ChangeRemoved, Tag: GenerateTag(entry, scheme), Row: entryRow(i, entry), Description: entry.Description})
+		}
+	}
+	return diff
+}
+
+// entryRow returns the spreadsheet row of an entry, else its 1-based index
+func entryRow(index int, entry BOMEntry) int {
+	if entry.SourceRow > 0 {
+		return entry.SourceRow
+	}
+	return index + 1
+}
+
+// sameInstrument reports whether two entries with different tags describe
+// the same instrument
+func sameInstrument(a, b BOMEntry) bool {
+	if a.SystemCode != b.SystemCode || a.FunctionCode != b.FunctionCode {
+		return false
+	}
+	return a.Designation != "" && a.Designation == b.Designation ||
+		a.Description != "" && a.Description == b.Description
+}
+
+// compareEntries lists the fields that differ between two entries
+func compareEntries(a, b BOMEntry) []FieldChange {
+	before, after := entryFields("", a, ""), entryFields("", b, "")
+	var extra []string
+	for _, fields := range []map[string]string{before, after} {
+		for name := range fields {
+			if strings.Contains(name, ".") && !slices.Contains(extra, name) {
+				extra = append(extra, name)
+			}
+		}
+	}
+	slices.Sort(extra)
+
+	var changes []FieldChange
+	for _, name := range append(slices.Clone(diffFields), extra...) {
+		if before[name] != after[name] {
+			changes = append(changes, FieldChange{Field: name, Old: before[name], New: after[name]})
+		}
+	}
+	return changes
+}
+
+// ExportDiff writes a change list as YAML, CSV or Markdown, chosen by the
+// file extension
+func ExportDiff(diff *BOMDiff, filename string) error {
+	var write func(io.Writer, *BOMDiff) error
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml", ".yml":
+		write = WriteDiffYAML
+	case ".csv":
+		write = WriteDiffCSV
+	case ".md":
+		write = WriteDiffMarkdown
+	default:
+		return fmt.Errorf("unknown change list format %q (use .yaml, .csv or .md)", filepath.Ext(filename))
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := write(f, diff); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// LoadDiff reads a change list written as YAML
+func LoadDiff(filename string) (*BOMDiff, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var diff BOMDiff
+	if err := yaml.Unmarshal(data, &diff); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+	return &diff, nil
+}
+
+// WriteDiffYAML writes a change list as YAML
+func WriteDiffYAML(w io.Writer, diff *BOMDiff) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(diff); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// WriteDiffCSV writes one row per changed field, and one per added or
+// removed entry
+func WriteDiffCSV(w io.Writer, diff *BOMDiff) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"kind", "tag", "old_tag", "row", "description", "field", "old", "new"})
+	for _, c := range diff.Changes {
+		row := []string{string(c.Kind), c.Tag, c.OldTag, strconv.Itoa(c.Row), c.Description}
+		if len(c.Fields) == 0 {
+			cw.Write(append(row, "", "", ""))
+		}
+		for _, f := range c.Fields {
+			cw.Write(append(slices.Clone(row), f.Field, f.Old, f.New))
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteDiffMarkdown writes a change list as Markdown tables, one per kind
+func WriteDiffMarkdown(w io.Writer, diff *BOMDiff) error {
+	fmt.Fprintf(w, "# BOM changes: %s\n\n%s\n", diff.Project, diff.Summary())
+	for _, kind := range changeKinds {
+		if diff.Count(kind) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n## %s\n\n", strings.ToUpper(string(kind[:1]))+string(kind[1:]))
+		fmt.Fprintln(w, "| Row | Tag | Old tag | Description | Changes |")
+		fmt.Fprintln(w, "|-----|-----|---------|-------------|---------|")
+		for _, c := range diff.Changes {
+			if c.Kind != kind {
+				continue
+			}
+			fields := make([]string, len(c.Fields))
+			for i, f := range c.Fields {
+				fields[i] = fmt.Sprintf("%s: %s → %s", f.Field, markdownCell(f.Old), markdownCell(f.New))
+			}
+			_, err := fmt.Fprintf(w, "| %d | %s | %s | %s | %s |\n", c.Row, c.Tag, c.OldTag, markdownCell(c.Description), strings.Join(fields, "<br>"))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// markdownCell escapes text for a Markdown table cell
+func markdownCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
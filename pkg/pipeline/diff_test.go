@@ -0,0 +1,116 @@
+package pipeline
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func diffRevisions() (*ProjectBOM, *ProjectBOM) {
+	old := &ProjectBOM{ProjectName: "demo", Entries: []BOMEntry{
+		{SystemCode: "POL", FunctionCode: "LT", EquipmentID: "1001", UDCCode: "681.2", Description: "Tank level"},
+		{SystemCode: "POL", FunctionCode: "PT", EquipmentID: "1002", Description: "Feed pressure"},
+		{SystemCode: "POL", FunctionCode: "TT", EquipmentID: "1003", Description: "Tank temperature"},
+		{SystemCode: "POL", FunctionCode: "FT", EquipmentID: "1004", Description: "Return flow"},
+	}}
+	new := &ProjectBOM{ProjectName: "demo", Entries: []BOMEntry{
+		{SystemCode: "POL", FunctionCode: "LT", EquipmentID: "1001", UDCCode: "681.21", Description: "Tank level", Unit: "%"},
+		{SystemCode: "POL", FunctionCode: "PT", EquipmentID: "1002", Description: "Feed pressure"},
+		{SystemCode: "POL", FunctionCode: "TT", EquipmentID: "1013", Description: "Tank temperature"},
+		{SystemCode: "POL", FunctionCode: "LS", EquipmentID: "1005", Description: "High level switch"},
+	}}
+	return old, new
+}
+
+func TestDiffBOM(t *testing.T) {
+	old, new := diffRevisions()
+	diff := DiffBOM(old, new, nil)
+
+	want := []struct {
+		kind      ChangeKind
+		tag, from string
+		row       int
+	}{
+		{ChangeModified, "POL-LT1001", "", 1},
+		{ChangeRenumbered, "POL-TT1013", "POL-TT1003", 3},
+		{ChangeAdded, "POL-LS1005", "", 4},
+		{ChangeRemoved, "POL-FT1004", "", 4},
+	}
+	if len(diff.Changes) != len(want) {
+		t.Fatalf("Expected %d changes, got %+v", len(want), diff.Changes)
+	}
+	for i, w := range want {
+		c := diff.Changes[i]
+		if c.Kind != w.kind || c.Tag != w.tag || c.OldTag != w.from || c.Row != w.row {
+			t.Errorf("Expected change %d to be %s %s (from %q) at row %d, got %+v", i, w.kind, w.tag, w.from, w.row, c)
+		}
+	}
+	if diff.Unchanged != 1 {
+		t.Errorf("Expected 1 unchanged entry, got %d", diff.Unchanged)
+	}
+
+	modified := diff.Changes[0]
+	if len(modified.Fields) != 2 || modified.Fields[0] != (FieldChange{"udc_code", "681.2", "681.21"}) || modified.Fields[1].Field != "unit" {
+		t.Errorf("Expected the UDC code and unit to change, got %+v", modified.Fields)
+	}
+	if !modified.Reclassified() || diff.Changes[1].Reclassified() {
+		t.Error("Expected only the modified entry to be reclassified")
+	}
+	if diff.Summary() != "1 added, 1 removed, 1 modified, 1 renumbered (1 reclassified), 1 unchanged" {
+		t.Errorf("Unexpected summary %q", diff.Summary())
+	}
+}
+
+func TestDiffBOMRepeatedTags(t *testing.T) {
+	entry := BOMEntry{SystemCode: "POL", FunctionCode: "LT", EquipmentID: "1001", Description: "Tank level"}
+	old := &ProjectBOM{Entries: []BOMEntry{entry, entry}}
+	new := &ProjectBOM{Entries: []BOMEntry{entry}}
+	diff := DiffBOM(old, new, nil)
+	if diff.Unchanged != 1 || len(diff.Changes) != 1 || diff.Changes[0].Kind != ChangeRemoved || diff.Changes[0].Row != 2 {
+		t.Errorf("Expected the second copy to be removed, got %+v", diff)
+	}
+	if !DiffBOM(new, new, nil).Empty() {
+		t.Error("Expected no changes between equal revisions")
+	}
+}
+
+func TestExportDiff(t *testing.T) {
+	old, new := diffRevisions()
+	diff := DiffBOM(old, new, nil)
+	dir := t.TempDir()
+
+	yamlFile := filepath.Join(dir, "changes.yaml")
+	if err := ExportDiff(diff, yamlFile); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadDiff(yamlFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Summary() != diff.Summary() {
+		t.Errorf("Expected the YAML change list to load back, got %s", loaded.Summary())
+	}
+
+	var csvText bytes.Buffer
+	if err := WriteDiffCSV(&csvText, diff); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(csvText.String()), "\n")
+	// Header, two modified fields, one renumbered field, added and removed
+	if len(lines) != 6 || lines[3] != "renumbered,POL-TT1013,POL-TT1003,3,Tank temperature,equipment_id,1003,1013" {
+		t.Errorf("Unexpected CSV change list:\n%s", csvText.String())
+	}
+
+	var md bytes.Buffer
+	if err := WriteDiffMarkdown(&md, diff); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(md.String(), "## Renumbered") || !strings.Contains(md.String(), "udc_code: 681.2 → 681.21") {
+		t.Errorf("Unexpected Markdown change list:\n%s", md.String())
+	}
+
+	if err := ExportDiff(diff, filepath.Join(dir, "changes.txt")); err == nil {
+		t.Error("Expected an unknown extension to fail")
+	}
+}
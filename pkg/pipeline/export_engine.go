@@ -1,7 +1,6 @@
 package pipeline
 
 import (
-	"fmt"
 	"os"
 
 	"gopkg.in/yaml.v3"
@@ -22,18 +21,7 @@ func LoadExportedTags(filename string) ([]ExportRecord, error) {
 	return records, nil
 }
 
-func ExportMarkdown(project string, entries []ExportRecord) error {
-	f, err := os.Create(fmt.Sprintf("data/%s_doc.md", project))
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-
-	fmt.Fprintf(f, "# Project: %s\n\n", project)
-	fmt.Fprintln(f, "| FullTag | System | Description | UDC |")
-	fmt.Fprintln(f, "|---------|--------|-------------|-----|")
-	for _, rec := range entries {
-		fmt.Fprintf(f, "| %s | %s | %s | %s |\n", rec.FullTag, rec.SystemName, rec.Description, rec.UDCCode)
-	}
-	return nil
+// ExportMarkdown writes a tag list as a Markdown table
+func ExportMarkdown(project string, entries []ExportRecord, filename string) error {
+	return ExportTags(markdownExporter, project, entries, filename)
 }
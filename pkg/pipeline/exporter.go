@@ -1,26 +1,28 @@
 package pipeline
 
-import (
-	"os"
-
-	"gopkg.in/yaml.v3"
-)
+import "github.com/thornzero/udc_codec/pkg/crosswalk"
 
 type ExportRecord struct {
-	FullTag     string `yaml:"full_tag"`
-	SystemName  string `yaml:"system_name"`
-	Description string `yaml:"description"`
-	UDCCode     string `yaml:"udc_code,omitempty"`
+	FullTag     string `yaml:"full_tag" json:"full_tag"`
+	SystemName  string `yaml:"system_name" json:"system_name"`
+	Description string `yaml:"description" json:"description"`
+	// TagDescription is generated from the project's description template
+	TagDescription string `yaml:"tag_description,omitempty" json:"tag_description,omitempty"`
+	UDCCode        string `yaml:"udc_code,omitempty" json:"udc_code,omitempty"`
+	Designation    string `yaml:"designation,omitempty" json:"designation,omitempty"`
+	// MappedCodes lists the codes the UDC code maps to in other schemes
+	MappedCodes []crosswalk.Translation `yaml:"mapped_codes,omitempty" json:"mapped_codes,omitempty"`
 }
 
-func ExportTagList(entries []ExportRecord, filename string) error {
-	f, err := os.Create(filename)
-	if err != nil {
-		return err
+// MappedCodes returns the crosswalk translations of a UDC code, if any
+func MappedCodes(cw *crosswalk.Crosswalk, udcCode string) []crosswalk.Translation {
+	if cw == nil || udcCode == "" {
+		return nil
 	}
-	defer f.Close()
+	return cw.FromUDC(udcCode, "")
+}
 
-	encoder := yaml.NewEncoder(f)
-	encoder.SetIndent(2)
-	return encoder.Encode(entries)
+// ExportTagList writes a tag list as YAML, the file LoadExportedTags reads
+func ExportTagList(entries []ExportRecord, filename string) error {
+	return ExportTags(yamlExporter, "", entries, filename)
 }
@@ -0,0 +1,281 @@
+package pipeline
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/thornzero/udc_codec/pkg/crosswalk"
+)
+
+// Exporter writes a project's tag list in one file format
+type Exporter interface {
+	// Name returns the format name the exporter is registered under
+	Name() string
+	// Extension returns the file extension, with the dot
+	Extension() string
+	// ContentType returns the MIME type of the format
+	ContentType() string
+	// Write writes the tag list
+	Write(w io.Writer, project string, records []ExportRecord) error
+}
+
+// NewExporter creates an exporter from a write function
+func NewExporter(name, extension, contentType string, write func(w io.Writer, project string, records []ExportRecord) error) Exporter {
+	return &funcExporter{name: name, extension: extension, contentType: contentType, write: write}
+}
+
+type funcExporter struct {
+	name, extension, contentType string
+	write                        func(w io.Writer, project string, records []ExportRecord) error
+}
+
+func (e *funcExporter) Name() string        { return e.name }
+func (e *funcExporter) Extension() string   { return e.extension }
+func (e *funcExporter) ContentType() string { return e.contentType }
+func (e *funcExporter) Write(w io.Writer, project string, records []ExportRecord) error {
+	return e.write(w, project, records)
+}
+
+// Exporters holds the tag list formats available to the CLI, the pipeline
+// and the API. It is safe for concurrent use.
+type Exporters struct {
+	mu        sync.RWMutex
+	exporters map[string]Exporter
+}
+
+// NewExporters creates a registry holding the given exporters
+func NewExporters(exporters ...Exporter) *Exporters {
+	r := &Exporters{exporters: make(map[string]Exporter)}
+	for _, e := range exporters {
+		r.Register(e)
+	}
+	return r
+}
+
+// The built-in tag list formats
+var (
+	yamlExporter     = NewExporter("yaml", ".yaml", "application/yaml", writeTagsYAML)
+	markdownExporter = NewExporter("markdown", ".md", "text/markdown", writeTagsMarkdown)
+	builtinExporters = []Exporter{
+		yamlExporter,
+		NewExporter("json", ".json", "application/json", writeTagsJSON),
+		NewExporter("csv", ".csv", "text/csv", writeTagsCSV),
+		markdownExporter,
+		NewExporter("html", ".html", "text/html", writeTagsHTML),
+		NewExporter("xlsx", ".xlsx", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", writeTagsXLSX),
+	}
+)
+
+// DefaultExporters creates a registry of the built-in formats: yaml, json,
+// csv, markdown, html and xlsx
+func DefaultExporters() *Exporters {
+	return NewExporters(builtinExporters...)
+}
+
+// Register adds an exporter, replacing any registered under the same name
+func (r *Exporters) Register(e Exporter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.exporters[e.Name()] = e
+}
+
+// Get returns the exporter registered under a name
+func (r *Exporters) Get(name string) (Exporter, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	e, ok := r.exporters[name]
+	return e, ok
+}
+
+// Names returns the registered format names in sorted order
+func (r *Exporters) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.exporters))
+	for name := range r.exporters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Exporter returns the exporter registered under a name, or an error if
+// there is none
+func (r *Exporters) Exporter(name string) (Exporter, error) {
+	e, ok := r.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown export format %q (use %s)", name, strings.Join(r.Names(), ", "))
+	}
+	return e, nil
+}
+
+// ExportTags writes a tag list to a file with an exporter
+func ExportTags(e Exporter, project string, records []ExportRecord, filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := e.Write(f, project, records); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// exportColumns are the columns of the tabular formats
+var exportColumns = []string{"full_tag", "system_name", "tag_description", "description", "udc_code", "designation", "mapped_codes"}
+
+// exportRow returns a record's values in the order of exportColumns
+func exportRow(rec ExportRecord) []string {
+	return []string{rec.FullTag, rec.SystemName, rec.TagDescription, rec.Description, rec.UDCCode, rec.Designation, crosswalk.Join(rec.MappedCodes)}
+}
+
+func writeTagsYAML(w io.Writer, project string, records []ExportRecord) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(records); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func writeTagsJSON(w io.Writer, project string, records []ExportRecord) error {
+	if records == nil {
+		records = []ExportRecord{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(records)
+}
+
+func writeTagsCSV(w io.Writer, project string, records []ExportRecord) error {
+	cw := csv.NewWriter(w)
+	cw.Write(exportColumns)
+	for _, rec := range records {
+		cw.Write(exportRow(rec))
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeTagsMarkdown(w io.Writer, project string, records []ExportRecord) error {
+	fmt.Fprintf(w, "# Project: %s\n\n", project)
+	fmt.Fprintln(w, "| Full Tag | System | Tag Description | Description | UDC | Designation | Mapped Codes |")
+	fmt.Fprintln(w, "|----------|--------|-----------------|-------------|-----|-------------|--------------|")
+	for _, rec := range records {
+		row := exportRow(rec)
+		for i := range row {
+			row[i] = markdownCell(row[i])
+		}
+		if _, err := fmt.Fprintf(w, "| %s |\n", strings.Join(row, " | ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var tagsHTML = template.Must(template.New("tags").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Tags: {{ .Project }}</title>
+</head>
+<body>
+<h1>Tags: {{ .Project }}</h1>
+<table border="1">
+  <tr>{{ range .Columns }}<th>{{ . }}</th>{{ end }}</tr>
+  {{ range .Rows }}
+  <tr>{{ range . }}<td>{{ . }}</td>{{ end }}</tr>
+  {{ end }}
+</table>
+</body>
+</html>
+`))
+
+func writeTagsHTML(w io.Writer, project string, records []ExportRecord) error {
+	rows := make([][]string, len(records))
+	for i, rec := range records {
+		rows[i] = exportRow(rec)
+	}
+	return tagsHTML.Execute(w, map[string]any{"Project": project, "Columns": exportColumns, "Rows": rows})
+}
+
+// xlsxParts are the workbook parts around the worksheet of an XLSX tag list
+var xlsxParts = []struct{ name, text string }{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+	{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Tags" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`},
+}
+
+// writeTagsXLSX writes a workbook with one sheet of inline strings
+func writeTagsXLSX(w io.Writer, project string, records []ExportRecord) error {
+	zw := zip.NewWriter(w)
+	for _, part := range xlsxParts {
+		f, err := zw.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, part.text); err != nil {
+			return err
+		}
+	}
+
+	sheet, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	io.WriteString(sheet, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	writeRow := func(number int, values []string) error {
+		fmt.Fprintf(sheet, `<row r="%d">`, number)
+		for col, value := range values {
+			if value == "" {
+				continue
+			}
+			fmt.Fprintf(sheet, `<c r="%s%d" t="inlineStr"><is><t xml:space="preserve">`, columnName(col), number)
+			if err := xml.EscapeText(sheet, []byte(value)); err != nil {
+				return err
+			}
+			io.WriteString(sheet, `</t></is></c>`)
+		}
+		_, err := io.WriteString(sheet, `</row>`)
+		return err
+	}
+	if err := writeRow(1, exportColumns); err != nil {
+		return err
+	}
+	for i, rec := range records {
+		if err := writeRow(i+2, exportRow(rec)); err != nil {
+			return err
+		}
+	}
+	if _, err := io.WriteString(sheet, `</sheetData></worksheet>`); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// columnName returns the letters of a 0-based spreadsheet column, e.g. AB
+func columnName(col int) string {
+	name := ""
+	for col++; col > 0; col = (col - 1) / 26 {
+		name = string(rune('A'+(col-1)%26)) + name
+	}
+	return name
+}
@@ -0,0 +1,103 @@
+package pipeline
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/thornzero/udc_codec/pkg/crosswalk"
+)
+
+func exportSample() []ExportRecord {
+	return []ExportRecord{
+		{FullTag: "POL-LT1001", SystemName: "Polyol System", TagDescription: "Level Transmitter 1001, Polyol System", Description: `Tank "A" level`, UDCCode: "681.2",
+			MappedCodes: []crosswalk.Translation{{Scheme: "eclass", Code: "27-20", Equivalence: "related"}}},
+		{FullTag: "POL-PT1002", SystemName: "Polyol System", Description: "Feed | return\npressure"},
+	}
+}
+
+func TestDefaultExporters(t *testing.T) {
+	exporters := DefaultExporters()
+	want := []string{"csv", "html", "json", "markdown", "xlsx", "yaml"}
+	if !slices.Equal(exporters.Names(), want) {
+		t.Errorf("Expected formats %v, got %v", want, exporters.Names())
+	}
+	if _, err := exporters.Exporter("pdf"); err == nil || !strings.Contains(err.Error(), "csv, html") {
+		t.Errorf("Expected an unknown format to list the formats, got %v", err)
+	}
+
+	custom := NewExporter("tags", ".txt", "text/plain", func(w io.Writer, project string, records []ExportRecord) error {
+		for _, r := range records {
+			io.WriteString(w, r.FullTag+"\n")
+		}
+		return nil
+	})
+	exporters.Register(custom)
+	e, err := exporters.Exporter("tags")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := e.Write(&buf, "demo", exportSample()); err != nil || buf.String() != "POL-LT1001\nPOL-PT1002\n" {
+		t.Errorf("Expected the registered exporter to write the tags, got %q, %v", buf.String(), err)
+	}
+}
+
+func TestCSVExporterQuotes(t *testing.T) {
+	e, _ := DefaultExporters().Get("csv")
+	var buf bytes.Buffer
+	if err := e.Write(&buf, "demo", exportSample()); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 || !slices.Equal(rows[0], exportColumns) {
+		t.Fatalf("Expected a header and 2 rows, got %v", rows)
+	}
+	if rows[1][2] != "Level Transmitter 1001, Polyol System" || rows[1][3] != `Tank "A" level` || rows[1][6] != "eclass:27-20 (related)" {
+		t.Errorf("Expected commas and quotes to survive, got %v", rows[1])
+	}
+	if rows[2][3] != "Feed | return\npressure" {
+		t.Errorf("Expected line breaks to survive, got %q", rows[2][3])
+	}
+}
+
+func TestTextExporters(t *testing.T) {
+	exporters := DefaultExporters()
+	write := func(format string, records []ExportRecord) string {
+		e, _ := exporters.Get(format)
+		var buf bytes.Buffer
+		if err := e.Write(&buf, "demo", records); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+
+	var records []ExportRecord
+	if err := json.Unmarshal([]byte(write("json", exportSample())), &records); err != nil || len(records) != 2 || records[0].FullTag != "POL-LT1001" {
+		t.Errorf("Expected the JSON tag list to decode, got %+v, %v", records, err)
+	}
+	if got := write("json", nil); got != "[]\n" {
+		t.Errorf("Expected an empty JSON list, got %q", got)
+	}
+	if md := write("markdown", exportSample()); !strings.Contains(md, `| Feed \| return pressure |`) {
+		t.Errorf("Expected Markdown cells to be escaped, got:\n%s", md)
+	}
+	if html := write("html", exportSample()); !strings.Contains(html, "Tank &#34;A&#34; level") {
+		t.Errorf("Expected HTML to be escaped, got:\n%s", html)
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	for col, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"} {
+		if got := columnName(col); got != want {
+			t.Errorf("Expected column %d to be %s, got %s", col, want, got)
+		}
+	}
+}
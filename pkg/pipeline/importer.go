@@ -20,3 +20,16 @@ func LoadBOM(filename string) (*ProjectBOM, error) {
 	}
 	return &bom, nil
 }
+
+// ExportBOM writes a BOM as YAML, e.g. after importing it from a spreadsheet
+func ExportBOM(bom *ProjectBOM, filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	encoder := yaml.NewEncoder(f)
+	encoder.SetIndent(2)
+	return encoder.Encode(bom)
+}
@@ -0,0 +1,131 @@
+package pipeline
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/thornzero/udc_codec/pkg/assettag"
+	"github.com/thornzero/udc_codec/pkg/classification"
+)
+
+// LoopRecord is one loop of a loop list
+type LoopRecord struct {
+	System       string   `json:"system" yaml:"system"`
+	SystemName   string   `json:"system_name,omitempty" yaml:"system_name,omitempty"`
+	Loop         string   `json:"loop" yaml:"loop"`
+	Variable     string   `json:"variable" yaml:"variable"`
+	VariableName string   `json:"variable_name,omitempty" yaml:"variable_name,omitempty"`
+	Tags         []string `json:"tags" yaml:"tags"`
+	Issues       []string `json:"issues,omitempty" yaml:"issues,omitempty"`
+}
+
+// LoopList is the loop list of a project with summaries per system and
+// measured variable
+type LoopList struct {
+	Project string                 `json:"project" yaml:"project"`
+	Loops   []LoopRecord           `json:"loops" yaml:"loops"`
+	Summary []assettag.LoopSummary `json:"summary" yaml:"summary"`
+}
+
+// Inconsistent returns the loops flagged for mixed variables or bad codes
+func (l *LoopList) Inconsistent() []LoopRecord {
+	var flagged []LoopRecord
+	for _, r := range l.Loops {
+		if len(r.Issues) > 0 {
+			flagged = append(flagged, r)
+		}
+	}
+	return flagged
+}
+
+// BuildLoopList groups parsed tags into loops. letters and systemName may
+// be nil.
+func BuildLoopList(project string, tags []*assettag.Tag, letters *classification.ISALetters, systemName func(code string) string) *LoopList {
+	loops := assettag.GroupLoops(tags, letters)
+	list := &LoopList{Project: project, Summary: assettag.SummariseLoops(loops)}
+	for _, loop := range loops {
+		r := LoopRecord{
+			System:       loop.System,
+			Loop:         loop.Number,
+			Variable:     loop.Variable,
+			VariableName: loop.VariableName,
+			Issues:       loop.Issues,
+		}
+		if systemName != nil {
+			r.SystemName = systemName(loop.System)
+		}
+		for _, tag := range loop.Tags {
+			r.Tags = append(r.Tags, tag.String())
+		}
+		list.Loops = append(list.Loops, r)
+	}
+	return list
+}
+
+// ExportLoopList writes a loop list to a YAML file
+func ExportLoopList(list *LoopList, filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	encoder := yaml.NewEncoder(f)
+	encoder.SetIndent(2)
+	return encoder.Encode(list)
+}
+
+// LoadLoopList reads a loop list written by ExportLoopList
+func LoadLoopList(filename string) (*LoopList, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var list LoopList
+	if err := yaml.NewDecoder(f).Decode(&list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// ExportLoopMarkdown writes the loop summary and loop list as Markdown
+func ExportLoopMarkdown(list *LoopList, filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fmt.Fprintf(f, "# Loops: %s\n\n", list.Project)
+	fmt.Fprintln(f, "| System | Variable | Loops | Instruments | Inconsistent |")
+	fmt.Fprintln(f, "|--------|----------|-------|-------------|--------------|")
+	for _, s := range list.Summary {
+		fmt.Fprintf(f, "| %s | %s %s | %d | %d | %d |\n", s.System, s.Variable, s.VariableName, s.Loops, s.Instruments, s.Inconsistent)
+	}
+	fmt.Fprintln(f)
+	fmt.Fprintln(f, "| System | Loop | Variable | Tags | Issues |")
+	fmt.Fprintln(f, "|--------|------|----------|------|--------|")
+	for _, r := range list.Loops {
+		fmt.Fprintf(f, "| %s | %s | %s | %s | %s |\n", r.System, r.Loop, r.Variable, strings.Join(r.Tags, ", "), strings.Join(r.Issues, "; "))
+	}
+	return nil
+}
+
+// WriteLoopCSV writes one row per loop as CSV
+func WriteLoopCSV(w io.Writer, list *LoopList) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"system", "system_name", "loop", "variable", "variable_name", "instruments", "tags", "issues"})
+	for _, r := range list.Loops {
+		cw.Write([]string{r.System, r.SystemName, r.Loop, r.Variable, r.VariableName, strconv.Itoa(len(r.Tags)), strings.Join(r.Tags, "; "), strings.Join(r.Issues, "; ")})
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -1,14 +1,35 @@
 package pipeline
 
 type BOMEntry struct {
-	SystemCode  string `yaml:"system_code"`
-	EquipmentID string `yaml:"equipment_id"`
+	SystemCode   string `yaml:"system_code"`
+	EquipmentID  string `yaml:"equipment_id"`
 	FunctionCode string `yaml:"function_code"`
-	UDCCode     string `yaml:"udc_code,omitempty"`
-	Description string `yaml:"description"`
+	// InstrumentID is the instrument suffix, e.g. A in POL-LT1001-A
+	InstrumentID string `yaml:"instrument_id,omitempty"`
+	UDCCode      string `yaml:"udc_code,omitempty"`
+	Description  string `yaml:"description"`
+	// Unit is the engineering unit of the measured variable, e.g. °C
+	Unit string `yaml:"unit,omitempty"`
+	// Designation is the IEC 81346 reference designation, e.g. =A1=WP2-QA1+R101
+	Designation string `yaml:"designation,omitempty"`
+	// Segments holds values for site-specific tag segments such as area
+	Segments map[string]string `yaml:"segments,omitempty"`
+	// Classifications holds codes in further schemes, keyed by scheme name
+	Classifications map[string]string `yaml:"classifications,omitempty"`
+	// SourceRow is the spreadsheet row an imported entry was read from
+	SourceRow int `yaml:"source_row,omitempty"`
 }
 
 type ProjectBOM struct {
-	ProjectName string      `yaml:"project_name"`
-	Entries     []BOMEntry  `yaml:"entries"`
+	ProjectName string `yaml:"project_name"`
+	TagScheme   string `yaml:"tag_scheme,omitempty"`
+	// Language selects the tag description template; empty uses the
+	// configured language
+	Language string `yaml:"language,omitempty"`
+	// AllocateNumbers assigns loop numbers to entries without an equipment ID
+	AllocateNumbers bool `yaml:"allocate_numbers,omitempty"`
+	// WarningPolicy is allow or block: whether validation warnings stop
+	// the export. Errors always do.
+	WarningPolicy string     `yaml:"warning_policy,omitempty"`
+	Entries       []BOMEntry `yaml:"entries"`
 }
@@ -0,0 +1,203 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+)
+
+// Severity of a validation issue
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// Rule IDs of the checks the validator runs
+const (
+	RuleUnknownSystem         = "unknown-system"
+	RuleUnknownFunction       = "unknown-function"
+	RuleMissingEquipmentID    = "missing-equipment-id"
+	RuleTagFormat             = "tag-format"
+	RuleDuplicateTag          = "duplicate-tag"
+	RuleInvalidUDC            = "invalid-udc"
+	RuleMissingUDC            = "missing-udc"
+	RuleInvalidDesignation    = "invalid-designation"
+	RuleInvalidClassification = "invalid-classification"
+	RuleMissingDescription    = "missing-description"
+	RuleWarningPolicy         = "warning-policy"
+)
+
+// Issue is one finding for one BOM row
+type Issue struct {
+	// Row is the spreadsheet row for imported BOMs, else the 1-based entry
+	Row      int      `json:"row" yaml:"row"`
+	Tag      string   `json:"tag,omitempty" yaml:"tag,omitempty"`
+	Field    string   `json:"field" yaml:"field"`
+	Rule     string   `json:"rule" yaml:"rule"`
+	Severity Severity `json:"severity" yaml:"severity"`
+	Message  string   `json:"message" yaml:"message"`
+	// Fix suggests how to resolve the issue
+	Fix string `json:"fix,omitempty" yaml:"fix,omitempty"`
+}
+
+// WarningPolicy decides whether warnings block the export
+type WarningPolicy string
+
+const (
+	WarningsAllow WarningPolicy = "allow"
+	WarningsBlock WarningPolicy = "block"
+)
+
+// ParseWarningPolicy checks a policy name; empty selects allow
+func ParseWarningPolicy(name string) (WarningPolicy, error) {
+	switch WarningPolicy(name) {
+	case "", WarningsAllow:
+		return WarningsAllow, nil
+	case WarningsBlock:
+		return WarningsBlock, nil
+	}
+	return "", fmt.Errorf("unknown warning policy %q (use allow or block)", name)
+}
+
+// ValidationReport holds every issue found in a BOM
+type ValidationReport struct {
+	Project string        `json:"project" yaml:"project"`
+	Policy  WarningPolicy `json:"policy" yaml:"policy"`
+	Rows    int           `json:"rows" yaml:"rows"`
+	Issues  []Issue       `json:"issues" yaml:"issues"`
+	// Omitted counts the issues left out by severity once MaxIssues are held
+	Omitted map[Severity]int `json:"omitted,omitempty" yaml:"omitted,omitempty"`
+	// MaxIssues limits the issues Add keeps; 0 keeps all
+	MaxIssues int `json:"-" yaml:"-"`
+}
+
+// Add appends issues to the report. Once it holds MaxIssues issues,
+// further ones are only counted.
+func (r *ValidationReport) Add(issues ...Issue) {
+	for _, i := range issues {
+		if r.MaxIssues > 0 && len(r.Issues) >= r.MaxIssues {
+			if r.Omitted == nil {
+				r.Omitted = make(map[Severity]int)
+			}
+			r.Omitted[i.Severity]++
+			continue
+		}
+		r.Issues = append(r.Issues, i)
+	}
+}
+
+// Count returns the number of issues of a severity, including omitted ones
+func (r *ValidationReport) Count(severity Severity) int {
+	n := r.Omitted[severity]
+	for _, i := range r.Issues {
+		if i.Severity == severity {
+			n++
+		}
+	}
+	return n
+}
+
+// Blocks reports whether the issues stop the export under the report's policy
+func (r *ValidationReport) Blocks() bool {
+	return r.Count(SeverityError) > 0 || r.Policy == WarningsBlock && r.Count(SeverityWarning) > 0
+}
+
+// Summary returns the issue counts as text, e.g. "2 errors, 1 warning, 0 info"
+func (r *ValidationReport) Summary() string {
+	return fmt.Sprintf("%s, %s, %d info", plural(r.Count(SeverityError), "error"), plural(r.Count(SeverityWarning), "warning"), r.Count(SeverityInfo))
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, word)
+	}
+	return fmt.Sprintf("%d %ss", n, word)
+}
+
+// ValidationError is returned when a report blocks the export
+type ValidationError struct {
+	Report *ValidationReport
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("validation of %s failed: %s", e.Report.Project, e.Report.Summary())
+}
+
+// ExportValidationReport writes a report as JSON
+func ExportValidationReport(report *ValidationReport, filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return WriteValidationJSON(f, report)
+}
+
+// LoadValidationReport reads a report written by ExportValidationReport
+func LoadValidationReport(filename string) (*ValidationReport, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var report ValidationReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+	return &report, nil
+}
+
+// WriteValidationJSON writes a report as indented JSON
+func WriteValidationJSON(w io.Writer, report *ValidationReport) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+// WriteValidationCSV writes one row per issue as CSV
+func WriteValidationCSV(w io.Writer, report *ValidationReport) error {
+	iw := NewIssueWriter(w)
+	if err := iw.Write(report.Issues...); err != nil {
+		return err
+	}
+	return iw.Flush()
+}
+
+var validationHTML = template.Must(template.New("validation").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Validation: {{ .Project }}</title>
+<style>
+  body { font-family: sans-serif; }
+  table { border-collapse: collapse; }
+  th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+  .error { background: #fdd; }
+  .warning { background: #ffd; }
+  .info { background: #eef; }
+</style>
+</head>
+<body>
+<h2>Validation: {{ .Project }}</h2>
+<p>{{ .Rows }} rows: {{ .Summary }} (warnings {{ if eq .Policy "block" }}block{{ else }}allowed{{ end }}){{ if .Blocks }}; export blocked{{ end }}</p>
+{{ if .Omitted }}<p>Only the first {{ len .Issues }} issues are listed; the CSV report has them all.</p>{{ end }}
+{{ if .Issues }}
+<table>
+  <tr><th>Row</th><th>Tag</th><th>Field</th><th>Rule</th><th>Severity</th><th>Message</th><th>Fix</th></tr>
+  {{ range .Issues }}
+  <tr class="{{ .Severity }}"><td>{{ .Row }}</td><td>{{ .Tag }}</td><td>{{ .Field }}</td><td>{{ .Rule }}</td><td>{{ .Severity }}</td><td>{{ .Message }}</td><td>{{ .Fix }}</td></tr>
+  {{ end }}
+</table>
+{{ end }}
+</body>
+</html>
+`))
+
+// WriteValidationHTML writes a report as a standalone HTML page
+func WriteValidationHTML(w io.Writer, report *ValidationReport) error {
+	return validationHTML.Execute(w, report)
+}
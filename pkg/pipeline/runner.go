@@ -0,0 +1,225 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefinitionFile is the file in the data directory holding the pipeline
+// definition
+const DefinitionFile = "pipeline.yaml"
+
+// StageDef is one stage of a pipeline definition
+type StageDef struct {
+	ID string `json:"id" yaml:"id"`
+	// Uses names the stage type; empty uses the ID
+	Uses string `json:"uses,omitempty" yaml:"uses,omitempty"`
+	// Needs lists the stages that must finish first
+	Needs  []string       `json:"needs,omitempty" yaml:"needs,omitempty"`
+	Config map[string]any `json:"config,omitempty" yaml:"config,omitempty"`
+}
+
+// Type returns the stage type
+func (d StageDef) Type() string {
+	if d.Uses != "" {
+		return d.Uses
+	}
+	return d.ID
+}
+
+// Definition is a pipeline: stages and the dependencies between them
+type Definition struct {
+	Name        string     `json:"name" yaml:"name"`
+	Description string     `json:"description,omitempty" yaml:"description,omitempty"`
+	Stages      []StageDef `json:"stages" yaml:"stages"`
+}
+
+// DefaultDefinition returns the built-in pipeline: import, normalise,
+// allocate, classify, validate, tag, export and register
+func DefaultDefinition() *Definition {
+	return &Definition{
+		Name:        "default",
+		Description: "Import a BOM, check it and export the tag and loop lists",
+		Stages: []StageDef{
+			{ID: "import"},
+			{ID: "normalise", Needs: []string{"import"}},
+			{ID: "allocate", Needs: []string{"normalise"}},
+			{ID: "classify", Needs: []string{"normalise"}},
+			{ID: "validate", Needs: []string{"allocate", "classify"}, Config: map[string]any{"formats": []any{"json", "html", "csv"}}},
+			{ID: "tag", Needs: []string{"validate"}},
+			{ID: "export", Needs: []string{"tag"}, Config: map[string]any{"formats": []any{"taglist", "loops", "loops-markdown"}}},
+			{ID: "register", Needs: []string{"export"}},
+		},
+	}
+}
+
+// StreamDefinition returns the built-in pipeline for large BOMs: one
+// stream stage reading, checking and tagging rows in parallel, then
+// register
+func StreamDefinition() *Definition {
+	return &Definition{
+		Name:        "stream",
+		Description: "Check and tag a large BOM row by row with a pool of workers",
+		Stages: []StageDef{
+			{ID: "stream", Config: map[string]any{"reports": []any{"json", "html", "csv"}, "formats": []any{"taglist"}}},
+			{ID: "register", Needs: []string{"stream"}},
+		},
+	}
+}
+
+// LoadDefinition reads a pipeline definition and checks its dependencies
+func LoadDefinition(filename string) (*Definition, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filename, err)
+	}
+	var def Definition
+	if err := yaml.Unmarshal(data, &def); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+	if _, err := def.Order(); err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", filename, err)
+	}
+	return &def, nil
+}
+
+// LoadDefinitionDir loads pipeline.yaml from the data directory, falling
+// back to the default pipeline when the file is missing
+func LoadDefinitionDir(dataDir string) (*Definition, error) {
+	filename := filepath.Join(dataDir, DefinitionFile)
+	if _, err := os.Stat(filename); err != nil {
+		return DefaultDefinition(), nil
+	}
+	return LoadDefinition(filename)
+}
+
+// Order returns the stages so that each comes after the stages it needs.
+// Stages keep their definition order where the dependencies allow.
+func (d *Definition) Order() ([]StageDef, error) {
+	if len(d.Stages) == 0 {
+		return nil, fmt.Errorf("pipeline %s has no stages", d.Name)
+	}
+	byID := make(map[string]StageDef, len(d.Stages))
+	for _, s := range d.Stages {
+		if s.ID == "" {
+			return nil, fmt.Errorf("pipeline %s has a stage without an id", d.Name)
+		}
+		if _, ok := byID[s.ID]; ok {
+			return nil, fmt.Errorf("pipeline %s has duplicate stage %s", d.Name, s.ID)
+		}
+		byID[s.ID] = s
+	}
+	for _, s := range d.Stages {
+		for _, need := range s.Needs {
+			if _, ok := byID[need]; !ok {
+				return nil, fmt.Errorf("stage %s needs unknown stage %s", s.ID, need)
+			}
+		}
+	}
+
+	done := make(map[string]bool, len(d.Stages))
+	var order []StageDef
+	for len(order) < len(d.Stages) {
+		progressed := false
+		for _, s := range d.Stages {
+			if done[s.ID] || !allDone(s.Needs, done) {
+				continue
+			}
+			done[s.ID] = true
+			order = append(order, s)
+			progressed = true
+		}
+		if !progressed {
+			var cycle []string
+			for _, s := range d.Stages {
+				if !done[s.ID] {
+					cycle = append(cycle, s.ID)
+				}
+			}
+			return nil, fmt.Errorf("pipeline %s has a dependency cycle between %s", d.Name, strings.Join(cycle, ", "))
+		}
+	}
+	return order, nil
+}
+
+func allDone(ids []string, done map[string]bool) bool {
+	for _, id := range ids {
+		if !done[id] {
+			return false
+		}
+	}
+	return true
+}
+
+// Runner executes pipeline definitions with a set of stage types
+type Runner struct {
+	Stages map[string]Stage
+	// Events receives every event as it happens; optional
+	Events func(Event)
+}
+
+// Check returns an error if a definition is invalid or uses unknown stages
+func (r *Runner) Check(def *Definition) error {
+	order, err := def.Order()
+	if err != nil {
+		return err
+	}
+	for _, s := range order {
+		if _, ok := r.Stages[s.Type()]; !ok {
+			return fmt.Errorf("stage %s uses unknown stage type %s", s.ID, s.Type())
+		}
+	}
+	return nil
+}
+
+// Run executes the stages of a definition in dependency order. The first
+// failing stage stops the run, the side effects registered with OnFailure
+// are undone and its error is returned; the run keeps the events and
+// artifacts produced so far.
+func (r *Runner) Run(ctx context.Context, def *Definition, run *Run) error {
+	if err := r.Check(def); err != nil {
+		return err
+	}
+	order, _ := def.Order()
+	run.emit = r.Events
+	defer func() { run.stage, run.emit = "", nil }()
+
+	for _, s := range order {
+		if err := ctx.Err(); err != nil {
+			return run.fail(err)
+		}
+		run.stage = s.ID
+		run.send(Event{Kind: EventStarted, Message: s.Type()})
+		err := r.Stages[s.Type()].Run(ctx, run, stageConfig(s, run.Params))
+		if reason, ok := isSkip(err); ok {
+			run.send(Event{Kind: EventSkipped, Message: reason})
+			continue
+		}
+		if err != nil {
+			run.send(Event{Kind: EventFailed, Message: err.Error()})
+			return run.fail(fmt.Errorf("stage %s failed: %w", s.ID, err))
+		}
+		run.send(Event{Kind: EventDone})
+	}
+	run.undoes = nil
+	return nil
+}
+
+// stageConfig merges the run parameters for a stage over its settings
+func stageConfig(s StageDef, params map[string]string) StageConfig {
+	cfg := make(StageConfig, len(s.Config))
+	for k, v := range s.Config {
+		cfg[k] = v
+	}
+	for k, v := range params {
+		if key, ok := strings.CutPrefix(k, s.ID+"."); ok && v != "" {
+			cfg[key] = v
+		}
+	}
+	return cfg
+}
@@ -0,0 +1,195 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDefinitionOrder(t *testing.T) {
+	def := &Definition{Name: "test", Stages: []StageDef{
+		{ID: "export", Needs: []string{"tag"}},
+		{ID: "import"},
+		{ID: "tag", Needs: []string{"validate", "import"}},
+		{ID: "validate", Needs: []string{"import"}},
+	}}
+	order, err := def.Order()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, s := range order {
+		ids = append(ids, s.ID)
+	}
+	if got := strings.Join(ids, ","); got != "import,validate,tag,export" {
+		t.Errorf("Expected dependency order, got %s", got)
+	}
+
+	for _, bad := range []*Definition{
+		{Name: "empty"},
+		{Name: "unknown", Stages: []StageDef{{ID: "a", Needs: []string{"b"}}}},
+		{Name: "duplicate", Stages: []StageDef{{ID: "a"}, {ID: "a"}}},
+		{Name: "cycle", Stages: []StageDef{{ID: "a", Needs: []string{"b"}}, {ID: "b", Needs: []string{"a"}}}},
+	} {
+		if _, err := bad.Order(); err == nil {
+			t.Errorf("Expected an error for pipeline %s", bad.Name)
+		}
+	}
+}
+
+func TestRunnerRun(t *testing.T) {
+	var calls []string
+	record := func(name string) Stage {
+		return StageFunc(func(ctx context.Context, run *Run, cfg StageConfig) error {
+			calls = append(calls, name+":"+cfg.String("mode", "-"))
+			return nil
+		})
+	}
+	runner := &Runner{Stages: map[string]Stage{
+		"import": record("import"),
+		"check":  record("check"),
+		"skip": StageFunc(func(ctx context.Context, run *Run, cfg StageConfig) error {
+			return Skip("nothing to do")
+		}),
+		"write": StageFunc(func(ctx context.Context, run *Run, cfg StageConfig) error {
+			run.Progress(1, 2)
+			run.AddArtifact("taglist", "out.yaml")
+			return nil
+		}),
+	}}
+	var events []Event
+	runner.Events = func(e Event) { events = append(events, e) }
+
+	def := &Definition{Name: "test", Stages: []StageDef{
+		{ID: "import"},
+		{ID: "strict", Uses: "check", Needs: []string{"import"}, Config: map[string]any{"mode": "strict"}},
+		{ID: "loose", Uses: "check", Needs: []string{"import"}},
+		{ID: "skip", Needs: []string{"import"}},
+		{ID: "write", Needs: []string{"strict", "loose", "skip"}},
+	}}
+	run := &Run{Params: map[string]string{"loose.mode": "loose", "strict.other": "x"}}
+	if err := runner.Run(context.Background(), def, run); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(calls, ","); got != "import:-,check:strict,check:loose" {
+		t.Errorf("Expected each stage to get its own settings, got %s", got)
+	}
+	if path, ok := run.Artifact("taglist"); !ok || path != "out.yaml" || run.Artifacts[0].Stage != "write" {
+		t.Errorf("Expected the artifact of the write stage, got %+v", run.Artifacts)
+	}
+	var kinds []string
+	for _, e := range events {
+		if e.Stage == "skip" || e.Stage == "write" {
+			kinds = append(kinds, e.Stage+":"+e.Kind)
+		}
+	}
+	want := "skip:started,skip:skipped,write:started,write:progress,write:artifact,write:done"
+	if got := strings.Join(kinds, ","); got != want {
+		t.Errorf("Expected events %s, got %s", want, got)
+	}
+	if len(run.Events) != len(events) {
+		t.Errorf("Expected the run to keep its %d events, got %d", len(events), len(run.Events))
+	}
+}
+
+func TestRunnerStopsAtFailure(t *testing.T) {
+	report := &ValidationReport{Project: "demo", Issues: []Issue{{Severity: SeverityError}}}
+	ran := false
+	runner := &Runner{Stages: map[string]Stage{
+		"validate": StageFunc(func(ctx context.Context, run *Run, cfg StageConfig) error {
+			return &ValidationError{Report: report}
+		}),
+		"export": StageFunc(func(ctx context.Context, run *Run, cfg StageConfig) error {
+			ran = true
+			return nil
+		}),
+	}}
+	def := &Definition{Name: "test", Stages: []StageDef{{ID: "validate"}, {ID: "export", Needs: []string{"validate"}}}}
+	err := runner.Run(context.Background(), def, &Run{})
+
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Report != report {
+		t.Errorf("Expected the validation error to be passed on, got %v", err)
+	}
+	if ran {
+		t.Error("Expected the export stage not to run")
+	}
+
+	def.Stages = append(def.Stages, StageDef{ID: "publish"})
+	if err := runner.Run(context.Background(), def, &Run{}); err == nil || !strings.Contains(err.Error(), "unknown stage type publish") {
+		t.Errorf("Expected an unknown stage type error, got %v", err)
+	}
+}
+
+func TestRunnerUndoesOnFailure(t *testing.T) {
+	var undone []string
+	runner := &Runner{Stages: map[string]Stage{
+		"reserve": StageFunc(func(ctx context.Context, run *Run, cfg StageConfig) error {
+			run.OnFailure(func() error {
+				undone = append(undone, "reserve")
+				return nil
+			})
+			return nil
+		}),
+		"validate": StageFunc(func(ctx context.Context, run *Run, cfg StageConfig) error {
+			return errors.New("invalid BOM")
+		}),
+	}}
+	def := &Definition{Name: "test", Stages: []StageDef{{ID: "reserve"}, {ID: "validate", Needs: []string{"reserve"}}}}
+	if err := runner.Run(context.Background(), def, &Run{}); err == nil || err.Error() != "stage validate failed: invalid BOM" {
+		t.Errorf("Expected the validation failure, got %v", err)
+	}
+	if len(undone) != 1 {
+		t.Errorf("Expected the reservation to be undone once, got %v", undone)
+	}
+
+	undone = nil
+	def.Stages = def.Stages[:1]
+	if err := runner.Run(context.Background(), def, &Run{}); err != nil || len(undone) != 0 {
+		t.Errorf("Expected a successful run to keep its side effects, got %v, %v", undone, err)
+	}
+}
+
+func TestLoadDefinitionDir(t *testing.T) {
+	dir := t.TempDir()
+	def, err := LoadDefinitionDir(dir)
+	if err != nil || def.Name != "default" {
+		t.Fatalf("Expected the default pipeline without a file, got %v %v", def, err)
+	}
+
+	content := `
+name: quick
+stages:
+  - id: import
+  - id: tag
+    needs: [import]
+  - id: export
+    needs: [tag]
+    config:
+      formats: [taglist]
+`
+	if err := os.WriteFile(filepath.Join(dir, DefinitionFile), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	def, err = LoadDefinitionDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := stageConfig(def.Stages[2], map[string]string{"export.formats": "taglist, loops"})
+	if def.Name != "quick" || strings.Join(cfg.Strings("formats", nil), "|") != "taglist|loops" {
+		t.Errorf("Unexpected definition %+v", def)
+	}
+	if got := StageConfig(def.Stages[2].Config).Strings("formats", nil); len(got) != 1 || got[0] != "taglist" {
+		t.Errorf("Expected the YAML list setting, got %v", got)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, DefinitionFile), []byte("name: bad\nstages:\n  - id: a\n    needs: [a]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadDefinitionDir(dir); err == nil {
+		t.Error("Expected an error for a stage needing itself")
+	}
+}
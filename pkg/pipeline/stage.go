@@ -0,0 +1,253 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/thornzero/udc_codec/pkg/assettag"
+)
+
+// Stage is one step of a pipeline run. Stages read and extend the shared
+// Run; cfg holds the stage's settings from the pipeline definition.
+type Stage interface {
+	Run(ctx context.Context, run *Run, cfg StageConfig) error
+}
+
+// StageFunc adapts a function to the Stage interface
+type StageFunc func(ctx context.Context, run *Run, cfg StageConfig) error
+
+func (f StageFunc) Run(ctx context.Context, run *Run, cfg StageConfig) error {
+	return f(ctx, run, cfg)
+}
+
+// skipped is returned by stages with nothing to do
+type skipped struct{ reason string }
+
+func (s skipped) Error() string { return "skipped: " + s.reason }
+
+// Skip returns the error a stage reports when it has nothing to do, e.g. an
+// allocate stage for a BOM where every entry has a number
+func Skip(reason string) error {
+	return skipped{reason}
+}
+
+// Event kinds
+const (
+	EventStarted  = "started"
+	EventProgress = "progress"
+	EventWarning  = "warning"
+	EventArtifact = "artifact"
+	EventSkipped  = "skipped"
+	EventDone     = "done"
+	EventFailed   = "failed"
+)
+
+// Event reports the progress of a run
+type Event struct {
+	Time    time.Time `json:"time" yaml:"time"`
+	Stage   string    `json:"stage" yaml:"stage"`
+	Kind    string    `json:"kind" yaml:"kind"`
+	Message string    `json:"message,omitempty" yaml:"message,omitempty"`
+	// Done and Total count the entries a progress event has handled
+	Done  int `json:"done,omitempty" yaml:"done,omitempty"`
+	Total int `json:"total,omitempty" yaml:"total,omitempty"`
+}
+
+func (e Event) String() string {
+	s := fmt.Sprintf("[%s] %s", e.Stage, e.Kind)
+	if e.Total > 0 {
+		s += fmt.Sprintf(" %d/%d", e.Done, e.Total)
+	}
+	if e.Message != "" {
+		s += ": " + e.Message
+	}
+	return s
+}
+
+// Artifact is a file a run wrote
+type Artifact struct {
+	Stage string `json:"stage" yaml:"stage"`
+	// Kind names the content, e.g. taglist or validation-html
+	Kind string `json:"kind" yaml:"kind"`
+	Path string `json:"path" yaml:"path"`
+}
+
+// Run is the state passed between the stages of one pipeline run
+type Run struct {
+	// Project names the output files; the import stage falls back to the
+	// BOM's project name
+	Project string
+	// Input is the BOM file the import stage reads
+	Input string
+	// Params override stage settings, keyed "<stage id>.<key>", e.g.
+	// import.profile
+	Params map[string]string
+
+	BOM        *ProjectBOM
+	TagScheme  *assettag.TagScheme
+	Validation *ValidationReport
+	Records    []ExportRecord
+	Tags       []*assettag.Tag
+	Loops      *LoopList
+
+	Events    []Event
+	Artifacts []Artifact
+
+	stage  string
+	emit   func(Event)
+	undoes []func() error
+}
+
+// OnFailure registers a function that undoes a stage's side effects, such
+// as reserved loop numbers, if a later stage fails
+func (r *Run) OnFailure(undo func() error) {
+	r.undoes = append(r.undoes, undo)
+}
+
+// fail runs the registered undo functions, last first, and returns the
+// error that stopped the run along with any undo errors
+func (r *Run) fail(err error) error {
+	errs := []error{err}
+	for i := len(r.undoes) - 1; i >= 0; i-- {
+		if undoErr := r.undoes[i](); undoErr != nil {
+			errs = append(errs, fmt.Errorf("failed to undo the run: %w", undoErr))
+		}
+	}
+	r.undoes = nil
+	if len(errs) == 1 {
+		return err
+	}
+	return errors.Join(errs...)
+}
+
+// Emit records an event for the current stage and passes it on
+func (r *Run) Emit(kind, message string) {
+	r.send(Event{Kind: kind, Message: message})
+}
+
+// Progress reports how many of the entries a stage has handled
+func (r *Run) Progress(done, total int) {
+	r.send(Event{Kind: EventProgress, Done: done, Total: total})
+}
+
+// AddArtifact records a file written by the current stage
+func (r *Run) AddArtifact(kind, path string) {
+	r.Artifacts = append(r.Artifacts, Artifact{Stage: r.stage, Kind: kind, Path: path})
+	r.send(Event{Kind: EventArtifact, Message: path})
+}
+
+// Artifact returns the path of the last artifact of a kind
+func (r *Run) Artifact(kind string) (string, bool) {
+	for i := len(r.Artifacts) - 1; i >= 0; i-- {
+		if r.Artifacts[i].Kind == kind {
+			return r.Artifacts[i].Path, true
+		}
+	}
+	return "", false
+}
+
+func (r *Run) send(e Event) {
+	e.Time = time.Now()
+	if e.Stage == "" {
+		e.Stage = r.stage
+	}
+	r.Events = append(r.Events, e)
+	if r.emit != nil {
+		r.emit(e)
+	}
+}
+
+// StageConfig holds a stage's settings. Values come from YAML, or from run
+// parameters as text, so the getters convert both.
+type StageConfig map[string]any
+
+// String returns a setting as text
+func (c StageConfig) String(key, fallback string) string {
+	v, ok := c[key]
+	if !ok || v == nil {
+		return fallback
+	}
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprint(v)
+}
+
+// Bool returns a true/false setting
+func (c StageConfig) Bool(key string, fallback bool) (bool, error) {
+	switch v := c[key].(type) {
+	case nil:
+		return fallback, nil
+	case bool:
+		return v, nil
+	case string:
+		if v == "" {
+			return fallback, nil
+		}
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return false, fmt.Errorf("setting %s: %q is not true or false", key, v)
+		}
+		return b, nil
+	}
+	return false, fmt.Errorf("setting %s: %v is not true or false", key, c[key])
+}
+
+// Float returns a numeric setting
+func (c StageConfig) Float(key string, fallback float64) (float64, error) {
+	switch v := c[key].(type) {
+	case nil:
+		return fallback, nil
+	case int:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case string:
+		if v == "" {
+			return fallback, nil
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, fmt.Errorf("setting %s: %q is not a number", key, v)
+		}
+		return f, nil
+	}
+	return 0, fmt.Errorf("setting %s: %v is not a number", key, c[key])
+}
+
+// Strings returns a list setting; text is split at commas
+func (c StageConfig) Strings(key string, fallback []string) []string {
+	switch v := c[key].(type) {
+	case []string:
+		return v
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			values = append(values, fmt.Sprint(item))
+		}
+		return values
+	case string:
+		if v == "" {
+			return fallback
+		}
+		values := strings.Split(v, ",")
+		for i := range values {
+			values[i] = strings.TrimSpace(values[i])
+		}
+		return values
+	}
+	return fallback
+}
+
+// isSkip reports whether a stage error is a Skip
+func isSkip(err error) (string, bool) {
+	var s skipped
+	if errors.As(err, &s) {
+		return s.reason, true
+	}
+	return "", false
+}
@@ -0,0 +1,173 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"runtime"
+	"strconv"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// windowPerWorker is how many items per worker may be read ahead of the
+// first result still being worked on
+const windowPerWorker = 4
+
+// Process runs work on every item next returns with a bounded pool of
+// workers and passes the results to emit in input order. Only a few items
+// per worker are held at once, so a stream of any length is processed in
+// constant memory. next returns io.EOF after the last item; the first
+// error from next or emit, or the end of ctx, stops the run. Fewer than one
+// worker uses one per CPU.
+func Process[T, R any](ctx context.Context, workers int, next func() (T, error), work func(index int, item T) R, emit func(index int, result R) error) error {
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type job struct {
+		index int
+		item  T
+	}
+	type result struct {
+		index int
+		value R
+	}
+	jobs := make(chan job)
+	results := make(chan result, workers)
+	// window holds a slot for every item read but not yet emitted
+	window := make(chan struct{}, workers*windowPerWorker)
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				results <- result{j.index, work(j.index, j.item)}
+			}
+		}()
+	}
+
+	readErr := make(chan error, 1)
+	go func() {
+		defer close(jobs)
+		for index := 0; ; index++ {
+			select {
+			case window <- struct{}{}:
+			case <-ctx.Done():
+				readErr <- ctx.Err()
+				return
+			}
+			item, err := next()
+			if err == io.EOF {
+				readErr <- nil
+				return
+			}
+			if err != nil {
+				readErr <- err
+				return
+			}
+			select {
+			case jobs <- job{index, item}:
+			case <-ctx.Done():
+				readErr <- ctx.Err()
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// Hold results that finish early until those before them are emitted
+	pending := make(map[int]R)
+	emitted := 0
+	var emitErr error
+	for r := range results {
+		if emitErr != nil {
+			continue
+		}
+		pending[r.index] = r.value
+		for {
+			value, ok := pending[emitted]
+			if !ok {
+				break
+			}
+			delete(pending, emitted)
+			if err := emit(emitted, value); err != nil {
+				emitErr = err
+				cancel()
+				break
+			}
+			emitted++
+			<-window
+		}
+	}
+	if emitErr != nil {
+		return emitErr
+	}
+	return <-readErr
+}
+
+// ListWriter writes a YAML sequence one item at a time. The text is the
+// same as encoding the whole slice, as ExportTagList does.
+type ListWriter[T any] struct {
+	w     io.Writer
+	items int
+}
+
+// NewListWriter starts a YAML sequence on w
+func NewListWriter[T any](w io.Writer) *ListWriter[T] {
+	return &ListWriter[T]{w: w}
+}
+
+// Write appends an item to the sequence
+func (l *ListWriter[T]) Write(item T) error {
+	enc := yaml.NewEncoder(l.w)
+	enc.SetIndent(2)
+	if err := enc.Encode([]T{item}); err != nil {
+		return err
+	}
+	l.items++
+	return enc.Close()
+}
+
+// Close ends the sequence; an empty one is written as []
+func (l *ListWriter[T]) Close() error {
+	if l.items > 0 {
+		return nil
+	}
+	_, err := io.WriteString(l.w, "[]\n")
+	return err
+}
+
+// IssueWriter writes validation issues as CSV rows as they are found
+type IssueWriter struct {
+	cw *csv.Writer
+}
+
+// NewIssueWriter writes the CSV header of a validation report
+func NewIssueWriter(w io.Writer) *IssueWriter {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"row", "tag", "field", "rule", "severity", "message", "fix"})
+	return &IssueWriter{cw: cw}
+}
+
+// Write adds a row per issue
+func (iw *IssueWriter) Write(issues ...Issue) error {
+	for _, i := range issues {
+		iw.cw.Write([]string{strconv.Itoa(i.Row), i.Tag, i.Field, i.Rule, string(i.Severity), i.Message, i.Fix})
+	}
+	return iw.cw.Error()
+}
+
+// Flush writes any buffered rows
+func (iw *IssueWriter) Flush() error {
+	iw.cw.Flush()
+	return iw.cw.Error()
+}
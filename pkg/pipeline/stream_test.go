@@ -0,0 +1,131 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// counter returns a next function handing out 0 to n-1
+func counter(n int) func() (int, error) {
+	i := 0
+	return func() (int, error) {
+		if i == n {
+			return 0, io.EOF
+		}
+		i++
+		return i - 1, nil
+	}
+}
+
+func TestProcessKeepsOrder(t *testing.T) {
+	var got []int
+	work := func(index, item int) int {
+		// Later items often finish first
+		time.Sleep(time.Duration(7-item%7) * 10 * time.Microsecond)
+		return item * 2
+	}
+	emit := func(index, result int) error {
+		if index != len(got) {
+			t.Fatalf("Expected index %d, got %d", len(got), index)
+		}
+		got = append(got, result)
+		return nil
+	}
+	if err := Process(context.Background(), 8, counter(1000), work, emit); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1000 {
+		t.Fatalf("Expected 1000 results, got %d", len(got))
+	}
+	for i, v := range got {
+		if v != i*2 {
+			t.Fatalf("Expected result %d at %d, got %d", i*2, i, v)
+		}
+	}
+}
+
+func TestProcessStops(t *testing.T) {
+	double := func(index, item int) int { return item * 2 }
+	stop := errors.New("stop")
+
+	emitted := 0
+	err := Process(context.Background(), 4, counter(1000), double, func(index, result int) error {
+		if index == 10 {
+			return stop
+		}
+		emitted++
+		return nil
+	})
+	if !errors.Is(err, stop) || emitted != 10 {
+		t.Errorf("Expected the emit error after 10 results, got %v after %d", err, emitted)
+	}
+
+	failing := func() (int, error) { return 0, stop }
+	if err := Process(context.Background(), 4, failing, double, func(int, int) error { return nil }); !errors.Is(err, stop) {
+		t.Errorf("Expected the read error, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := Process(ctx, 4, counter(1000), double, func(int, int) error { return nil }); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected a cancelled run to stop, got %v", err)
+	}
+}
+
+func TestListWriterMatchesExport(t *testing.T) {
+	records := []ExportRecord{
+		{FullTag: "POL-LT1001", SystemName: "Polyol System", Description: "Tank level", UDCCode: "681.21"},
+		{FullTag: "POL-PT1002", SystemName: "Polyol System", Description: "Feed\npressure"},
+	}
+	for _, n := range []int{0, 2} {
+		filename := filepath.Join(t.TempDir(), "taglist.yaml")
+		if err := ExportTagList(records[:n], filename); err != nil {
+			t.Fatal(err)
+		}
+		want, _ := os.ReadFile(filename)
+
+		var buf bytes.Buffer
+		w := NewListWriter[ExportRecord](&buf)
+		for _, r := range records[:n] {
+			if err := w.Write(r); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != string(want) {
+			t.Errorf("Expected the streamed list of %d to match the export:\n%s\ngot:\n%s", n, want, buf.String())
+		}
+	}
+}
+
+func TestValidationReportMaxIssues(t *testing.T) {
+	report := &ValidationReport{Project: "demo", MaxIssues: 2}
+	report.Add(
+		Issue{Row: 1, Severity: SeverityError},
+		Issue{Row: 2, Severity: SeverityWarning},
+		Issue{Row: 3, Severity: SeverityError},
+		Issue{Row: 4, Severity: SeverityInfo},
+	)
+	if len(report.Issues) != 2 {
+		t.Errorf("Expected 2 issues to be kept, got %d", len(report.Issues))
+	}
+	if report.Summary() != "2 errors, 1 warning, 1 info" {
+		t.Errorf("Expected omitted issues to be counted, got %s", report.Summary())
+	}
+
+	var buf bytes.Buffer
+	if err := WriteValidationHTML(&buf, report); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("Only the first 2 issues")) {
+		t.Error("Expected the HTML report to say issues were left out")
+	}
+}
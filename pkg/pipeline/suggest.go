@@ -0,0 +1,86 @@
+package pipeline
+
+import (
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/thornzero/udc_codec/pkg/suggest"
+)
+
+// SuggestMode controls what the suggest stage does with its results
+type SuggestMode string
+
+const (
+	// SuggestPropose only reports candidate codes
+	SuggestPropose SuggestMode = "propose"
+	// SuggestFill also sets the UDC code of entries whose best candidate
+	// reaches the minimum score
+	SuggestFill SuggestMode = "fill"
+)
+
+// SuggestOptions configures the suggest stage
+type SuggestOptions struct {
+	Mode     SuggestMode
+	Limit    int
+	MinScore float64
+}
+
+// Proposal lists the suggested UDC codes for an unclassified BOM entry
+type Proposal struct {
+	Index       int                  `yaml:"index"`
+	Tag         string               `yaml:"tag"`
+	Description string               `yaml:"description"`
+	Suggestions []suggest.Suggestion `yaml:"suggestions"`
+	Filled      string               `yaml:"filled,omitempty"`
+}
+
+// SuggestCodes ranks UDC codes for every BOM entry without one. In fill
+// mode the best candidate is written to the entry when it scores at least
+// MinScore.
+func SuggestCodes(bom *ProjectBOM, classifier *suggest.Classifier, opts SuggestOptions) []Proposal {
+	var proposals []Proposal
+	for i := range bom.Entries {
+		if p, ok := SuggestEntry(i, &bom.Entries[i], classifier, opts); ok {
+			proposals = append(proposals, p)
+		}
+	}
+	return proposals
+}
+
+// SuggestEntry ranks UDC codes for one entry without one; index is its
+// 0-based position in the BOM. It reports false when there is nothing to
+// propose.
+func SuggestEntry(index int, entry *BOMEntry, classifier *suggest.Classifier, opts SuggestOptions) (Proposal, bool) {
+	if entry.UDCCode != "" || entry.Description == "" {
+		return Proposal{}, false
+	}
+	suggestions := classifier.Suggest(entry.Description, opts.Limit)
+	if len(suggestions) == 0 {
+		return Proposal{}, false
+	}
+	p := Proposal{
+		Index:       index,
+		Tag:         GenerateFullTag(*entry),
+		Description: entry.Description,
+		Suggestions: suggestions,
+	}
+	if opts.Mode == SuggestFill && suggestions[0].Score >= opts.MinScore {
+		entry.UDCCode = suggestions[0].Code
+		p.Filled = entry.UDCCode
+	}
+	return p, true
+}
+
+// ExportProposals writes suggest stage results to a YAML file for review
+func ExportProposals(proposals []Proposal, filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	encoder := yaml.NewEncoder(f)
+	encoder.SetIndent(2)
+	return encoder.Encode(proposals)
+}
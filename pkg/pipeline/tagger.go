@@ -1,10 +1,54 @@
 package pipeline
 
 import (
-	"fmt"
+	"strings"
+
+	"github.com/thornzero/udc_codec/pkg/assettag"
 )
 
+var defaultTagScheme = assettag.DefaultTagScheme()
+
+// GenerateFullTag formats an entry's tag with the default tag scheme
 func GenerateFullTag(entry BOMEntry) string {
-	tag := fmt.Sprintf("%s-%s%s", entry.SystemCode, entry.FunctionCode, entry.EquipmentID)
-	return tag
+	return GenerateTag(entry, defaultTagScheme)
+}
+
+// GenerateTag formats an entry's tag with a project's tag scheme
+func GenerateTag(entry BOMEntry, scheme *assettag.TagScheme) string {
+	return scheme.Format(EntryTag(entry))
+}
+
+// EntryTag builds the tag identity of a BOM entry
+func EntryTag(entry BOMEntry) *assettag.Tag {
+	return &assettag.Tag{
+		SystemCode:      entry.SystemCode,
+		FunctionCode:    entry.FunctionCode,
+		EquipmentID:     entry.EquipmentID,
+		InstrumentID:    entry.InstrumentID,
+		UDCCode:         entry.UDCCode,
+		Classifications: entry.Classifications,
+		Segments:        entry.Segments,
+	}
+}
+
+// NormaliseEntry tidies codes typed by hand: whitespace, letter case,
+// numbers read as decimals, and the tag scheme's spelling of the tag. It
+// reports whether anything changed.
+func NormaliseEntry(entry *BOMEntry, scheme *assettag.TagScheme) bool {
+	before := *entry
+	entry.SystemCode = strings.ToUpper(strings.TrimSpace(entry.SystemCode))
+	entry.FunctionCode = strings.ToUpper(strings.TrimSpace(entry.FunctionCode))
+	entry.InstrumentID = strings.ToUpper(strings.TrimSpace(entry.InstrumentID))
+	entry.EquipmentID = strings.TrimSuffix(strings.TrimSpace(entry.EquipmentID), ".0")
+	entry.UDCCode = strings.TrimSpace(entry.UDCCode)
+	entry.Description = strings.TrimSpace(entry.Description)
+	if entry.EquipmentID != "" {
+		if tag, err := scheme.Normalise(GenerateTag(*entry, scheme)); err == nil {
+			entry.SystemCode, entry.FunctionCode = tag.SystemCode, tag.FunctionCode
+			entry.EquipmentID, entry.InstrumentID = tag.EquipmentID, tag.InstrumentID
+		}
+	}
+	return entry.SystemCode != before.SystemCode || entry.FunctionCode != before.FunctionCode ||
+		entry.EquipmentID != before.EquipmentID || entry.InstrumentID != before.InstrumentID ||
+		entry.UDCCode != before.UDCCode || entry.Description != before.Description
 }
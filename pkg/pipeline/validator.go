@@ -1,31 +1,304 @@
 package pipeline
 
 import (
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/thornzero/udc_codec/pkg/aggregator"
-	"github.com/thornzero/udc_codec/pkg/udc"
+	"github.com/thornzero/udc_codec/pkg/assettag"
+	"github.com/thornzero/udc_codec/pkg/classification"
+	"github.com/thornzero/udc_codec/pkg/rules"
 )
 
 type Validator struct {
 	Aggregator *aggregator.AggregatedDatabase
-	UDC        *udc.Codec
+	Schemes    *classification.Registry
+	// Rules holds the project conventions from validation_rules.yaml
+	Rules *rules.Set
 }
 
+// ValidateEntry returns the first error found in an entry, checking the
+// shared rules only
 func (v *Validator) ValidateEntry(entry BOMEntry) error {
+	return v.ValidateProjectEntry("", entry)
+}
+
+// ValidateProjectEntry returns the first error found in an entry of a project
+func (v *Validator) ValidateProjectEntry(project string, entry BOMEntry) error {
+	for _, issue := range v.CheckEntry(project, entry) {
+		if issue.Severity == SeverityError {
+			return errors.New(issue.Message)
+		}
+	}
+	return nil
+}
+
+// CheckEntry returns every issue of a single entry of a project, with the
+// tag formatted by the default tag scheme
+func (v *Validator) CheckEntry(project string, entry BOMEntry) []Issue {
+	tag := ""
+	if entry.EquipmentID != "" {
+		tag = GenerateFullTag(entry)
+	}
+	issues := v.checkEntry(entry)
+	return append(issues, v.checkRules(project, entry, tag)...)
+}
+
+// Validate checks every entry of a BOM against every rule and collects the
+// issues instead of stopping at the first one. Tags are formatted and
+// parsed with the project's tag scheme and checked for duplicates.
+func (v *Validator) Validate(bom *ProjectBOM, scheme *assettag.TagScheme) *ValidationReport {
+	report := NewValidationReport(bom)
+	report.Rows = len(bom.Entries)
+	seen := make(map[string]int)
+	for i, entry := range bom.Entries {
+		report.Add(v.CheckRow(bom.ProjectName, i, entry, scheme).Issues(seen)...)
+	}
+	return report
+}
+
+// NewValidationReport starts an empty report for a BOM under its warning
+// policy; an unknown policy is reported as an error
+func NewValidationReport(bom *ProjectBOM) *ValidationReport {
+	policy, err := ParseWarningPolicy(bom.WarningPolicy)
+	report := &ValidationReport{Project: bom.ProjectName, Policy: policy, Issues: []Issue{}}
+	if err != nil {
+		report.Add(Issue{
+			Field:    "warning_policy",
+			Rule:     RuleWarningPolicy,
+			Severity: SeverityError,
+			Message:  err.Error(),
+			Fix:      "set warning_policy to allow or block",
+		})
+	}
+	return report
+}
+
+// RowCheck holds the issues of one BOM entry. Duplicate tags depend on the
+// rows before it, so they are added by Issues.
+type RowCheck struct {
+	Row int
+	Tag string
+	// Parsed is the tag read back with the scheme; nil when the entry has
+	// no equipment ID or the tag does not parse
+	Parsed *assettag.Tag
+
+	entryIssues []Issue
+	ruleIssues  []Issue
+}
+
+// CheckRow checks one entry of a project's BOM; index is its 0-based
+// position. Rows may be checked concurrently.
+func (v *Validator) CheckRow(project string, index int, entry BOMEntry, scheme *assettag.TagScheme) *RowCheck {
+	c := &RowCheck{Row: entry.SourceRow, entryIssues: v.checkEntry(entry)}
+	if c.Row == 0 {
+		c.Row = index + 1
+	}
+	if entry.EquipmentID != "" {
+		c.Tag = GenerateTag(entry, scheme)
+		parsed, err := scheme.Parse(c.Tag)
+		if err != nil {
+			c.entryIssues = append(c.entryIssues, Issue{
+				Field:    "tag",
+				Rule:     RuleTagFormat,
+				Severity: SeverityError,
+				Message:  err.Error(),
+				Fix:      segmentHint(scheme),
+			})
+		} else {
+			c.Parsed = parsed
+		}
+	}
+	c.ruleIssues = v.checkRules(project, entry, c.Tag)
+	return c
+}
+
+// Issues returns every issue of the row. seen maps each tag to the row it
+// was first used in and is updated, so rows must be passed in order.
+func (c *RowCheck) Issues(seen map[string]int) []Issue {
+	issues := append([]Issue(nil), c.entryIssues...)
+	if c.Parsed != nil {
+		if first, ok := seen[c.Tag]; ok {
+			issues = append(issues, Issue{
+				Field:    "tag",
+				Rule:     RuleDuplicateTag,
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("duplicate tag %s, first used in row %d", c.Tag, first),
+				Fix:      "give the instrument another equipment ID or instrument suffix",
+			})
+		} else {
+			seen[c.Tag] = c.Row
+		}
+	}
+	issues = append(issues, c.ruleIssues...)
+	for i := range issues {
+		issues[i].Row, issues[i].Tag = c.Row, c.Tag
+	}
+	return issues
+}
+
+// checkEntry runs the per-entry rules; Row and Tag are left to the caller
+func (v *Validator) checkEntry(entry BOMEntry) []Issue {
+	var issues []Issue
+	add := func(field, rule string, severity Severity, fix, format string, args ...any) {
+		issues = append(issues, Issue{Field: field, Rule: rule, Severity: severity, Message: fmt.Sprintf(format, args...), Fix: fix})
+	}
+
 	sys := v.Aggregator.LookupSystem(entry.SystemCode)
 	if sys == nil {
-		return fmt.Errorf("unknown system code: %s", entry.SystemCode)
+		add("system_code", RuleUnknownSystem, SeverityError, "use one of "+v.systemCodes(), "unknown system code: %s", entry.SystemCode)
+	} else if _, ok := sys.ISAFunction[entry.FunctionCode]; !ok {
+		add("function_code", RuleUnknownFunction, SeverityError, "use one of "+functionCodes(sys), "invalid ISA function code %s for system %s", entry.FunctionCode, sys.SystemCode)
 	}
 
-	if _, ok := sys.ISAFunction[entry.FunctionCode]; !ok {
-		return fmt.Errorf("invalid ISA function code %s for system %s", entry.FunctionCode, sys.SystemCode)
+	if entry.EquipmentID == "" {
+		add("equipment_id", RuleMissingEquipmentID, SeverityError, "set equipment_id or enable allocate_numbers", "missing equipment ID")
 	}
 
-	if entry.UDCCode != "" {
-		if _, ok := v.UDC.Lookup(entry.UDCCode); !ok {
-			return fmt.Errorf("invalid UDC code %s", entry.UDCCode)
+	if entry.UDCCode == "" {
+		add("udc_code", RuleMissingUDC, SeverityWarning, "classify the entry or run the suggest stage", "missing UDC code")
+	} else if err := v.Schemes.Validate(classification.UDC, entry.UDCCode); err != nil {
+		fix := "look the code up with udccli search"
+		if parent := v.nearestCode(classification.UDC, entry.UDCCode); parent != "" {
+			fix = "nearest valid code is " + parent
 		}
+		add("udc_code", RuleInvalidUDC, SeverityError, fix, "invalid UDC code %s", entry.UDCCode)
 	}
-	return nil
+
+	if entry.Designation != "" {
+		if err := v.checkDesignation(entry.Designation); err != nil {
+			add("designation", RuleInvalidDesignation, SeverityError, "write the designation as e.g. =A1=WP2-QA1+R101 with IEC 81346 class letters", "invalid designation %s: %v", entry.Designation, err)
+		}
+	}
+
+	schemes := make([]string, 0, len(entry.Classifications))
+	for scheme := range entry.Classifications {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	for _, scheme := range schemes {
+		code := entry.Classifications[scheme]
+		if err := v.Schemes.Validate(scheme, code); err != nil {
+			fix := ""
+			if parent := v.nearestCode(scheme, code); parent != "" {
+				fix = "nearest valid code is " + parent
+			}
+			add("classifications."+scheme, RuleInvalidClassification, SeverityError, fix, "invalid %s code %s: %v", scheme, code, err)
+		}
+	}
+
+	if entry.Description == "" {
+		add("description", RuleMissingDescription, SeverityInfo, "add a description for the tag list", "missing description")
+	}
+	return issues
+}
+
+// checkRules runs the project's rules on an entry
+func (v *Validator) checkRules(project string, entry BOMEntry, tag string) []Issue {
+	projectRules := v.Rules.For(project)
+	if len(projectRules) == 0 {
+		return nil
+	}
+	env := rules.Env{Fields: entryFields(project, entry, tag), Schemes: v.Schemes}
+	var issues []Issue
+	for _, r := range projectRules {
+		field := r.Field
+		if field == "" {
+			field = "entry"
+		}
+		ok, err := r.Check(env)
+		switch {
+		case err != nil:
+			issues = append(issues, Issue{Field: field, Rule: r.ID, Severity: SeverityError, Message: fmt.Sprintf("rule %s could not be evaluated: %v", r.ID, err), Fix: "correct the rule in " + rules.RulesFile})
+		case !ok:
+			issues = append(issues, Issue{Field: field, Rule: r.ID, Severity: Severity(r.Severity), Message: r.Violation(), Fix: r.Fix})
+		}
+	}
+	return issues
+}
+
+// entryFields returns the values rules can refer to
+func entryFields(project string, entry BOMEntry, tag string) map[string]string {
+	fields := map[string]string{
+		"project":       project,
+		"tag":           tag,
+		"system_code":   entry.SystemCode,
+		"function_code": entry.FunctionCode,
+		"equipment_id":  entry.EquipmentID,
+		"instrument_id": entry.InstrumentID,
+		"udc_code":      entry.UDCCode,
+		"description":   entry.Description,
+		"designation":   entry.Designation,
+		"unit":          entry.Unit,
+	}
+	for name, value := range entry.Segments {
+		fields["segments."+name] = value
+	}
+	for scheme, code := range entry.Classifications {
+		fields["classifications."+scheme] = code
+	}
+	return fields
+}
+
+// checkDesignation parses a designation and validates its classes
+func (v *Validator) checkDesignation(designation string) error {
+	d, err := assettag.ParseDesignation(designation)
+	if err != nil {
+		return err
+	}
+	classes, err := v.Schemes.Scheme(classification.IEC81346)
+	if err != nil {
+		return err
+	}
+	return d.Validate(classes)
+}
+
+// nearestCode shortens a code until it is valid in a scheme, returning ""
+// when no prefix is
+func (v *Validator) nearestCode(scheme, code string) string {
+	for i := len(code) - 1; i > 0; i-- {
+		prefix := strings.TrimRight(code[:i], ".:-/ ")
+		if prefix == "" {
+			break
+		}
+		if v.Schemes.Validate(scheme, prefix) == nil {
+			return prefix
+		}
+	}
+	return ""
+}
+
+// systemCodes lists the known system codes
+func (v *Validator) systemCodes() string {
+	codes := make([]string, 0, len(v.Aggregator.Systems))
+	for _, sys := range v.Aggregator.Systems {
+		codes = append(codes, sys.SystemCode)
+	}
+	sort.Strings(codes)
+	return strings.Join(codes, ", ")
+}
+
+// functionCodes lists the ISA functions allowed in a system
+func functionCodes(sys *aggregator.AggregatedSystem) string {
+	codes := make([]string, 0, len(sys.ISAFunction))
+	for code := range sys.ISAFunction {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return strings.Join(codes, ", ")
+}
+
+// segmentHint describes the segments a tag scheme expects
+func segmentHint(scheme *assettag.TagScheme) string {
+	names := make([]string, 0, len(scheme.Segments))
+	for _, seg := range scheme.Segments {
+		names = append(names, seg.Name)
+	}
+	hint := fmt.Sprintf("check the %s segments of tag scheme %s", strings.Join(names, ", "), scheme.Name)
+	if scheme.Example != "" {
+		hint += ", e.g. " + scheme.Example
+	}
+	return hint
 }
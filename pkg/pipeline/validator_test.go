@@ -0,0 +1,183 @@
+package pipeline
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/thornzero/udc_codec/pkg/aggregator"
+	"github.com/thornzero/udc_codec/pkg/assettag"
+	"github.com/thornzero/udc_codec/pkg/classification"
+	"github.com/thornzero/udc_codec/pkg/rules"
+)
+
+func testValidator() *Validator {
+	return &Validator{
+		Aggregator: &aggregator.AggregatedDatabase{Systems: []aggregator.AggregatedSystem{
+			{SystemCode: "POL", SystemName: "Polyol System", ISAFunction: map[string]string{"LT": "Level Transmitter", "PT": "Pressure Transmitter"}},
+		}},
+		Schemes: classification.NewRegistry(
+			classification.NewTable(classification.UDC, map[string]string{"681.2": "Instruments", "681.21": "Level instruments"}),
+		),
+	}
+}
+
+func TestValidateCollectsAllIssues(t *testing.T) {
+	bom := &ProjectBOM{ProjectName: "demo", Entries: []BOMEntry{
+		{SystemCode: "POL", FunctionCode: "LT", EquipmentID: "1001", UDCCode: "681.21", Description: "Tank level"},
+		{SystemCode: "XYZ", FunctionCode: "LT", EquipmentID: "1002", UDCCode: "681.219", Description: "Bad system"},
+		{SystemCode: "POL", FunctionCode: "QQ", EquipmentID: "", SourceRow: 7},
+		{SystemCode: "POL", FunctionCode: "LT", EquipmentID: "1001", UDCCode: "681.21", Description: "Copy"},
+	}}
+	report := testValidator().Validate(bom, assettag.DefaultTagScheme())
+
+	if report.Rows != 4 {
+		t.Errorf("Expected 4 rows, got %d", report.Rows)
+	}
+	byRule := make(map[string]Issue)
+	for _, i := range report.Issues {
+		byRule[i.Rule] = i
+	}
+	for _, rule := range []string{RuleUnknownSystem, RuleInvalidUDC, RuleUnknownFunction, RuleMissingEquipmentID, RuleMissingUDC, RuleMissingDescription, RuleDuplicateTag} {
+		if _, ok := byRule[rule]; !ok {
+			t.Errorf("Expected an issue for rule %s, got %+v", rule, report.Issues)
+		}
+	}
+	if i := byRule[RuleUnknownSystem]; i.Row != 2 || i.Field != "system_code" || i.Fix != "use one of POL" {
+		t.Errorf("Unexpected unknown system issue: %+v", i)
+	}
+	if i := byRule[RuleInvalidUDC]; i.Fix != "nearest valid code is 681.21" {
+		t.Errorf("Expected the nearest valid UDC code as fix, got %q", i.Fix)
+	}
+	if i := byRule[RuleUnknownFunction]; i.Row != 7 || i.Fix != "use one of LT, PT" {
+		t.Errorf("Expected the source row and allowed functions, got %+v", i)
+	}
+	if i := byRule[RuleDuplicateTag]; i.Row != 4 || i.Tag != "POL-LT1001" || !strings.Contains(i.Message, "row 1") {
+		t.Errorf("Unexpected duplicate tag issue: %+v", i)
+	}
+	if i := byRule[RuleMissingUDC]; i.Severity != SeverityWarning {
+		t.Errorf("Expected a missing UDC code to be a warning, got %s", i.Severity)
+	}
+	if !report.Blocks() {
+		t.Error("Expected errors to block the export")
+	}
+}
+
+func TestValidateWarningPolicy(t *testing.T) {
+	bom := &ProjectBOM{ProjectName: "demo", Entries: []BOMEntry{
+		{SystemCode: "POL", FunctionCode: "PT", EquipmentID: "2001", Description: "Pump discharge"},
+	}}
+	v := testValidator()
+
+	report := v.Validate(bom, assettag.DefaultTagScheme())
+	if report.Count(SeverityError) != 0 || report.Count(SeverityWarning) != 1 {
+		t.Fatalf("Expected one warning, got %+v", report.Issues)
+	}
+	if report.Blocks() {
+		t.Error("Expected warnings not to block by default")
+	}
+
+	bom.WarningPolicy = "block"
+	if !v.Validate(bom, assettag.DefaultTagScheme()).Blocks() {
+		t.Error("Expected warnings to block with policy block")
+	}
+
+	bom.WarningPolicy = "sometimes"
+	report = v.Validate(bom, assettag.DefaultTagScheme())
+	if report.Count(SeverityError) != 1 || report.Issues[0].Rule != RuleWarningPolicy {
+		t.Errorf("Expected an error for an unknown policy, got %+v", report.Issues)
+	}
+}
+
+func TestValidateEntryReturnsFirstError(t *testing.T) {
+	v := testValidator()
+	if err := v.ValidateEntry(BOMEntry{SystemCode: "POL", FunctionCode: "LT", EquipmentID: "1001"}); err != nil {
+		t.Errorf("Expected warnings and info not to fail, got %v", err)
+	}
+	err := v.ValidateEntry(BOMEntry{SystemCode: "POL", FunctionCode: "XX", EquipmentID: "1001"})
+	if err == nil || err.Error() != "invalid ISA function code XX for system POL" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestValidationReportRendering(t *testing.T) {
+	report := &ValidationReport{Project: "demo", Policy: WarningsAllow, Rows: 1, Issues: []Issue{
+		{Row: 3, Tag: "POL-LT1001", Field: "udc_code", Rule: RuleInvalidUDC, Severity: SeverityError, Message: "invalid UDC code 681.219", Fix: "nearest valid code is 681.21, or <search>"},
+	}}
+
+	var buf bytes.Buffer
+	if err := WriteValidationCSV(&buf, report); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[1][0] != "3" || records[1][6] != "nearest valid code is 681.21, or <search>" {
+		t.Errorf("Unexpected CSV: %v", records)
+	}
+
+	buf.Reset()
+	if err := WriteValidationHTML(&buf, report); err != nil {
+		t.Fatal(err)
+	}
+	html := buf.String()
+	if !strings.Contains(html, "1 error, 0 warnings, 0 info") || !strings.Contains(html, "&lt;search&gt;") {
+		t.Errorf("Unexpected HTML: %s", html)
+	}
+
+	filename := filepath.Join(t.TempDir(), "demo_validation.json")
+	if err := ExportValidationReport(report, filename); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadValidationReport(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Issues) != 1 || loaded.Issues[0] != report.Issues[0] || loaded.Policy != WarningsAllow {
+		t.Errorf("Expected the report to round-trip, got %+v", loaded)
+	}
+}
+
+func TestValidateRunsProjectRules(t *testing.T) {
+	ruleSet, err := rules.NewSet(
+		[]*rules.Rule{{ID: "short-description", Require: "len(description) <= 10", Field: "description", Severity: "warning"}},
+		map[string][]*rules.Rule{"demo": {{
+			ID:      "lt-udc",
+			When:    `function_code == "LT"`,
+			Require: `descendantOf(udc_code, "681.21")`,
+			Field:   "udc_code",
+			Fix:     "classify level transmitters under 681.21",
+		}}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := testValidator()
+	v.Rules = ruleSet
+
+	bom := &ProjectBOM{ProjectName: "demo", Entries: []BOMEntry{
+		{SystemCode: "POL", FunctionCode: "LT", EquipmentID: "1001", UDCCode: "681.2", Description: "Reactor level"},
+		{SystemCode: "POL", FunctionCode: "PT", EquipmentID: "1002", UDCCode: "681.2", Description: "Feed"},
+	}}
+	report := v.Validate(bom, assettag.DefaultTagScheme())
+	var got []string
+	for _, i := range report.Issues {
+		got = append(got, fmt.Sprintf("%d %s %s %s", i.Row, i.Rule, i.Severity, i.Field))
+	}
+	want := []string{"1 short-description warning description", "1 lt-udc error udc_code"}
+	if strings.Join(got, "; ") != strings.Join(want, "; ") {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	err = v.ValidateProjectEntry("demo", bom.Entries[0])
+	if err == nil || err.Error() != "rule lt-udc failed: descendantOf(udc_code, \"681.21\")" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := v.ValidateEntry(bom.Entries[0]); err != nil {
+		t.Errorf("Expected project rules not to apply without a project, got %v", err)
+	}
+}
@@ -0,0 +1,585 @@
+package rules
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/thornzero/udc_codec/pkg/classification"
+)
+
+// Env is what an expression is evaluated against
+type Env struct {
+	// Fields holds the entry's values by field name, e.g. system_code
+	Fields map[string]string
+	// Schemes resolves descendantOf
+	Schemes *classification.Registry
+}
+
+// Fields rules can refer to; segments.<name> and classifications.<scheme>
+// are accepted as well
+var knownFields = map[string]bool{
+	"project":       true,
+	"tag":           true,
+	"system_code":   true,
+	"function_code": true,
+	"equipment_id":  true,
+	"instrument_id": true,
+	"udc_code":      true,
+	"description":   true,
+	"designation":   true,
+	"unit":          true,
+}
+
+// Expr is a compiled expression
+type Expr struct {
+	source string
+	root   node
+}
+
+// String returns the expression as written
+func (e *Expr) String() string {
+	return e.source
+}
+
+// Eval evaluates the expression to a boolean
+func (e *Expr) Eval(env Env) (bool, error) {
+	v, err := e.root.eval(env)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("%s is not a condition", e.source)
+	}
+	return b, nil
+}
+
+// Compile parses an expression such as
+//
+//	function_code in ["FCV", "FV"] && descendantOf(udc_code, "621.646")
+//
+// Values are strings, numbers, booleans and lists. Operators are ==, !=, <,
+// <=, >, >=, in, &&, || and !; functions are len, matches, startsWith and
+// descendantOf.
+func Compile(source string) (*Expr, error) {
+	tokens, err := lex(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %q: %w", source, err)
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.or()
+	if err == nil && p.peek().kind != tokEOF {
+		err = fmt.Errorf("unexpected %s", p.peek())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %q: %w", source, err)
+	}
+	return &Expr{source: source, root: root}, nil
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of expression"
+	case tokString:
+		return strconv.Quote(t.text)
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// lex splits an expression into tokens
+func lex(s string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(s); {
+		c := rune(s[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '"' || c == '\'':
+			j := i + 1
+			var b strings.Builder
+			for ; j < len(s) && rune(s[j]) != c; j++ {
+				if s[j] == '\\' && j+1 < len(s) {
+					j++
+				}
+				b.WriteByte(s[j])
+			}
+			if j >= len(s) {
+				return nil, fmt.Errorf("unterminated string at %d", i)
+			}
+			tokens = append(tokens, token{tokString, b.String()})
+			i = j + 1
+		case unicode.IsDigit(c):
+			j := i
+			for j < len(s) && (unicode.IsDigit(rune(s[j])) || s[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, token{tokNumber, s[i:j]})
+			i = j
+		case unicode.IsLetter(c) || c == '_':
+			j := i
+			for j < len(s) && (unicode.IsLetter(rune(s[j])) || unicode.IsDigit(rune(s[j])) || s[j] == '_' || s[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, token{tokIdent, s[i:j]})
+			i = j
+		default:
+			op := ""
+			for _, candidate := range []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "!", "(", ")", "[", "]", ","} {
+				if strings.HasPrefix(s[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected character %q at %d", c, i)
+			}
+			tokens = append(tokens, token{tokOp, op})
+			i += len(op)
+		}
+	}
+	return append(tokens, token{kind: tokEOF}), nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// accept consumes an operator or keyword if it is next
+func (p *parser) accept(text string) bool {
+	if t := p.peek(); (t.kind == tokOp || t.kind == tokIdent) && t.text == text {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(text string) error {
+	if !p.accept(text) {
+		return fmt.Errorf("expected %q, got %s", text, p.peek())
+	}
+	return nil
+}
+
+func (p *parser) or() (node, error) {
+	left, err := p.and()
+	for err == nil && p.accept("||") {
+		var right node
+		if right, err = p.and(); err == nil {
+			left = logical{op: "||", left: left, right: right}
+		}
+	}
+	return left, err
+}
+
+func (p *parser) and() (node, error) {
+	left, err := p.not()
+	for err == nil && p.accept("&&") {
+		var right node
+		if right, err = p.not(); err == nil {
+			left = logical{op: "&&", left: left, right: right}
+		}
+	}
+	return left, err
+}
+
+func (p *parser) not() (node, error) {
+	if p.accept("!") {
+		operand, err := p.not()
+		return not{operand}, err
+	}
+	return p.comparison()
+}
+
+func (p *parser) comparison() (node, error) {
+	left, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">", "in"} {
+		if p.accept(op) {
+			right, err := p.primary()
+			if err != nil {
+				return nil, err
+			}
+			return compare{op: op, left: left, right: right}, nil
+		}
+	}
+	return left, nil
+}
+
+func (p *parser) primary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokString:
+		return literal{t.text}, nil
+	case tokNumber:
+		n, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %s", t.text)
+		}
+		return literal{n}, nil
+	case tokIdent:
+		switch t.text {
+		case "true":
+			return literal{true}, nil
+		case "false":
+			return literal{false}, nil
+		}
+		if p.accept("(") {
+			return p.call(t.text)
+		}
+		if !knownFields[t.text] && !strings.HasPrefix(t.text, "segments.") && !strings.HasPrefix(t.text, "classifications.") {
+			return nil, fmt.Errorf("unknown field %s", t.text)
+		}
+		return field{t.text}, nil
+	case tokOp:
+		switch t.text {
+		case "(":
+			inner, err := p.or()
+			if err != nil {
+				return nil, err
+			}
+			return inner, p.expect(")")
+		case "[":
+			var items list
+			for !p.accept("]") {
+				if len(items) > 0 {
+					if err := p.expect(","); err != nil {
+						return nil, err
+					}
+				}
+				item, err := p.primary()
+				if err != nil {
+					return nil, err
+				}
+				items = append(items, item)
+			}
+			return items, nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected %s", t)
+}
+
+// call parses the arguments of a function and checks them
+func (p *parser) call(name string) (node, error) {
+	var args []node
+	for !p.accept(")") {
+		if len(args) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		arg, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+
+	arity := map[string]int{"len": 1, "matches": 2, "startsWith": 2, "descendantOf": 2}
+	n, ok := arity[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %s", name)
+	}
+	if len(args) != n {
+		return nil, fmt.Errorf("%s takes %d arguments, got %d", name, n, len(args))
+	}
+
+	switch name {
+	case "matches":
+		pattern, ok := args[1].(literal)
+		s, isString := pattern.value.(string)
+		if !ok || !isString {
+			return nil, fmt.Errorf("matches needs a string pattern")
+		}
+		re, err := regexp.Compile(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", s, err)
+		}
+		return matches{arg: args[0], re: re}, nil
+	case "descendantOf":
+		f, ok := args[0].(field)
+		if !ok {
+			return nil, fmt.Errorf("descendantOf needs a code field, e.g. udc_code")
+		}
+		scheme := schemeOf(f.name)
+		if scheme == "" {
+			return nil, fmt.Errorf("descendantOf needs udc_code or classifications.<scheme>, got %s", f.name)
+		}
+		return descendantOf{field: f, scheme: scheme, ancestor: args[1]}, nil
+	}
+	return function{name: name, args: args}, nil
+}
+
+// schemeOf returns the classification scheme of a code field
+func schemeOf(name string) string {
+	if name == "udc_code" {
+		return classification.UDC
+	}
+	if scheme, ok := strings.CutPrefix(name, "classifications."); ok {
+		return scheme
+	}
+	return ""
+}
+
+type node interface {
+	eval(env Env) (any, error)
+}
+
+type literal struct{ value any }
+
+func (n literal) eval(Env) (any, error) { return n.value, nil }
+
+type field struct{ name string }
+
+func (n field) eval(env Env) (any, error) { return env.Fields[n.name], nil }
+
+type list []node
+
+func (n list) eval(env Env) (any, error) {
+	values := make([]any, len(n))
+	for i, item := range n {
+		v, err := item.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+type not struct{ operand node }
+
+func (n not) eval(env Env) (any, error) {
+	b, err := evalBool(n.operand, env)
+	return !b, err
+}
+
+type logical struct {
+	op          string
+	left, right node
+}
+
+func (n logical) eval(env Env) (any, error) {
+	left, err := evalBool(n.left, env)
+	if err != nil {
+		return nil, err
+	}
+	// Short-circuit so that the right side can rely on the left
+	if n.op == "&&" && !left || n.op == "||" && left {
+		return left, nil
+	}
+	return evalBool(n.right, env)
+}
+
+type compare struct {
+	op          string
+	left, right node
+}
+
+func (n compare) eval(env Env) (any, error) {
+	left, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "in" {
+		items, ok := right.([]any)
+		if !ok {
+			return nil, fmt.Errorf("in needs a list")
+		}
+		for _, item := range items {
+			if c, err := order(left, item); err == nil && c == 0 {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	c, err := order(left, right)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "==":
+		return c == 0, nil
+	case "!=":
+		return c != 0, nil
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	}
+	return c >= 0, nil
+}
+
+// order compares two values of the same type. Numbers compare with
+// strings that hold numbers, so equipment_id >= 1000 works.
+func order(a, b any) (int, error) {
+	switch x := a.(type) {
+	case string:
+		if y, ok := b.(float64); ok {
+			n, err := strconv.ParseFloat(x, 64)
+			if err != nil {
+				return 0, fmt.Errorf("%q is not a number", x)
+			}
+			return order(n, y)
+		}
+		if y, ok := b.(string); ok {
+			return strings.Compare(x, y), nil
+		}
+	case float64:
+		if y, ok := b.(string); ok {
+			c, err := order(y, x)
+			return -c, err
+		}
+		if y, ok := b.(float64); ok {
+			switch {
+			case x < y:
+				return -1, nil
+			case x > y:
+				return 1, nil
+			}
+			return 0, nil
+		}
+	case bool:
+		if y, ok := b.(bool); ok {
+			if x == y {
+				return 0, nil
+			}
+			return 1, nil
+		}
+	}
+	return 0, fmt.Errorf("cannot compare %v with %v", a, b)
+}
+
+type function struct {
+	name string
+	args []node
+}
+
+func (n function) eval(env Env) (any, error) {
+	var args []string
+	for _, arg := range n.args {
+		s, err := evalString(arg, env)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, s)
+	}
+	switch n.name {
+	case "len":
+		return float64(len([]rune(args[0]))), nil
+	case "startsWith":
+		return strings.HasPrefix(args[0], args[1]), nil
+	}
+	return nil, fmt.Errorf("unknown function %s", n.name)
+}
+
+type matches struct {
+	arg node
+	re  *regexp.Regexp
+}
+
+func (n matches) eval(env Env) (any, error) {
+	s, err := evalString(n.arg, env)
+	if err != nil {
+		return nil, err
+	}
+	return n.re.MatchString(s), nil
+}
+
+// descendantOf is true when a code is the ancestor or lies below it in
+// the code's scheme
+type descendantOf struct {
+	field    field
+	scheme   string
+	ancestor node
+}
+
+func (n descendantOf) eval(env Env) (any, error) {
+	code := env.Fields[n.field.name]
+	ancestor, err := evalString(n.ancestor, env)
+	if err != nil || code == "" {
+		return false, err
+	}
+	if env.Schemes == nil {
+		return nil, fmt.Errorf("descendantOf needs classification schemes")
+	}
+	scheme, ok := env.Schemes.Get(n.scheme)
+	if !ok {
+		return nil, fmt.Errorf("unknown classification scheme %s", n.scheme)
+	}
+	classes, ok := scheme.Ancestry(code)
+	if !ok {
+		return false, nil
+	}
+	for _, c := range classes {
+		if c.Code == ancestor {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func evalBool(n node, env Env) (bool, error) {
+	v, err := n.eval(env)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("%v is not a condition", v)
+	}
+	return b, nil
+}
+
+func evalString(n node, env Env) (string, error) {
+	v, err := n.eval(env)
+	if err != nil {
+		return "", err
+	}
+	switch x := v.(type) {
+	case string:
+		return x, nil
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64), nil
+	}
+	return "", fmt.Errorf("%v is not text", v)
+}
@@ -0,0 +1,199 @@
+package rules
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RulesFile is the file in the data directory holding the validation rules
+const RulesFile = "validation_rules.yaml"
+
+// Rule severities
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+// Rule is one convention. Entries matching When must satisfy Require.
+type Rule struct {
+	ID          string `json:"id" yaml:"id"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// When selects the entries the rule applies to; empty selects all
+	When string `json:"when,omitempty" yaml:"when,omitempty"`
+	// Require must hold for the selected entries
+	Require string `json:"require" yaml:"require"`
+	// Field is the field reported for a violation
+	Field string `json:"field,omitempty" yaml:"field,omitempty"`
+	// Severity is error (the default), warning or info
+	Severity string `json:"severity,omitempty" yaml:"severity,omitempty"`
+	Message  string `json:"message,omitempty" yaml:"message,omitempty"`
+	Fix      string `json:"fix,omitempty" yaml:"fix,omitempty"`
+	// Disabled switches off a shared rule of the same ID for a project
+	Disabled bool `json:"disabled,omitempty" yaml:"disabled,omitempty"`
+
+	when, require *Expr
+}
+
+// compile parses the rule's expressions and fills defaults
+func (r *Rule) compile() error {
+	if r.ID == "" {
+		return fmt.Errorf("rule without an id")
+	}
+	if r.Disabled {
+		return nil
+	}
+	switch r.Severity {
+	case "":
+		r.Severity = SeverityError
+	case SeverityError, SeverityWarning, SeverityInfo:
+	default:
+		return fmt.Errorf("rule %s: unknown severity %q", r.ID, r.Severity)
+	}
+	if r.Require == "" {
+		return fmt.Errorf("rule %s has no require expression", r.ID)
+	}
+	var err error
+	if r.require, err = Compile(r.Require); err != nil {
+		return fmt.Errorf("rule %s: %w", r.ID, err)
+	}
+	if r.When != "" {
+		if r.when, err = Compile(r.When); err != nil {
+			return fmt.Errorf("rule %s: %w", r.ID, err)
+		}
+	}
+	return nil
+}
+
+// Check returns whether an entry satisfies the rule; entries the rule does
+// not apply to always do
+func (r *Rule) Check(env Env) (bool, error) {
+	if r.when != nil {
+		applies, err := r.when.Eval(env)
+		if err != nil || !applies {
+			return true, err
+		}
+	}
+	return r.require.Eval(env)
+}
+
+// Violation returns the message for an entry that fails the rule
+func (r *Rule) Violation() string {
+	switch {
+	case r.Message != "":
+		return r.Message
+	case r.Description != "":
+		return r.Description
+	}
+	return fmt.Sprintf("rule %s failed: %s", r.ID, r.Require)
+}
+
+// Set holds the shared rules and the rules of each project
+type Set struct {
+	Rules    []*Rule            `json:"rules" yaml:"rules"`
+	Projects map[string][]*Rule `json:"projects,omitempty" yaml:"projects,omitempty"`
+}
+
+// NewSet compiles shared and per-project rules
+func NewSet(shared []*Rule, projects map[string][]*Rule) (*Set, error) {
+	s := &Set{Rules: shared, Projects: projects}
+	if err := s.compile(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Set) compile() error {
+	seen := make(map[string]bool)
+	for _, r := range s.Rules {
+		if err := r.compile(); err != nil {
+			return err
+		}
+		if seen[r.ID] {
+			return fmt.Errorf("duplicate rule %s", r.ID)
+		}
+		seen[r.ID] = true
+	}
+	for project, rules := range s.Projects {
+		seen := make(map[string]bool)
+		for _, r := range rules {
+			if err := r.compile(); err != nil {
+				return fmt.Errorf("project %s: %w", project, err)
+			}
+			if seen[r.ID] {
+				return fmt.Errorf("project %s: duplicate rule %s", project, r.ID)
+			}
+			seen[r.ID] = true
+		}
+	}
+	return nil
+}
+
+// Load reads a rules file
+func Load(filename string) (*Set, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filename, err)
+	}
+	var s Set
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+	if err := s.compile(); err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", filename, err)
+	}
+	return &s, nil
+}
+
+// LoadDir loads validation_rules.yaml from the data directory; without the
+// file there are no rules
+func LoadDir(dataDir string) (*Set, error) {
+	filename := filepath.Join(dataDir, RulesFile)
+	if _, err := os.Stat(filename); err != nil {
+		return &Set{}, nil
+	}
+	return Load(filename)
+}
+
+// For returns the rules that apply to a project: the shared rules, with
+// project rules of the same ID replacing or disabling them, followed by the
+// project's own rules
+func (s *Set) For(project string) []*Rule {
+	if s == nil {
+		return nil
+	}
+	overrides := make(map[string]*Rule)
+	for _, r := range s.Projects[project] {
+		overrides[r.ID] = r
+	}
+	var rules []*Rule
+	for _, r := range s.Rules {
+		if o, ok := overrides[r.ID]; ok {
+			r = o
+			delete(overrides, o.ID)
+		}
+		if !r.Disabled {
+			rules = append(rules, r)
+		}
+	}
+	for _, r := range s.Projects[project] {
+		if _, ok := overrides[r.ID]; ok && !r.Disabled {
+			rules = append(rules, r)
+		}
+	}
+	return rules
+}
+
+// ProjectNames returns the projects with their own rules in sorted order
+func (s *Set) ProjectNames() []string {
+	names := make([]string, 0, len(s.Projects))
+	for name := range s.Projects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
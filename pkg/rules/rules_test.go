@@ -0,0 +1,182 @@
+package rules
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/thornzero/udc_codec/pkg/classification"
+)
+
+func testSchemes(t *testing.T) *classification.Registry {
+	t.Helper()
+	udc, err := classification.NewTree(classification.UDC, []*classification.TreeNode{
+		{Code: "621", Title: "Mechanical engineering", Children: []*classification.TreeNode{
+			{Code: "621.646", Title: "Valves", Children: []*classification.TreeNode{
+				{Code: "621.646.2", Title: "Control valves"},
+			}},
+			{Code: "621.65", Title: "Pumps"},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return classification.NewRegistry(udc)
+}
+
+func TestCompileAndEval(t *testing.T) {
+	env := Env{
+		Fields: map[string]string{
+			"system_code":   "POL",
+			"function_code": "FCV",
+			"equipment_id":  "1001",
+			"udc_code":      "621.646.2",
+			"description":   "Reactor feed valve",
+			"segments.area": "21",
+		},
+		Schemes: testSchemes(t),
+	}
+
+	for expr, want := range map[string]bool{
+		`function_code == "FCV"`:                           true,
+		`function_code != 'FCV'`:                           false,
+		`system_code in ["POL", "ISO"]`:                    true,
+		`!(system_code in ["ISO"])`:                        true,
+		`len(description) <= 40`:                           true,
+		`len(description) > 40 || equipment_id >= 1000`:    true,
+		`equipment_id < 1000 && undefinedIsNotEvaluated()`: false,
+		`matches(description, "(?i)^reactor")`:             true,
+		`startsWith(udc_code, "621.6")`:                    true,
+		`descendantOf(udc_code, "621.646")`:                true,
+		`descendantOf(udc_code, "621.646.2")`:              true,
+		`descendantOf(udc_code, "621.65")`:                 false,
+		`segments.area == "21" && unit == ""`:              true,
+	} {
+		if strings.Contains(expr, "undefined") {
+			// Unknown functions are rejected when compiling
+			if _, err := Compile(expr); err == nil {
+				t.Errorf("Expected %s to fail to compile", expr)
+			}
+			continue
+		}
+		e, err := Compile(expr)
+		if err != nil {
+			t.Errorf("Failed to compile %s: %v", expr, err)
+			continue
+		}
+		got, err := e.Eval(env)
+		if err != nil {
+			t.Errorf("Failed to evaluate %s: %v", expr, err)
+		} else if got != want {
+			t.Errorf("Expected %s to be %v, got %v", expr, want, got)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, expr := range []string{
+		`system == "POL"`,
+		`function_code ==`,
+		`matches(description, "(")`,
+		`descendantOf(description, "621")`,
+		`len(description, 2) > 1`,
+		`"unterminated`,
+		`system_code == "POL" extra`,
+	} {
+		if _, err := Compile(expr); err == nil {
+			t.Errorf("Expected an error compiling %s", expr)
+		}
+	}
+
+	// Type mismatches are reported when evaluating
+	e, err := Compile(`description > 3`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.Eval(Env{Fields: map[string]string{"description": "valve"}}); err == nil {
+		t.Error("Expected an error comparing text with a number")
+	}
+}
+
+func TestSetForProject(t *testing.T) {
+	dir := t.TempDir()
+	content := `
+rules:
+  - id: description-length
+    require: len(description) <= 40
+    severity: warning
+  - id: has-udc
+    require: udc_code != ""
+projects:
+  alpha:
+    - id: description-length
+      require: len(description) <= 60
+    - id: pit-systems
+      when: function_code == "PIT"
+      require: system_code in ["POL", "ISO"]
+  beta:
+    - id: has-udc
+      disabled: true
+`
+	if err := os.WriteFile(filepath.Join(dir, RulesFile), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	set, err := LoadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := func(rules []*Rule) string {
+		var s []string
+		for _, r := range rules {
+			s = append(s, r.ID)
+		}
+		return strings.Join(s, ",")
+	}
+	if got := ids(set.For("")); got != "description-length,has-udc" {
+		t.Errorf("Expected the shared rules, got %s", got)
+	}
+	alpha := set.For("alpha")
+	if got := ids(alpha); got != "description-length,has-udc,pit-systems" {
+		t.Errorf("Expected alpha's rules, got %s", got)
+	}
+	if alpha[0].Require != "len(description) <= 60" || alpha[0].Severity != SeverityError {
+		t.Errorf("Expected alpha's description rule to replace the shared one, got %+v", alpha[0])
+	}
+	if got := ids(set.For("beta")); got != "description-length" {
+		t.Errorf("Expected has-udc to be disabled for beta, got %s", got)
+	}
+
+	env := Env{Fields: map[string]string{"function_code": "PIT", "system_code": "WAT"}}
+	if ok, err := alpha[2].Check(env); err != nil || ok {
+		t.Errorf("Expected pit-systems to fail for WAT, got %v %v", ok, err)
+	}
+	env.Fields["function_code"] = "LT"
+	if ok, err := alpha[2].Check(env); err != nil || !ok {
+		t.Errorf("Expected pit-systems not to apply to LT, got %v %v", ok, err)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+	if set, err := LoadDir(dir); err != nil || len(set.For("any")) != 0 {
+		t.Errorf("Expected no rules without a file, got %v", err)
+	}
+
+	for _, content := range []string{
+		"rules:\n  - require: 'udc_code != \"\"'\n",
+		"rules:\n  - id: a\n",
+		"rules:\n  - id: a\n    require: 'udc_code ='\n",
+		"rules:\n  - id: a\n    require: 'true'\n    severity: fatal\n",
+		"rules:\n  - id: a\n    require: 'true'\n  - id: a\n    require: 'true'\n",
+	} {
+		filename := filepath.Join(dir, RulesFile)
+		if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(filename); err == nil {
+			t.Errorf("Expected an error loading %q", content)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package stages
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/thornzero/udc_codec/pkg/aggregator"
+	"github.com/thornzero/udc_codec/pkg/assettag"
+	"github.com/thornzero/udc_codec/pkg/bomimport"
+	"github.com/thornzero/udc_codec/pkg/config"
+	"github.com/thornzero/udc_codec/pkg/crosswalk"
+	"github.com/thornzero/udc_codec/pkg/db"
+	"github.com/thornzero/udc_codec/pkg/dictionary"
+	"github.com/thornzero/udc_codec/pkg/pipeline"
+	"github.com/thornzero/udc_codec/pkg/rules"
+	"github.com/thornzero/udc_codec/pkg/suggest"
+)
+
+// lazy loads a value on first use and keeps it, or the error
+type lazy[T any] struct {
+	mu     sync.Mutex
+	value  T
+	err    error
+	loaded bool
+}
+
+func (l *lazy[T]) get(load func() (T, error)) (T, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.loaded {
+		l.value, l.err = load()
+		l.loaded = true
+	}
+	return l.value, l.err
+}
+
+// Env holds the reference data and stores the stages share. Each is
+// loaded when a stage first needs it, so a pipeline without a classify
+// stage never reads the dictionary. An Env is safe for concurrent use.
+type Env struct {
+	Config *config.Config
+	// Exporters holds the tag list formats the export stages can write
+	Exporters *pipeline.Exporters
+
+	resolver   lazy[*assettag.Resolver]
+	aggregated lazy[*aggregator.AggregatedDatabase]
+	crosswalks lazy[*crosswalk.Crosswalk]
+	tagSchemes lazy[*assettag.TagSchemes]
+	profiles   lazy[*bomimport.Profiles]
+	rules      lazy[*rules.Set]
+	store      lazy[*db.Store]
+	validator  lazy[*pipeline.Validator]
+	classifier lazy[*suggest.Classifier]
+}
+
+// NewEnv creates an environment reading from the configured data directory
+func NewEnv(cfg *config.Config) *Env {
+	return &Env{Config: cfg, Exporters: pipeline.DefaultExporters()}
+}
+
+// Path returns a file in the data directory
+func (e *Env) Path(name string) string {
+	return filepath.Join(e.Config.DataDir, name)
+}
+
+// Resolver loads and checks the classification schemes
+func (e *Env) Resolver() (*assettag.Resolver, error) {
+	return e.resolver.get(func() (*assettag.Resolver, error) {
+		return assettag.NewResolver(e.Config)
+	})
+}
+
+// Aggregator loads the aggregated systems
+func (e *Env) Aggregator() (*aggregator.AggregatedDatabase, error) {
+	return e.aggregated.get(func() (*aggregator.AggregatedDatabase, error) {
+		return aggregator.LoadAggregatedDatabase(e.Path("aggregated_master.yaml"))
+	})
+}
+
+// Crosswalks loads the crosswalks to ECLASS and UNSPSC
+func (e *Env) Crosswalks() (*crosswalk.Crosswalk, error) {
+	return e.crosswalks.get(func() (*crosswalk.Crosswalk, error) {
+		return crosswalk.Load(e.Config.DataDir)
+	})
+}
+
+// TagSchemes loads the tag schemes
+func (e *Env) TagSchemes() (*assettag.TagSchemes, error) {
+	return e.tagSchemes.get(func() (*assettag.TagSchemes, error) {
+		return assettag.LoadTagSchemesDir(e.Config.DataDir)
+	})
+}
+
+// Profiles loads the BOM column-mapping profiles
+func (e *Env) Profiles() (*bomimport.Profiles, error) {
+	return e.profiles.get(func() (*bomimport.Profiles, error) {
+		return bomimport.LoadProfilesDir(e.Config.DataDir)
+	})
+}
+
+// Rules loads the validation rules
+func (e *Env) Rules() (*rules.Set, error) {
+	return e.rules.get(func() (*rules.Set, error) {
+		return rules.LoadDir(e.Config.DataDir)
+	})
+}
+
+// Store opens and migrates the tag DB
+func (e *Env) Store() (*db.Store, error) {
+	return e.store.get(func() (*db.Store, error) {
+		store, err := db.OpenDB(e.Config.DBPath)
+		if err != nil {
+			return nil, err
+		}
+		if err := store.Migrate(); err != nil {
+			return nil, err
+		}
+		return store, nil
+	})
+}
+
+// Validator builds the validator from the schemes, systems and rules
+func (e *Env) Validator() (*pipeline.Validator, error) {
+	return e.validator.get(func() (*pipeline.Validator, error) {
+		resolver, err := e.Resolver()
+		if err != nil {
+			return nil, err
+		}
+		agg, err := e.Aggregator()
+		if err != nil {
+			return nil, err
+		}
+		ruleSet, err := e.Rules()
+		if err != nil {
+			return nil, err
+		}
+		return &pipeline.Validator{Aggregator: agg, Schemes: resolver.Schemes, Rules: ruleSet}, nil
+	})
+}
+
+// Classifier builds the UDC classifier, trained on the tags already
+// classified in the registry when there is one
+func (e *Env) Classifier() (*suggest.Classifier, error) {
+	return e.classifier.get(func() (*suggest.Classifier, error) {
+		resolver, err := e.Resolver()
+		if err != nil {
+			return nil, err
+		}
+		codec, ok := resolver.Schemes.UDCCodec()
+		if !ok {
+			return nil, fmt.Errorf("UDC data not loaded")
+		}
+		dict, err := dictionary.Load(e.Config.DataDir, e.Config.Language)
+		if err != nil {
+			return nil, err
+		}
+		classifier := suggest.New(codec, dict)
+		if _, err := os.Stat(e.Config.DBPath); err == nil {
+			store, err := e.Store()
+			if err != nil {
+				return nil, err
+			}
+			if err := classifier.TrainFromStore(store); err != nil {
+				return nil, err
+			}
+		}
+		return classifier, nil
+	})
+}
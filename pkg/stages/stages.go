@@ -0,0 +1,459 @@
+package stages
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/thornzero/udc_codec/pkg/aggregator"
+	"github.com/thornzero/udc_codec/pkg/allocator"
+	"github.com/thornzero/udc_codec/pkg/assettag"
+	"github.com/thornzero/udc_codec/pkg/bomimport"
+	"github.com/thornzero/udc_codec/pkg/config"
+	"github.com/thornzero/udc_codec/pkg/crosswalk"
+	"github.com/thornzero/udc_codec/pkg/db"
+	"github.com/thornzero/udc_codec/pkg/pipeline"
+)
+
+// progressEvery is how many entries a stage handles between progress events
+const progressEvery = 100
+
+// New returns the stage types available to pipeline definitions
+func New(env *Env) map[string]pipeline.Stage {
+	return map[string]pipeline.Stage{
+		"import":    pipeline.StageFunc(env.importBOM),
+		"normalise": pipeline.StageFunc(env.normalise),
+		"allocate":  pipeline.StageFunc(env.allocate),
+		"classify":  pipeline.StageFunc(env.classify),
+		"validate":  pipeline.StageFunc(env.validate),
+		"tag":       pipeline.StageFunc(env.tag),
+		"export":    pipeline.StageFunc(env.export),
+		"register":  pipeline.StageFunc(env.register),
+		"stream":    pipeline.StageFunc(env.stream),
+	}
+}
+
+// Run executes the pipeline definition of the data directory, or the
+// built-in default, on a run. The web upload and the autopipeline both
+// run BOMs through here.
+func Run(ctx context.Context, cfg *config.Config, run *pipeline.Run, events func(pipeline.Event)) error {
+	def, err := pipeline.LoadDefinitionDir(cfg.DataDir)
+	if err != nil {
+		return err
+	}
+	runner := &pipeline.Runner{Stages: New(NewEnv(cfg)), Events: events}
+	return runner.Run(ctx, def, run)
+}
+
+// needBOM returns an error for stages run before import
+func needBOM(run *pipeline.Run) error {
+	if run.BOM == nil || run.TagScheme == nil {
+		return fmt.Errorf("no BOM loaded; the stage needs an import stage first")
+	}
+	return nil
+}
+
+// importBOM reads the run's input as YAML, CSV or XLSX and selects the
+// BOM's tag scheme. Settings: profile.
+func (e *Env) importBOM(ctx context.Context, run *pipeline.Run, cfg pipeline.StageConfig) error {
+	if run.Input == "" {
+		return fmt.Errorf("no BOM file to import")
+	}
+	tagSchemes, err := e.TagSchemes()
+	if err != nil {
+		return err
+	}
+	profiles, err := e.Profiles()
+	if err != nil {
+		return err
+	}
+	profile, err := profiles.Get(cfg.String("profile", ""))
+	if err != nil {
+		return err
+	}
+	bom, report, err := bomimport.Load(run.Input, bomimport.Options{Profile: profile, ProjectName: run.Project, TagSchemes: tagSchemes})
+	if err != nil {
+		return err
+	}
+	for _, w := range report.Warnings {
+		run.Emit(pipeline.EventWarning, w.Error())
+	}
+	if len(report.Errors) > 0 {
+		errs := []error{report.Err()}
+		for _, e := range report.Errors {
+			errs = append(errs, e)
+		}
+		return errors.Join(errs...)
+	}
+	scheme, err := tagSchemes.Get(bom.TagScheme)
+	if err != nil {
+		return err
+	}
+	if run.Project == "" {
+		run.Project = bom.ProjectName
+	}
+	run.BOM, run.TagScheme = bom, scheme
+	run.Emit(pipeline.EventProgress, fmt.Sprintf("read %d entries from %s", len(bom.Entries), run.Input))
+	return nil
+}
+
+// normalise tidies codes typed by hand: whitespace, letter case, numbers
+// read as decimals, and the tag scheme's spelling of each tag
+func (e *Env) normalise(ctx context.Context, run *pipeline.Run, cfg pipeline.StageConfig) error {
+	if err := needBOM(run); err != nil {
+		return err
+	}
+	changed := 0
+	for i := range run.BOM.Entries {
+		if pipeline.NormaliseEntry(&run.BOM.Entries[i], run.TagScheme) {
+			changed++
+		}
+	}
+	run.Emit(pipeline.EventProgress, fmt.Sprintf("normalised %d of %d entries", changed, len(run.BOM.Entries)))
+	return nil
+}
+
+// allocate assigns loop numbers to entries without an equipment ID from the
+// ranges in tag_numbering.yaml. It runs when enabled or when the BOM sets
+// allocate_numbers. Entries of an earlier revision of the BOM, if given,
+// keep their numbers. The numbers are taken back if the run fails.
+// Settings: enabled, previous (BOM file of the earlier revision).
+func (e *Env) allocate(ctx context.Context, run *pipeline.Run, cfg pipeline.StageConfig) error {
+	if err := needBOM(run); err != nil {
+		return err
+	}
+	enabled, err := cfg.Bool("enabled", false)
+	if err != nil {
+		return err
+	}
+	if !enabled && !run.BOM.AllocateNumbers {
+		return pipeline.Skip("allocation not enabled")
+	}
+	numbering, err := allocator.LoadConfigDir(e.Config.DataDir)
+	if err != nil {
+		return err
+	}
+	store, err := e.Store()
+	if err != nil {
+		return err
+	}
+	a, err := allocator.New(store, numbering)
+	if err != nil {
+		return err
+	}
+	if previous := cfg.String("previous", ""); previous != "" {
+		before, err := pipeline.LoadBOM(previous)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+		case err != nil:
+			return fmt.Errorf("failed to load the previous revision: %w", err)
+		default:
+			carried := pipeline.CarryNumbers(before, run.BOM, run.TagScheme)
+			run.Emit(pipeline.EventProgress, fmt.Sprintf("kept %d loop numbers from %s", carried, previous))
+		}
+	}
+	allocations, err := pipeline.AllocateNumbers(run.BOM, a, run.TagScheme)
+	if err != nil {
+		return err
+	}
+	// A BOM that fails a later stage gives its numbers back
+	run.OnFailure(func() error {
+		return pipeline.CancelNumbers(run.BOM, a, allocations)
+	})
+	allocated := 0
+	for _, al := range allocations {
+		if !al.Existing {
+			allocated++
+		}
+	}
+	run.Emit(pipeline.EventProgress, fmt.Sprintf("allocated %d loop numbers", allocated))
+	return nil
+}
+
+// classify proposes UDC codes for entries without one and writes the
+// proposals for review; mode fill also sets the best code. Settings: mode
+// (propose or fill), min_score, limit.
+func (e *Env) classify(ctx context.Context, run *pipeline.Run, cfg pipeline.StageConfig) error {
+	if err := needBOM(run); err != nil {
+		return err
+	}
+	opts, err := suggestOptions(cfg, "mode")
+	if err != nil {
+		return err
+	}
+	if opts.Mode == "" {
+		return pipeline.Skip("no suggest mode")
+	}
+	classifier, err := e.Classifier()
+	if err != nil {
+		return err
+	}
+
+	proposals := pipeline.SuggestCodes(run.BOM, classifier, opts)
+	filename := e.Path(run.Project + "_suggestions.yaml")
+	if err := pipeline.ExportProposals(proposals, filename); err != nil {
+		return err
+	}
+	run.AddArtifact("suggestions", filename)
+	run.Emit(pipeline.EventProgress, fmt.Sprintf("%d UDC suggestions", len(proposals)))
+	return nil
+}
+
+// suggestOptions reads the suggest settings; the mode is read from modeKey
+// and is empty when not set
+func suggestOptions(cfg pipeline.StageConfig, modeKey string) (pipeline.SuggestOptions, error) {
+	mode := pipeline.SuggestMode(cfg.String(modeKey, ""))
+	switch mode {
+	case "", pipeline.SuggestPropose, pipeline.SuggestFill:
+	default:
+		return pipeline.SuggestOptions{}, fmt.Errorf("unknown suggest mode %q (use propose or fill)", mode)
+	}
+	minScore, err := cfg.Float("min_score", 0.5)
+	if err != nil {
+		return pipeline.SuggestOptions{}, err
+	}
+	limit, err := cfg.Float("limit", 5)
+	if err != nil {
+		return pipeline.SuggestOptions{}, err
+	}
+	return pipeline.SuggestOptions{Mode: mode, Limit: int(limit), MinScore: minScore}, nil
+}
+
+// validate checks every entry and writes the validation report. Errors,
+// and warnings under the block policy, stop the run with a
+// *pipeline.ValidationError. Settings: warnings (allow or block),
+// formats (json, html, csv).
+func (e *Env) validate(ctx context.Context, run *pipeline.Run, cfg pipeline.StageConfig) error {
+	if err := needBOM(run); err != nil {
+		return err
+	}
+	if policy := cfg.String("warnings", ""); policy != "" {
+		if _, err := pipeline.ParseWarningPolicy(policy); err != nil {
+			return err
+		}
+		run.BOM.WarningPolicy = policy
+	}
+	validator, err := e.Validator()
+	if err != nil {
+		return err
+	}
+	report := validator.Validate(run.BOM, run.TagScheme)
+	run.Validation = report
+
+	if err := e.writeReports(run, cfg.Strings("formats", []string{"json"})); err != nil {
+		return err
+	}
+
+	run.Emit(pipeline.EventProgress, fmt.Sprintf("%d rows: %s", report.Rows, report.Summary()))
+	if report.Blocks() {
+		return &pipeline.ValidationError{Report: report}
+	}
+	return nil
+}
+
+// writeReports writes the run's validation report in each format
+func (e *Env) writeReports(run *pipeline.Run, formats []string) error {
+	report := run.Validation
+	base := e.Path(run.Project + "_validation")
+	for _, format := range formats {
+		var write func(*os.File) error
+		switch format {
+		case "json":
+			write = func(f *os.File) error { return pipeline.WriteValidationJSON(f, report) }
+		case "html":
+			write = func(f *os.File) error { return pipeline.WriteValidationHTML(f, report) }
+		case "csv":
+			write = func(f *os.File) error { return pipeline.WriteValidationCSV(f, report) }
+		default:
+			return fmt.Errorf("unknown validation report format %q (use json, html or csv)", format)
+		}
+		if err := writeFile(base+"."+format, write); err != nil {
+			return err
+		}
+		run.AddArtifact("validation-"+format, base+"."+format)
+	}
+	return nil
+}
+
+// writeFile creates a file and writes it with write
+func writeFile(filename string, write func(*os.File) error) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// tag formats the tags with the project's tag scheme, describes them and
+// maps their UDC codes to the crosswalk schemes, then groups them into loops
+func (e *Env) tag(ctx context.Context, run *pipeline.Run, cfg pipeline.StageConfig) error {
+	if err := needBOM(run); err != nil {
+		return err
+	}
+	d, err := e.describer(run)
+	if err != nil {
+		return err
+	}
+
+	total := len(run.BOM.Entries)
+	run.Records, run.Tags = make([]pipeline.ExportRecord, 0, total), make([]*assettag.Tag, 0, total)
+	for i, entry := range run.BOM.Entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		tag := pipeline.GenerateTag(entry, run.TagScheme)
+		parsed, err := run.TagScheme.Parse(tag)
+		if err != nil {
+			return fmt.Errorf("tag generation failed for entry %d: %w", i+1, err)
+		}
+		record, err := d.record(entry, tag, parsed)
+		if err != nil {
+			return err
+		}
+		run.Tags = append(run.Tags, parsed)
+		run.Records = append(run.Records, record)
+		if (i+1)%progressEvery == 0 {
+			run.Progress(i+1, total)
+		}
+	}
+	run.Progress(total, total)
+	run.Loops = d.loops(run.Tags)
+	for _, l := range run.Loops.Inconsistent() {
+		run.Emit(pipeline.EventWarning, fmt.Sprintf("loop %s %s: %s", l.System, l.Loop, strings.Join(l.Issues, "; ")))
+	}
+	return nil
+}
+
+// describer turns the tags of a run into tag list records
+type describer struct {
+	resolver   *assettag.Resolver
+	aggregated *aggregator.AggregatedDatabase
+	crosswalks *crosswalk.Crosswalk
+	project    string
+	language   string
+}
+
+// describer loads the reference data the records of a run need
+func (e *Env) describer(run *pipeline.Run) (*describer, error) {
+	resolver, err := e.Resolver()
+	if err != nil {
+		return nil, err
+	}
+	agg, err := e.Aggregator()
+	if err != nil {
+		return nil, err
+	}
+	crosswalks, err := e.Crosswalks()
+	if err != nil {
+		return nil, err
+	}
+	language := run.BOM.Language
+	if language == "" {
+		language = e.Config.Language
+	}
+	return &describer{resolver: resolver, aggregated: agg, crosswalks: crosswalks, project: run.Project, language: language}, nil
+}
+
+// record describes a tag and maps its UDC code to the crosswalk schemes.
+// It may be called concurrently.
+func (d *describer) record(entry pipeline.BOMEntry, tag string, parsed *assettag.Tag) (pipeline.ExportRecord, error) {
+	parsed.UDCCode = entry.UDCCode
+	tagDescription, err := d.resolver.Describe(parsed, d.project, d.language)
+	if err != nil {
+		return pipeline.ExportRecord{}, fmt.Errorf("description failed for %s: %w", tag, err)
+	}
+	return pipeline.ExportRecord{
+		FullTag:        tag,
+		SystemName:     d.systemName(entry.SystemCode),
+		Description:    entry.Description,
+		TagDescription: tagDescription,
+		UDCCode:        entry.UDCCode,
+		Designation:    entry.Designation,
+		MappedCodes:    pipeline.MappedCodes(d.crosswalks, entry.UDCCode),
+	}, nil
+}
+
+// systemName returns the name of a system code, or ""
+func (d *describer) systemName(code string) string {
+	if sys := d.aggregated.LookupSystem(code); sys != nil {
+		return sys.SystemName
+	}
+	return ""
+}
+
+// loops groups tags into the project's loop list
+func (d *describer) loops(tags []*assettag.Tag) *pipeline.LoopList {
+	letters, _ := d.resolver.ISALetters()
+	return pipeline.BuildLoopList(d.project, tags, letters, d.systemName)
+}
+
+// export writes the tag list and loop list. Settings: formats (taglist,
+// loops, loops-markdown, or a tag list format of the exporter registry such
+// as csv or xlsx, written to <project>_tags.<ext>).
+func (e *Env) export(ctx context.Context, run *pipeline.Run, cfg pipeline.StageConfig) error {
+	if run.Records == nil || run.Loops == nil {
+		return fmt.Errorf("no tags to export; the stage needs a tag stage first")
+	}
+	for _, format := range cfg.Strings("formats", []string{"taglist"}) {
+		if err := e.exportFile(run, format); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportFile writes one export format of a tagged run
+func (e *Env) exportFile(run *pipeline.Run, format string) error {
+	var filename string
+	var err error
+	switch format {
+	case "taglist":
+		filename = e.Path(run.Project + "_taglist.yaml")
+		err = pipeline.ExportTagList(run.Records, filename)
+	case "loops":
+		filename = e.Path(run.Project + "_loops.yaml")
+		err = pipeline.ExportLoopList(run.Loops, filename)
+	case "loops-markdown":
+		filename = e.Path(run.Project + "_loops.md")
+		err = pipeline.ExportLoopMarkdown(run.Loops, filename)
+	default:
+		exporter, ok := e.Exporters.Get(format)
+		if !ok {
+			return e.unknownFormat(format)
+		}
+		filename = e.Path(run.Project + "_tags" + exporter.Extension())
+		err = pipeline.ExportTags(exporter, run.Project, run.Records, filename)
+	}
+	if err != nil {
+		return err
+	}
+	run.AddArtifact(format, filename)
+	return nil
+}
+
+// unknownFormat reports an export format that is neither a file the export
+// stage writes nor a registered tag list format
+func (e *Env) unknownFormat(format string) error {
+	formats := append([]string{"taglist", "loops", "loops-markdown"}, e.Exporters.Names()...)
+	return fmt.Errorf("unknown export format %q (use %s)", format, strings.Join(formats, ", "))
+}
+
+// register records the project in the tag DB
+func (e *Env) register(ctx context.Context, run *pipeline.Run, cfg pipeline.StageConfig) error {
+	store, err := e.Store()
+	if err != nil {
+		return err
+	}
+	_, err = store.InsertProject(db.ProjectRecord{
+		ProjectName: run.Project,
+		FullBOMFile: run.Input,
+		Validated:   run.Validation != nil && !run.Validation.Blocks(),
+	})
+	return err
+}
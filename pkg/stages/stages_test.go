@@ -0,0 +1,278 @@
+package stages
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/thornzero/udc_codec/pkg/assettag"
+	"github.com/thornzero/udc_codec/pkg/config"
+	"github.com/thornzero/udc_codec/pkg/db"
+	"github.com/thornzero/udc_codec/pkg/pipeline"
+)
+
+func TestDefaultDefinitionStages(t *testing.T) {
+	runner := &pipeline.Runner{Stages: New(NewEnv(&config.Config{DataDir: t.TempDir()}))}
+	for _, def := range []*pipeline.Definition{pipeline.DefaultDefinition(), pipeline.StreamDefinition()} {
+		if err := runner.Check(def); err != nil {
+			t.Errorf("Expected every stage of the %s pipeline to exist, got %v", def.Name, err)
+		}
+	}
+}
+
+func TestImportAndNormalise(t *testing.T) {
+	dir := t.TempDir()
+	bom := `project_name: demo
+entries:
+  - system_code: " pol"
+    function_code: lt
+    equipment_id: "1001.0"
+    description: " Reactor level "
+  - system_code: POL
+    function_code: PT
+    equipment_id: "1002"
+    description: Feed pressure
+`
+	input := filepath.Join(dir, "demo_bom.yaml")
+	if err := os.WriteFile(input, []byte(bom), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	def := &pipeline.Definition{Name: "test", Stages: []pipeline.StageDef{
+		{ID: "import"},
+		{ID: "normalise", Needs: []string{"import"}},
+		{ID: "allocate", Needs: []string{"normalise"}},
+	}}
+	runner := &pipeline.Runner{Stages: New(NewEnv(&config.Config{DataDir: dir}))}
+	run := &pipeline.Run{Input: input}
+	if err := runner.Run(context.Background(), def, run); err != nil {
+		t.Fatal(err)
+	}
+
+	if run.Project != "demo" || run.TagScheme.Name != assettag.DefaultTagSchemeName {
+		t.Errorf("Expected project demo with the default tag scheme, got %s %s", run.Project, run.TagScheme.Name)
+	}
+	e := run.BOM.Entries[0]
+	if e.SystemCode != "POL" || e.FunctionCode != "LT" || e.EquipmentID != "1001" || e.Description != "Reactor level" {
+		t.Errorf("Expected a normalised entry, got %+v", e)
+	}
+	last := run.Events[len(run.Events)-1]
+	if last.Stage != "allocate" || last.Kind != pipeline.EventSkipped {
+		t.Errorf("Expected allocation to be skipped, got %v", last)
+	}
+}
+
+func TestAllocateUndoneOnFailure(t *testing.T) {
+	dir := testDataDir(t)
+	bom := `project_name: demo
+allocate_numbers: true
+entries:
+  - system_code: POL
+    function_code: LT
+    description: Reactor level
+  - system_code: POL
+    function_code: PT
+    equipment_id: "1500"
+    udc_code: %q
+    description: Feed pressure
+`
+	input := filepath.Join(dir, "demo_bom.yaml")
+	def := &pipeline.Definition{Name: "test", Stages: []pipeline.StageDef{
+		{ID: "import"},
+		{ID: "allocate", Needs: []string{"import"}},
+		{ID: "validate", Needs: []string{"allocate"}},
+	}}
+	env := NewEnv(&config.Config{DataDir: dir, DBPath: filepath.Join(dir, "tags.db"), Language: "en"})
+	runner := &pipeline.Runner{Stages: New(env)}
+	runBOM := func(udcCode string, params map[string]string) (*pipeline.Run, error) {
+		if err := os.WriteFile(input, []byte(fmt.Sprintf(bom, udcCode)), 0644); err != nil {
+			t.Fatal(err)
+		}
+		run := &pipeline.Run{Input: input, Params: params}
+		return run, runner.Run(context.Background(), def, run)
+	}
+	store, err := env.Store()
+	if err != nil {
+		t.Fatal(err)
+	}
+	numbers := func() []db.NumberReservation {
+		numbers, err := store.ListNumbers("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return numbers
+	}
+
+	run, err := runBOM("not a code", nil)
+	var validationErr *pipeline.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected the validation to fail, got %v", err)
+	}
+	if n := numbers(); len(n) != 0 {
+		t.Errorf("Expected the failed run to leave no reservations, got %+v", n)
+	}
+	if run.BOM.Entries[0].EquipmentID != "" || run.BOM.Entries[1].EquipmentID != "1500" {
+		t.Errorf("Expected only the allocated equipment ID to be cleared, got %+v", run.BOM.Entries)
+	}
+
+	// The next successful run gets the same numbers
+	run, err = runBOM("681.2", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := run.BOM.Entries[0].EquipmentID; got != "1000" {
+		t.Errorf("Expected the undone number 1000 to be handed out again, got %s", got)
+	}
+	n := numbers()
+	if len(n) != 2 || n[0].Number != 1000 || n[0].Tag != "POL-LT1000" || n[0].Status != db.NumberReserved {
+		t.Errorf("Expected 1000 to be reserved for POL-LT1000, got %+v", n)
+	}
+
+	// Uploading the BOM again keeps the numbers of the previous revision
+	previous := filepath.Join(dir, "demo_accepted.yaml")
+	if err := pipeline.ExportBOM(run.BOM, previous); err != nil {
+		t.Fatal(err)
+	}
+	run, err = runBOM("681.2", map[string]string{"allocate.previous": previous})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := run.BOM.Entries[0].EquipmentID; got != "1000" || len(numbers()) != 2 {
+		t.Errorf("Expected the entry to keep 1000 without a new reservation, got %s, %+v", got, numbers())
+	}
+}
+
+func TestStagesNeedImport(t *testing.T) {
+	def := &pipeline.Definition{Name: "test", Stages: []pipeline.StageDef{{ID: "validate"}}}
+	runner := &pipeline.Runner{Stages: New(NewEnv(&config.Config{DataDir: t.TempDir()}))}
+	if err := runner.Run(context.Background(), def, &pipeline.Run{}); err == nil {
+		t.Error("Expected validate without import to fail")
+	}
+}
+
+// testDataDir copies the reference data to a temporary data directory and
+// adds the aggregated systems
+func testDataDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files, err := filepath.Glob("../../data/*.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files = append(files, "../../data/asset_tags.json")
+	for _, file := range files {
+		if filepath.Base(file) == pipeline.DefinitionFile {
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, filepath.Base(file)), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	systems := `systems:
+  - system_code: POL
+    system_name: Polyol System
+    isa_function: {LT: Level Transmitter, PT: Pressure Transmitter}
+`
+	if err := os.WriteFile(filepath.Join(dir, "aggregated_master.yaml"), []byte(systems), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestStreamMatchesStagedPipeline(t *testing.T) {
+	dir := testDataDir(t)
+	var text strings.Builder
+	text.WriteString("Tag;Service;UDC\n")
+	for i := 0; i < 500; i++ {
+		function := []string{"LT", "PT"}[i%2]
+		fmt.Fprintf(&text, "pol-%s-%d;Service %d;681.2\n", function, 1000+i, i)
+	}
+	input := filepath.Join(dir, "plant.csv")
+	if err := os.WriteFile(input, []byte(text.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runner := &pipeline.Runner{Stages: New(NewEnv(&config.Config{DataDir: dir, DBPath: filepath.Join(dir, "tags.db")}))}
+
+	staged := &pipeline.Definition{Name: "staged", Stages: []pipeline.StageDef{
+		{ID: "import"},
+		{ID: "normalise", Needs: []string{"import"}},
+		{ID: "validate", Needs: []string{"normalise"}, Config: map[string]any{"formats": []any{"csv"}}},
+		{ID: "tag", Needs: []string{"validate"}},
+		{ID: "export", Needs: []string{"tag"}, Config: map[string]any{"formats": []any{"taglist", "loops", "csv"}}},
+	}}
+	if err := runner.Run(context.Background(), staged, &pipeline.Run{Input: input}); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]byte{}
+	for _, name := range []string{"plant_taglist.yaml", "plant_loops.yaml", "plant_tags.csv", "plant_validation.csv"} {
+		want[name], _ = os.ReadFile(filepath.Join(dir, name))
+		os.Remove(filepath.Join(dir, name))
+	}
+
+	streamed := &pipeline.Definition{Name: "streamed", Stages: []pipeline.StageDef{
+		{ID: "stream", Config: map[string]any{"workers": 4, "formats": []any{"taglist", "loops", "csv"}}},
+	}}
+	run := &pipeline.Run{Input: input}
+	if err := runner.Run(context.Background(), streamed, run); err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range want {
+		got, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != string(data) {
+			t.Errorf("Expected the streamed %s to match the staged pipeline", name)
+		}
+	}
+	if run.Validation.Rows != 500 || len(run.Tags) != 500 {
+		t.Errorf("Expected 500 rows and tags, got %d and %d", run.Validation.Rows, len(run.Tags))
+	}
+
+	unknown := &pipeline.Definition{Name: "unknown", Stages: []pipeline.StageDef{
+		{ID: "stream", Config: map[string]any{"formats": []any{"pdf"}}},
+	}}
+	if err := runner.Run(context.Background(), unknown, &pipeline.Run{Input: input}); err == nil || !strings.Contains(err.Error(), "loops-markdown, csv") {
+		t.Errorf("Expected an unknown format to list the formats, got %v", err)
+	}
+}
+
+func TestStreamBlocksExport(t *testing.T) {
+	dir := testDataDir(t)
+	bom := `project_name: demo
+entries:
+  - {system_code: POL, function_code: LT, equipment_id: "1001", description: Tank level}
+  - {system_code: POL, function_code: LT, equipment_id: "1001", description: Tank level again}
+`
+	input := filepath.Join(dir, "demo_bom.yaml")
+	if err := os.WriteFile(input, []byte(bom), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runner := &pipeline.Runner{Stages: New(NewEnv(&config.Config{DataDir: dir}))}
+	def := &pipeline.Definition{Name: "streamed", Stages: []pipeline.StageDef{{ID: "stream"}}}
+	run := &pipeline.Run{Input: input}
+
+	err := runner.Run(context.Background(), def, run)
+	var verr *pipeline.ValidationError
+	if !errors.As(err, &verr) || verr.Report.Count(pipeline.SeverityError) != 1 {
+		t.Fatalf("Expected a duplicate tag to block the run, got %v", err)
+	}
+	for _, name := range []string{"demo_taglist.yaml", "demo_taglist.yaml.tmp"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			t.Errorf("Expected no %s for a blocked BOM", name)
+		}
+	}
+	for _, kind := range []string{"validation-csv", "validation-json"} {
+		if _, ok := run.Artifact(kind); !ok {
+			t.Errorf("Expected a %s report for a blocked BOM", kind)
+		}
+	}
+}
@@ -0,0 +1,311 @@
+package stages
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/thornzero/udc_codec/pkg/assettag"
+	"github.com/thornzero/udc_codec/pkg/bomimport"
+	"github.com/thornzero/udc_codec/pkg/pipeline"
+	"github.com/thornzero/udc_codec/pkg/suggest"
+)
+
+// Stream stage defaults
+const (
+	// streamProgressEvery is how many rows the stream stage handles between
+	// progress events
+	streamProgressEvery = 10000
+	// defaultMaxIssues is how many issues the JSON and HTML reports of a
+	// streamed BOM list; the CSV report lists every issue
+	defaultMaxIssues = 1000
+)
+
+// streamRow is one entry worked on by the stream stage
+type streamRow struct {
+	check    *pipeline.RowCheck
+	proposal *pipeline.Proposal
+	record   pipeline.ExportRecord
+	err      error
+}
+
+// streamOutput is a file the stream stage writes as rows finish. Files
+// that depend on the BOM passing validation are written under a temporary
+// name and only renamed when it does.
+type streamOutput struct {
+	kind, path string
+	file       *os.File
+	temporary  bool
+}
+
+func createOutput(kind, path string, temporary bool) (*streamOutput, error) {
+	name := path
+	if temporary {
+		name += ".tmp"
+	}
+	f, err := os.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	return &streamOutput{kind: kind, path: path, file: f, temporary: temporary}, nil
+}
+
+// finish closes the file and, when keep is set, moves it into place
+func (o *streamOutput) finish(keep bool) error {
+	err := o.file.Close()
+	if !o.temporary {
+		return err
+	}
+	if err != nil || !keep {
+		os.Remove(o.file.Name())
+		return err
+	}
+	return os.Rename(o.file.Name(), o.path)
+}
+
+// stream imports, normalises, classifies, validates and tags a BOM in one
+// pass. Rows are read one at a time and worked on by a pool of workers; the
+// tag list and the issue CSV are written in row order as rows finish, so
+// memory stays flat for BOMs of any size. Loop numbers are not allocated,
+// and the loop lists, which need every tag, hold the tags in memory.
+// Settings: profile, workers (default one per CPU), suggest (propose or
+// fill), min_score, limit, warnings (allow or block), reports (json, html,
+// csv), formats (taglist, loops, loops-markdown or an exporter's format),
+// max_issues. Formats other than taglist hold their rows in memory.
+func (e *Env) stream(ctx context.Context, run *pipeline.Run, cfg pipeline.StageConfig) error {
+	if run.Input == "" {
+		return fmt.Errorf("no BOM file to import")
+	}
+	workers, err := cfg.Float("workers", 0)
+	if err != nil {
+		return err
+	}
+	maxIssues, err := cfg.Float("max_issues", defaultMaxIssues)
+	if err != nil {
+		return err
+	}
+	opts, err := suggestOptions(cfg, "suggest")
+	if err != nil {
+		return err
+	}
+	reports := cfg.Strings("reports", []string{"json", "csv"})
+	formats := cfg.Strings("formats", []string{"taglist"})
+	keepRecords := false
+	for _, format := range formats {
+		if _, ok := e.Exporters.Get(format); ok {
+			keepRecords = true
+		} else if !slices.Contains([]string{"taglist", "loops", "loops-markdown"}, format) {
+			return e.unknownFormat(format)
+		}
+	}
+
+	tagSchemes, err := e.TagSchemes()
+	if err != nil {
+		return err
+	}
+	profiles, err := e.Profiles()
+	if err != nil {
+		return err
+	}
+	profile, err := profiles.Get(cfg.String("profile", ""))
+	if err != nil {
+		return err
+	}
+	rows, err := bomimport.Open(run.Input, bomimport.Options{Profile: profile, ProjectName: run.Project, TagSchemes: tagSchemes})
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	if policy := cfg.String("warnings", ""); policy != "" {
+		if _, err := pipeline.ParseWarningPolicy(policy); err != nil {
+			return err
+		}
+		rows.BOM.WarningPolicy = policy
+	}
+	scheme, err := tagSchemes.Get(rows.BOM.TagScheme)
+	if err != nil {
+		return err
+	}
+	if run.Project == "" {
+		run.Project = rows.BOM.ProjectName
+	}
+	run.BOM, run.TagScheme = rows.BOM, scheme
+
+	validator, err := e.Validator()
+	if err != nil {
+		return err
+	}
+	d, err := e.describer(run)
+	if err != nil {
+		return err
+	}
+	var classifier *suggest.Classifier
+	if opts.Mode != "" {
+		if classifier, err = e.Classifier(); err != nil {
+			return err
+		}
+	}
+	report := pipeline.NewValidationReport(run.BOM)
+	report.MaxIssues = int(maxIssues)
+	run.Validation = report
+
+	// Open the files written row by row
+	var outputs []*streamOutput
+	defer func() {
+		for _, o := range outputs {
+			o.finish(false)
+		}
+	}()
+	open := func(kind, path string, temporary bool) (*os.File, error) {
+		o, err := createOutput(kind, path, temporary)
+		if err != nil {
+			return nil, err
+		}
+		outputs = append(outputs, o)
+		return o.file, nil
+	}
+	var issues *pipeline.IssueWriter
+	if slices.Contains(reports, "csv") {
+		f, err := open("validation-csv", e.Path(run.Project+"_validation.csv"), false)
+		if err != nil {
+			return err
+		}
+		issues = pipeline.NewIssueWriter(f)
+	}
+	var proposals *pipeline.ListWriter[pipeline.Proposal]
+	if classifier != nil {
+		f, err := open("suggestions", e.Path(run.Project+"_suggestions.yaml"), false)
+		if err != nil {
+			return err
+		}
+		proposals = pipeline.NewListWriter[pipeline.Proposal](f)
+	}
+	var records *pipeline.ListWriter[pipeline.ExportRecord]
+	if slices.Contains(formats, "taglist") {
+		f, err := open("taglist", e.Path(run.Project+"_taglist.yaml"), true)
+		if err != nil {
+			return err
+		}
+		records = pipeline.NewListWriter[pipeline.ExportRecord](f)
+	}
+	keepTags := slices.Contains(formats, "loops") || slices.Contains(formats, "loops-markdown")
+	var tags []*assettag.Tag
+	var kept []pipeline.ExportRecord
+
+	work := func(index int, entry pipeline.BOMEntry) streamRow {
+		var row streamRow
+		pipeline.NormaliseEntry(&entry, scheme)
+		if classifier != nil {
+			if p, ok := pipeline.SuggestEntry(index, &entry, classifier, opts); ok {
+				row.proposal = &p
+			}
+		}
+		row.check = validator.CheckRow(run.Project, index, entry, scheme)
+		if row.check.Parsed != nil {
+			row.record, row.err = d.record(entry, row.check.Tag, row.check.Parsed)
+		}
+		return row
+	}
+	seen := make(map[string]int)
+	emit := func(index int, row streamRow) error {
+		if row.err != nil {
+			return row.err
+		}
+		found := row.check.Issues(seen)
+		report.Rows++
+		report.Add(found...)
+		if issues != nil {
+			if err := issues.Write(found...); err != nil {
+				return err
+			}
+		}
+		if proposals != nil && row.proposal != nil {
+			if err := proposals.Write(*row.proposal); err != nil {
+				return err
+			}
+		}
+		if row.check.Parsed != nil {
+			if records != nil {
+				if err := records.Write(row.record); err != nil {
+					return err
+				}
+			}
+			if keepTags {
+				tags = append(tags, row.check.Parsed)
+			}
+			if keepRecords {
+				kept = append(kept, row.record)
+			}
+		}
+		if report.Rows%streamProgressEvery == 0 {
+			run.Emit(pipeline.EventProgress, fmt.Sprintf("processed %d rows", report.Rows))
+		}
+		return nil
+	}
+	if err := pipeline.Process(ctx, int(workers), rows.Next, work, emit); err != nil {
+		return err
+	}
+
+	for _, w := range rows.Report.Warnings {
+		run.Emit(pipeline.EventWarning, w.Error())
+	}
+	if len(rows.Report.Errors) > 0 {
+		errs := []error{rows.Report.Err()}
+		for _, e := range rows.Report.Errors {
+			errs = append(errs, e)
+		}
+		return errors.Join(errs...)
+	}
+	if issues != nil {
+		if err := issues.Flush(); err != nil {
+			return err
+		}
+	}
+	if proposals != nil {
+		if err := proposals.Close(); err != nil {
+			return err
+		}
+	}
+	if records != nil {
+		if err := records.Close(); err != nil {
+			return err
+		}
+	}
+
+	// Keep the tag list only when the BOM passed
+	passed := !report.Blocks()
+	for len(outputs) > 0 {
+		o := outputs[0]
+		outputs = outputs[1:]
+		if err := o.finish(passed); err != nil {
+			return err
+		}
+		if passed || !o.temporary {
+			run.AddArtifact(o.kind, o.path)
+		}
+	}
+	if err := e.writeReports(run, slices.DeleteFunc(slices.Clone(reports), func(format string) bool { return format == "csv" })); err != nil {
+		return err
+	}
+	run.Emit(pipeline.EventProgress, fmt.Sprintf("%d rows: %s", report.Rows, report.Summary()))
+	if !passed {
+		return &pipeline.ValidationError{Report: report}
+	}
+
+	if keepTags {
+		run.Tags = tags
+		run.Loops = d.loops(tags)
+	}
+	run.Records = kept
+	for _, format := range formats {
+		if format == "taglist" {
+			continue
+		}
+		if err := e.exportFile(run, format); err != nil {
+			return err
+		}
+	}
+	return nil
+}
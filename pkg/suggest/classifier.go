@@ -0,0 +1,289 @@
+package suggest
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/thornzero/udc_codec/pkg/db"
+	"github.com/thornzero/udc_codec/pkg/udc"
+)
+
+// DefaultModelWeight is how much the trained model counts towards a score
+// once the classifier has seen classified examples
+const DefaultModelWeight = 0.6
+
+// Suggestion is a candidate UDC code for a description
+type Suggestion struct {
+	Code  string  `json:"code" yaml:"code"`
+	Title string  `json:"title" yaml:"title"`
+	Score float64 `json:"score" yaml:"score"`
+	// TitleScore is the TF-IDF similarity to the code's title and notes
+	TitleScore float64 `json:"title_score" yaml:"title_score"`
+	// ModelScore is the naive Bayes probability from classified tags
+	ModelScore float64 `json:"model_score" yaml:"model_score"`
+}
+
+// Example is an already-classified description used for training
+type Example struct {
+	Description string
+	Code        string
+}
+
+// Classifier ranks UDC codes for free-text equipment descriptions. It
+// combines TF-IDF similarity against the codec titles and notes with a
+// naive Bayes model trained on classified tags. Everything runs offline.
+// Suggest may be called concurrently, also while training.
+type Classifier struct {
+	codec    *udc.Codec
+	expander Expander
+
+	// ModelWeight is the share of the score taken by the trained model
+	ModelWeight float64
+
+	idf     map[string]float64
+	vectors map[string]map[string]float64
+	index   map[string][]string
+
+	// mu guards the model
+	mu          sync.RWMutex
+	examples    int
+	classDocs   map[string]int
+	classTokens map[string]map[string]int
+	classTotals map[string]int
+	vocabulary  map[string]bool
+}
+
+// New builds a classifier over the codec titles and notes. The expander,
+// usually a dictionary.Dictionary, may be nil.
+func New(codec *udc.Codec, expander Expander) *Classifier {
+	c := &Classifier{
+		codec:       codec,
+		expander:    expander,
+		ModelWeight: DefaultModelWeight,
+		idf:         make(map[string]float64),
+		vectors:     make(map[string]map[string]float64),
+		index:       make(map[string][]string),
+		classDocs:   make(map[string]int),
+		classTokens: make(map[string]map[string]int),
+		classTotals: make(map[string]int),
+		vocabulary:  make(map[string]bool),
+	}
+	c.buildIndex()
+	return c
+}
+
+// tokens tokenises and expands a text. Expansions are tokenised again so
+// they are stemmed like codec titles.
+func (c *Classifier) tokens(text string) []string {
+	tokens := Tokenize(text)
+	if c.expander != nil {
+		tokens = Tokenize(strings.Join(c.expander.Expand(tokens), " "))
+	}
+	return tokens
+}
+
+// buildIndex computes normalised TF-IDF vectors for every codec node
+func (c *Classifier) buildIndex() {
+	nodes := c.codec.Nodes()
+	counts := make(map[string]map[string]int, len(nodes))
+	df := make(map[string]int)
+	for _, n := range nodes {
+		tf := make(map[string]int)
+		for _, t := range Tokenize(n.Title + " " + n.Notes) {
+			tf[t]++
+		}
+		counts[n.Code] = tf
+		for t := range tf {
+			df[t]++
+		}
+	}
+	for t, d := range df {
+		c.idf[t] = math.Log(float64(len(nodes))/float64(d)) + 1
+	}
+	for code, tf := range counts {
+		vector := make(map[string]float64, len(tf))
+		for t, n := range tf {
+			vector[t] = float64(n) * c.idf[t]
+			c.index[t] = append(c.index[t], code)
+		}
+		c.vectors[code] = normalise(vector)
+	}
+}
+
+// Train adds classified examples to the naive Bayes model
+func (c *Classifier) Train(examples []Example) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, e := range examples {
+		if e.Code == "" {
+			continue
+		}
+		tokens := c.tokens(e.Description)
+		if len(tokens) == 0 {
+			continue
+		}
+		c.examples++
+		c.classDocs[e.Code]++
+		if c.classTokens[e.Code] == nil {
+			c.classTokens[e.Code] = make(map[string]int)
+		}
+		for _, t := range tokens {
+			c.classTokens[e.Code][t]++
+			c.classTotals[e.Code]++
+			c.vocabulary[t] = true
+		}
+	}
+}
+
+// TrainFromStore trains on every tag in the database with a UDC code and description
+func (c *Classifier) TrainFromStore(store *db.Store) error {
+	tags, err := store.ListClassifiedTags()
+	if err != nil {
+		return err
+	}
+	examples := make([]Example, 0, len(tags))
+	for _, t := range tags {
+		examples = append(examples, Example{Description: t.Description, Code: t.UDCCode})
+	}
+	c.Train(examples)
+	return nil
+}
+
+// Examples returns the number of examples the model was trained on
+func (c *Classifier) Examples() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.examples
+}
+
+// Suggest returns up to limit candidate codes for a description, best
+// first. A limit of 0 returns every candidate.
+func (c *Classifier) Suggest(description string, limit int) []Suggestion {
+	tokens := c.tokens(description)
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	titleScores := c.titleScores(tokens)
+	c.mu.RLock()
+	modelScores := c.modelScores(tokens)
+	c.mu.RUnlock()
+	weight := 0.0
+	if len(modelScores) > 0 {
+		weight = c.ModelWeight
+	}
+
+	candidates := make(map[string]bool)
+	for code := range titleScores {
+		candidates[code] = true
+	}
+	for code := range modelScores {
+		candidates[code] = true
+	}
+
+	suggestions := make([]Suggestion, 0, len(candidates))
+	for code := range candidates {
+		s := Suggestion{
+			Code:       code,
+			TitleScore: titleScores[code],
+			ModelScore: modelScores[code],
+		}
+		s.Score = (1-weight)*s.TitleScore + weight*s.ModelScore
+		if s.Score <= 0 {
+			continue
+		}
+		s.Title, _ = c.codec.Lookup(code)
+		suggestions = append(suggestions, s)
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Score != suggestions[j].Score {
+			return suggestions[i].Score > suggestions[j].Score
+		}
+		return suggestions[i].Code < suggestions[j].Code
+	})
+	if limit > 0 && len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions
+}
+
+// titleScores returns the cosine similarity of the tokens to each codec node
+func (c *Classifier) titleScores(tokens []string) map[string]float64 {
+	tf := make(map[string]int)
+	for _, t := range tokens {
+		if _, ok := c.idf[t]; ok {
+			tf[t]++
+		}
+	}
+	query := make(map[string]float64, len(tf))
+	for t, n := range tf {
+		query[t] = float64(n) * c.idf[t]
+	}
+	query = normalise(query)
+
+	scores := make(map[string]float64)
+	for t, w := range query {
+		for _, code := range c.index[t] {
+			scores[code] += w * c.vectors[code][t]
+		}
+	}
+	return scores
+}
+
+// modelScores returns the naive Bayes posterior of each trained class
+func (c *Classifier) modelScores(tokens []string) map[string]float64 {
+	if c.examples == 0 {
+		return nil
+	}
+	known := tokens[:0:0]
+	for _, t := range tokens {
+		if c.vocabulary[t] {
+			known = append(known, t)
+		}
+	}
+	if len(known) == 0 {
+		return nil
+	}
+
+	vocab := float64(len(c.vocabulary))
+	logs := make(map[string]float64, len(c.classDocs))
+	best := math.Inf(-1)
+	for code, docs := range c.classDocs {
+		p := math.Log(float64(docs) / float64(c.examples))
+		for _, t := range known {
+			p += math.Log((float64(c.classTokens[code][t]) + 1) / (float64(c.classTotals[code]) + vocab))
+		}
+		logs[code] = p
+		best = math.Max(best, p)
+	}
+
+	// Softmax, shifted by the best score to avoid underflow
+	total := 0.0
+	scores := make(map[string]float64, len(logs))
+	for code, p := range logs {
+		scores[code] = math.Exp(p - best)
+		total += scores[code]
+	}
+	for code := range scores {
+		scores[code] /= total
+	}
+	return scores
+}
+
+// normalise scales a vector to unit length
+func normalise(vector map[string]float64) map[string]float64 {
+	sum := 0.0
+	for _, w := range vector {
+		sum += w * w
+	}
+	if sum == 0 {
+		return vector
+	}
+	norm := math.Sqrt(sum)
+	for t := range vector {
+		vector[t] /= norm
+	}
+	return vector
+}
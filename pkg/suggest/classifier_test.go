@@ -0,0 +1,135 @@
+package suggest
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/thornzero/udc_codec/pkg/dictionary"
+	"github.com/thornzero/udc_codec/pkg/udc"
+)
+
+func loadTestCodec(t *testing.T) *udc.Codec {
+	t.Helper()
+	tempDir := t.TempDir()
+
+	udcContent := `
+- code: "62"
+  title: "Engineering"
+  children:
+    - code: "621.6"
+      title: "Fluids handling, storage and distribution plant"
+    - code: "621.3"
+      title: "Electrical engineering"
+- code: "681"
+  title: "Precision mechanics. Automatic control"
+  children:
+    - code: "681.2"
+      title: "Measuring instruments. Instrumentation"
+    - code: "681.5"
+      title: "Automatic control technology"
+      notes: "Controllers, PLCs and control loops"
+`
+	if err := os.WriteFile(filepath.Join(tempDir, "udc_full.yaml"), []byte(udcContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+	codec, err := udc.LoadCodec(filepath.Join(tempDir, "udc_full.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return codec
+}
+
+func TestTokenize(t *testing.T) {
+	got := Tokenize("Pumps and Valves, for the boxes; 2x batteries")
+	want := []string{"pump", "valve", "box", "2x", "battery"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestSuggestFromTitles(t *testing.T) {
+	codec := loadTestCodec(t)
+	c := New(codec, nil)
+
+	suggestions := c.Suggest("pressure measuring instrument", 3)
+	if len(suggestions) == 0 || suggestions[0].Code != "681.2" {
+		t.Fatalf("Expected 681.2 as best suggestion, got %v", suggestions)
+	}
+	if suggestions[0].Title != "Measuring instruments. Instrumentation" {
+		t.Errorf("Expected title of 681.2, got %q", suggestions[0].Title)
+	}
+
+	// Notes are indexed as well as titles
+	suggestions = c.Suggest("PLC", 1)
+	if len(suggestions) != 1 || suggestions[0].Code != "681.5" {
+		t.Errorf("Expected 681.5 for PLC, got %v", suggestions)
+	}
+
+	if got := c.Suggest("the and of", 5); got != nil {
+		t.Errorf("Expected no suggestions for stopwords, got %v", got)
+	}
+}
+
+func TestSuggestWithDictionary(t *testing.T) {
+	codec := loadTestCodec(t)
+	dict := dictionary.New("en")
+	dict.Add(&dictionary.File{
+		Abbreviations: map[string]dictionary.Expansions{
+			"xmtr":         {"instruments"},
+			"diaphragm pp": {"fluids handling"},
+		},
+	})
+	c := New(codec, dict)
+
+	if s := c.Suggest("level xmtr", 1); len(s) != 1 || s[0].Code != "681.2" {
+		t.Errorf("Expected xmtr to expand to instruments, got %v", s)
+	}
+	if s := c.Suggest("Diaphragm PP 2in", 1); len(s) != 1 || s[0].Code != "621.6" {
+		t.Errorf("Expected two-word abbreviation to match 621.6, got %v", s)
+	}
+}
+
+func TestSuggestWithModel(t *testing.T) {
+	codec := loadTestCodec(t)
+	c := New(codec, nil)
+	c.Train([]Example{
+		{Description: "Diaphragm pump, polyol transfer", Code: "621.6"},
+		{Description: "Centrifugal pump for isocyanate", Code: "621.6"},
+		{Description: "Motor starter panel", Code: "621.3"},
+		{Description: "Unclassified", Code: ""},
+	})
+	if c.Examples() != 3 {
+		t.Errorf("Expected 3 training examples, got %d", c.Examples())
+	}
+
+	suggestions := c.Suggest("polyol dosing pump", 2)
+	if len(suggestions) == 0 || suggestions[0].Code != "621.6" {
+		t.Fatalf("Expected 621.6 from trained model, got %v", suggestions)
+	}
+	if suggestions[0].ModelScore <= 0.5 || suggestions[0].TitleScore != 0 {
+		t.Errorf("Expected score from model only, got %+v", suggestions[0])
+	}
+}
+
+func TestSuggestConcurrently(t *testing.T) {
+	c := New(loadTestCodec(t), nil)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			c.Train([]Example{{Description: "Diaphragm pump", Code: "621.6"}})
+		}()
+		go func() {
+			defer wg.Done()
+			c.Suggest("polyol pump", 3)
+		}()
+	}
+	wg.Wait()
+	if c.Examples() != 8 {
+		t.Errorf("Expected 8 training examples, got %d", c.Examples())
+	}
+}
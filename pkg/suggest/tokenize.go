@@ -0,0 +1,47 @@
+package suggest
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Expander adds synonyms and the expansions of abbreviations to a token list
+type Expander interface {
+	Expand(tokens []string) []string
+}
+
+var stopwords = map[string]bool{
+	"a": true, "an": true, "and": true, "as": true, "at": true, "by": true,
+	"for": true, "from": true, "in": true, "into": true, "is": true, "it": true,
+	"its": true, "of": true, "on": true, "or": true, "the": true, "their": true,
+	"to": true, "with": true, "etc": true, "eg": true, "ie": true, "see": true,
+}
+
+// Tokenize lower-cases text, splits it into words, drops stopwords and
+// reduces plurals so that "Pumps" and "pump" match
+func Tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	tokens := make([]string, 0, len(words))
+	for _, w := range words {
+		if stopwords[w] {
+			continue
+		}
+		tokens = append(tokens, stem(w))
+	}
+	return tokens
+}
+
+// stem strips common English plural endings
+func stem(w string) string {
+	switch {
+	case len(w) > 4 && strings.HasSuffix(w, "ies"):
+		return w[:len(w)-3] + "y"
+	case len(w) > 4 && (strings.HasSuffix(w, "ches") || strings.HasSuffix(w, "shes") || strings.HasSuffix(w, "xes") || strings.HasSuffix(w, "sses")):
+		return w[:len(w)-2]
+	case len(w) > 3 && strings.HasSuffix(w, "s") && !strings.HasSuffix(w, "ss") && !strings.HasSuffix(w, "us") && !strings.HasSuffix(w, "is"):
+		return w[:len(w)-1]
+	}
+	return w
+}
@@ -5,18 +5,35 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 
 	"gopkg.in/yaml.v3"
 )
 
+// Codec is safe for concurrent use: the code tables never change after
+// loading and the expander is guarded
 type Codec struct {
-	flat map[string]*Node
+	flat    map[string]*Node
+	parents map[string]*Node
+	roots   []*Node
+	sources map[string]string
+
+	mu       sync.RWMutex
+	expander TermExpander
+}
+
+// TermExpander returns a search term followed by its alternative spellings,
+// such as the expansions of industrial abbreviations
+type TermExpander interface {
+	Variants(term string) []string
 }
 
 type Node struct {
 	Code     string  `yaml:"code"`
 	Title    string  `yaml:"title"`
+	Notes    string  `yaml:"notes,omitempty"`
 	Children []*Node `yaml:"children,omitempty"`
 }
 
@@ -33,8 +50,12 @@ func LoadCodec(filename string) (*Codec, error) {
 		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
 	}
 
+	// Record which file each code came from
+	sources := make(map[string]string)
+	recordSources(nodes, filepath.Base(filename), sources)
+
 	// Load and merge local addendums
-	addendumNodes, err := loadAddendums(filepath.Dir(filename))
+	addendumNodes, err := loadAddendums(filepath.Dir(filename), sources)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load addendums: %w", err)
 	}
@@ -49,11 +70,15 @@ func LoadCodec(filename string) (*Codec, error) {
 	flat := make(map[string]*Node)
 	buildFlatMap(nodes, flat)
 
-	return &Codec{flat: flat}, nil
+	parents := make(map[string]*Node)
+	buildParentMap(nodes, nil, parents)
+
+	return &Codec{flat: flat, parents: parents, roots: nodes, sources: sources}, nil
 }
 
-// loadAddendums loads all addendum files from the data directory
-func loadAddendums(dataDir string) ([]*Node, error) {
+// loadAddendums loads all addendum files from the data directory,
+// recording the file each addendum code was read from
+func loadAddendums(dataDir string, sources map[string]string) ([]*Node, error) {
 	var allAddendumNodes []*Node
 
 	// Look for files matching the pattern "udc_addendum_*.yaml"
@@ -83,6 +108,12 @@ func loadAddendums(dataDir string) ([]*Node, error) {
 			return fmt.Errorf("failed to parse addendum %s: %w", path, err)
 		}
 
+		for _, node := range addendumNodes {
+			if _, exists := sources[node.Code]; !exists {
+				recordSources([]*Node{node}, d.Name(), sources)
+			}
+		}
+
 		allAddendumNodes = append(allAddendumNodes, addendumNodes...)
 		return nil
 	})
@@ -125,6 +156,24 @@ func buildFlatMap(nodes []*Node, flat map[string]*Node) {
 	}
 }
 
+// buildParentMap records the parent node of every code in the hierarchy
+func buildParentMap(nodes []*Node, parent *Node, parents map[string]*Node) {
+	for _, node := range nodes {
+		if parent != nil {
+			parents[node.Code] = parent
+		}
+		buildParentMap(node.Children, node, parents)
+	}
+}
+
+// recordSources records the source file name for every code in a hierarchy
+func recordSources(nodes []*Node, source string, sources map[string]string) {
+	for _, node := range nodes {
+		sources[node.Code] = source
+		recordSources(node.Children, source, sources)
+	}
+}
+
 func (c *Codec) Lookup(code string) (string, bool) {
 	node, ok := c.flat[code]
 	if !ok {
@@ -149,8 +198,24 @@ func (c *Codec) ParseComposite(code string) ([]*Node, error) {
 	return result, nil
 }
 
+// Search returns the nodes whose title contains the term or, when an
+// expander is set, any of its variants
 func (c *Codec) Search(term string) []*Node {
-	return search(c.flat, term)
+	terms := []string{term}
+	c.mu.RLock()
+	expander := c.expander
+	c.mu.RUnlock()
+	if expander != nil {
+		terms = expander.Variants(term)
+	}
+	return search(c.flat, terms...)
+}
+
+// SetExpander sets the expander used by Search
+func (c *Codec) SetExpander(e TermExpander) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.expander = e
 }
 
 func (c *Codec) Children(code string) ([]*Node, bool) {
@@ -162,18 +227,27 @@ func (c *Codec) Children(code string) ([]*Node, bool) {
 }
 
 func (c *Codec) Ancestry(code string) ([]*Node, bool) {
-	// Since we don't have parent pointers in the flat map,
-	// we need to reconstruct the ancestry by searching for parent codes
+	if _, ok := c.flat[code]; !ok {
+		return nil, false
+	}
+
+	// Follow the loaded hierarchy, falling back to the parent inferred from
+	// the code for roots such as addendum nodes
 	var path []*Node
+	seen := make(map[string]bool)
 	currentCode := code
-	for currentCode != "" {
-		if currentNode, exists := c.flat[currentCode]; exists {
-			path = append([]*Node{currentNode}, path...)
-			// Find parent code by removing last character or dot-separated part
-			currentCode = findParentCode(currentCode)
-		} else {
+	for currentCode != "" && !seen[currentCode] {
+		currentNode, exists := c.flat[currentCode]
+		if !exists {
 			break
 		}
+		seen[currentCode] = true
+		path = append([]*Node{currentNode}, path...)
+		if parent, ok := c.parents[currentCode]; ok {
+			currentCode = parent.Code
+		} else {
+			currentCode = findParentCode(currentCode)
+		}
 	}
 	return path, true
 }
@@ -182,6 +256,40 @@ func (c *Codec) Validate(code string) error {
 	return validateComposite(code, c.flat)
 }
 
+// Node returns the node for a code
+func (c *Codec) Node(code string) (*Node, bool) {
+	node, ok := c.flat[code]
+	return node, ok
+}
+
+// Roots returns the top-level nodes, followed by the addendum roots
+func (c *Codec) Roots() []*Node {
+	return c.roots
+}
+
+// Nodes returns every node in the codec, sorted by code
+func (c *Codec) Nodes() []*Node {
+	nodes := make([]*Node, 0, len(c.flat))
+	for _, node := range c.flat {
+		nodes = append(nodes, node)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Code < nodes[j].Code
+	})
+	return nodes
+}
+
+// Source returns the name of the file a code was loaded from
+func (c *Codec) Source(code string) (string, bool) {
+	source, ok := c.sources[code]
+	return source, ok
+}
+
+// IsAddendum reports whether a code was loaded from a local addendum file
+func (c *Codec) IsAddendum(code string) bool {
+	return strings.HasPrefix(c.sources[code], "udc_addendum_")
+}
+
 // AddendumManager provides functions for managing addendum files
 type AddendumManager struct {
 	dataDir string
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 
 	"gopkg.in/yaml.v3"
@@ -519,3 +520,113 @@ func TestAddendumManagerValidation(t *testing.T) {
 		t.Errorf("Expected error about existing code, but got: %v", err)
 	}
 }
+
+func TestCodecSources(t *testing.T) {
+	tempDir := t.TempDir()
+
+	udcContent := `
+- code: "6"
+  title: "Applied sciences"
+  children:
+    - code: "62"
+      title: "Engineering"
+`
+	addendumContent := `
+- code: "62.LOCAL"
+  title: "Local engineering"
+  notes: "Plant-specific engineering topics"
+`
+	if err := os.WriteFile(filepath.Join(tempDir, "udc_full.yaml"), []byte(udcContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "udc_addendum_local.yaml"), []byte(addendumContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	codec, err := LoadCodec(filepath.Join(tempDir, "udc_full.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(codec.Roots()) != 2 {
+		t.Fatalf("Expected 2 roots, got %d", len(codec.Roots()))
+	}
+
+	if source, ok := codec.Source("62"); !ok || source != "udc_full.yaml" {
+		t.Errorf("Expected source 'udc_full.yaml' for code '62', got '%s'", source)
+	}
+	if codec.IsAddendum("62") {
+		t.Error("Expected code '62' not to be an addendum code")
+	}
+
+	if source, ok := codec.Source("62.LOCAL"); !ok || source != "udc_addendum_local.yaml" {
+		t.Errorf("Expected source 'udc_addendum_local.yaml' for code '62.LOCAL', got '%s'", source)
+	}
+	if !codec.IsAddendum("62.LOCAL") {
+		t.Error("Expected code '62.LOCAL' to be an addendum code")
+	}
+
+	node, ok := codec.Node("62.LOCAL")
+	if !ok {
+		t.Fatal("Expected to find node '62.LOCAL'")
+	}
+	if node.Notes != "Plant-specific engineering topics" {
+		t.Errorf("Expected notes to be loaded, got '%s'", node.Notes)
+	}
+}
+
+// staticExpander returns fixed variants for a term
+type staticExpander map[string][]string
+
+func (e staticExpander) Variants(term string) []string {
+	return append([]string{term}, e[term]...)
+}
+
+func TestSearchWithExpander(t *testing.T) {
+	tempDir := t.TempDir()
+	udcContent := `
+- code: "621"
+  title: "Mechanical engineering"
+  children:
+    - code: "621.6"
+      title: "Pumps and valves"
+`
+	if err := os.WriteFile(filepath.Join(tempDir, "udc_full.yaml"), []byte(udcContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+	codec, err := LoadCodec(filepath.Join(tempDir, "udc_full.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if results := codec.Search("vlv"); len(results) != 0 {
+		t.Errorf("Expected no results without an expander, got %d", len(results))
+	}
+	codec.SetExpander(staticExpander{"vlv": {"valve"}})
+	results := codec.Search("vlv")
+	if len(results) != 1 || results[0].Code != "621.6" {
+		t.Errorf("Expected 621.6 for expanded term, got %v", results)
+	}
+}
+
+func TestCodecConcurrentUse(t *testing.T) {
+	codec, err := LoadCodec("../../data/udc_full.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			codec.SetExpander(staticExpander{"vlv": {"valve"}})
+		}()
+		go func() {
+			defer wg.Done()
+			codec.Search("vlv")
+			codec.Ancestry("681.2")
+			codec.Validate("681.2")
+		}()
+	}
+	wg.Wait()
+}
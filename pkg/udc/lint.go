@@ -0,0 +1,281 @@
+package udc
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Lint rule identifiers
+const (
+	RuleDuplicateCode   = "duplicate-code"
+	RuleMisplaced       = "misplaced"
+	RuleOrphan          = "orphan"
+	RuleEmptyTitle      = "empty-title"
+	RuleSuspiciousTitle = "suspicious-title"
+	RulePlaceParent     = "place-parent"
+)
+
+// Lint severities
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// LintIssue describes a single consistency problem found in UDC data
+type LintIssue struct {
+	Rule     string `json:"rule" yaml:"rule"`
+	Severity string `json:"severity" yaml:"severity"`
+	Code     string `json:"code" yaml:"code"`
+	Parent   string `json:"parent,omitempty" yaml:"parent,omitempty"`
+	Expected string `json:"expected,omitempty" yaml:"expected,omitempty"`
+	Source   string `json:"source,omitempty" yaml:"source,omitempty"`
+	Message  string `json:"message" yaml:"message"`
+}
+
+var (
+	htmlEntityRegex = regexp.MustCompile(`&(#\d+|#x[0-9a-fA-F]+|[a-zA-Z]+);`)
+	htmlTagRegex    = regexp.MustCompile(`</?[a-zA-Z][^>]*>`)
+	placeCodeRegex  = regexp.MustCompile(`^\((\d+(\.\d+)*)\)$`)
+)
+
+// lintEntry is a node together with the context it was found in
+type lintEntry struct {
+	node   *Node
+	parent *Node
+	source string
+}
+
+// linter collects UDC hierarchies from one or more sources and checks them together
+type linter struct {
+	entries []lintEntry
+}
+
+// Add queues a hierarchy for linting, labelled with the source it was read from
+func (l *linter) Add(source string, nodes []*Node) {
+	l.collect(source, nodes, nil)
+}
+
+func (l *linter) collect(source string, nodes []*Node, parent *Node) {
+	for _, node := range nodes {
+		l.entries = append(l.entries, lintEntry{node: node, parent: parent, source: source})
+		l.collect(source, node.Children, node)
+	}
+}
+
+// Run checks every queued node and returns the issues found, ordered by code
+func (l *linter) Run() []LintIssue {
+	codes := make(map[string][]lintEntry)
+	for _, e := range l.entries {
+		codes[e.node.Code] = append(codes[e.node.Code], e)
+	}
+
+	var issues []LintIssue
+	for code, entries := range codes {
+		if len(entries) > 1 {
+			var sources []string
+			for _, e := range entries {
+				sources = append(sources, e.source)
+			}
+			issues = append(issues, LintIssue{
+				Rule:     RuleDuplicateCode,
+				Severity: SeverityError,
+				Code:     code,
+				Source:   entries[1].source,
+				Message:  fmt.Sprintf("code appears %d times (%s)", len(entries), strings.Join(sources, ", ")),
+			})
+		}
+	}
+
+	for _, e := range l.entries {
+		issues = append(issues, lintTitle(e)...)
+		if issue, ok := lintPlacement(e, codes); ok {
+			issues = append(issues, issue)
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Code != issues[j].Code {
+			return issues[i].Code < issues[j].Code
+		}
+		return issues[i].Rule < issues[j].Rule
+	})
+	return issues
+}
+
+// Lint checks a single UDC hierarchy for consistency problems
+func Lint(nodes []*Node) []LintIssue {
+	var l linter
+	l.Add("", nodes)
+	return l.Run()
+}
+
+// LintFile lints a UDC data file together with the addendums next to it
+func LintFile(filename string) ([]LintIssue, error) {
+	var l linter
+
+	nodes, err := readNodes(filename)
+	if err != nil {
+		return nil, err
+	}
+	l.Add(filepath.Base(filename), nodes)
+
+	am := NewAddendumManager(filepath.Dir(filename))
+	addendums, err := am.ListAddendums()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range addendums {
+		nodes, err := readNodes(filepath.Join(filepath.Dir(filename), name))
+		if err != nil {
+			return nil, err
+		}
+		l.Add(name, nodes)
+	}
+
+	return l.Run(), nil
+}
+
+// readNodes reads a YAML list of nodes from disk
+func readNodes(filename string) ([]*Node, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filename, err)
+	}
+	var nodes []*Node
+	if err := yaml.Unmarshal(data, &nodes); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+	return nodes, nil
+}
+
+// lintTitle reports empty titles and leftovers from the scraped HTML
+func lintTitle(e lintEntry) []LintIssue {
+	title := e.node.Title
+	issue := LintIssue{Code: e.node.Code, Source: e.source}
+	if e.parent != nil {
+		issue.Parent = e.parent.Code
+	}
+
+	if strings.TrimSpace(title) == "" {
+		issue.Rule = RuleEmptyTitle
+		issue.Severity = SeverityError
+		issue.Message = "title is empty"
+		return []LintIssue{issue}
+	}
+
+	var problems []string
+	if m := htmlEntityRegex.FindString(title); m != "" {
+		problems = append(problems, fmt.Sprintf("contains HTML entity %s", m))
+	}
+	if m := htmlTagRegex.FindString(title); m != "" {
+		problems = append(problems, fmt.Sprintf("contains HTML tag %s", m))
+	}
+	if strings.ContainsRune(title, ' ') {
+		problems = append(problems, "contains non-breaking space")
+	}
+	if title != strings.TrimSpace(title) {
+		problems = append(problems, "has leading or trailing whitespace")
+	}
+	if strings.Contains(title, "  ") {
+		problems = append(problems, "contains repeated spaces")
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+
+	issue.Rule = RuleSuspiciousTitle
+	issue.Severity = SeverityWarning
+	issue.Message = "title " + strings.Join(problems, "; ")
+	return []LintIssue{issue}
+}
+
+// lintPlacement compares where a node sits with where its code says it belongs
+func lintPlacement(e lintEntry, codes map[string][]lintEntry) (LintIssue, bool) {
+	code := e.node.Code
+	issue := LintIssue{Code: code, Source: e.source}
+	actual := ""
+	if e.parent != nil {
+		actual = e.parent.Code
+		issue.Parent = actual
+	}
+
+	// Place auxiliaries belong under their longest existing prefix, (540) under (54)
+	if placeCodeRegex.MatchString(code) {
+		expected := findPlaceParent(code, codes)
+		if expected == "" || expected == actual || (e.parent == nil && isAddendumSource(e.source)) {
+			return issue, false
+		}
+		issue.Rule = RulePlaceParent
+		issue.Severity = SeverityError
+		issue.Expected = expected
+		issue.Message = fmt.Sprintf("place auxiliary should be under %s", expected)
+		return issue, true
+	}
+
+	// Table headings such as (0...) use placeholder notation with no inferable parent
+	if strings.Contains(code, "...") {
+		return issue, false
+	}
+
+	expected := findParentCode(code)
+	if expected == "" || expected == actual {
+		return issue, false
+	}
+	issue.Expected = expected
+
+	if _, exists := codes[expected]; !exists {
+		// Nested nodes were placed by the site's parent IDs, which is fine
+		// when the inferred parent is simply not part of the summary
+		if (e.parent != nil && actual != "TOP") || expected == "TOP" {
+			return issue, false
+		}
+		issue.Rule = RuleOrphan
+		issue.Severity = SeverityWarning
+		issue.Message = fmt.Sprintf("inferred parent %s does not exist", expected)
+		return issue, true
+	}
+
+	// Addendum roots are attached to their inferred parent when loaded
+	if e.parent == nil && isAddendumSource(e.source) {
+		return issue, false
+	}
+
+	issue.Rule = RuleMisplaced
+	issue.Severity = SeverityError
+	if actual == "" {
+		issue.Message = fmt.Sprintf("node is a root but its code places it under %s", expected)
+	} else {
+		issue.Message = fmt.Sprintf("node is under %s but its code places it under %s", actual, expected)
+	}
+	return issue, true
+}
+
+// findPlaceParent finds the longest existing prefix of a place auxiliary code
+func findPlaceParent(code string, codes map[string][]lintEntry) string {
+	inner := placeCodeRegex.FindStringSubmatch(code)[1]
+	if len(inner) == 1 {
+		if _, ok := codes["TOP"]; ok {
+			return "TOP"
+		}
+		return ""
+	}
+	for len(inner) > 1 {
+		inner = strings.TrimSuffix(inner[:len(inner)-1], ".")
+		candidate := "(" + inner + ")"
+		if _, ok := codes[candidate]; ok {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// isAddendumSource reports whether a source label names an addendum file
+func isAddendumSource(source string) bool {
+	return strings.HasPrefix(source, "udc_addendum_")
+}
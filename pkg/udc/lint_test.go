@@ -0,0 +1,121 @@
+package udc
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func findIssue(issues []LintIssue, rule, code string) *LintIssue {
+	for i := range issues {
+		if issues[i].Rule == rule && issues[i].Code == code {
+			return &issues[i]
+		}
+	}
+	return nil
+}
+
+func TestLint(t *testing.T) {
+	nodes := []*Node{
+		{
+			Code:  "TOP",
+			Title: "UDC Summary Root",
+			Children: []*Node{
+				{Code: "0", Title: "Science and Knowledge", Children: []*Node{
+					{Code: "00", Title: "Prolegomena"},
+					{Code: "001.1", Title: "Concepts&nbsp;of science"},
+				}},
+				{Code: "001", Title: ""},
+				{Code: "030", Title: "Reference works"},
+				{Code: "(5)", Title: "Asia", Children: []*Node{
+					{Code: "(54)", Title: "India", Children: []*Node{
+						{Code: "(540.1)", Title: "Indian states"},
+					}},
+					{Code: "(540)", Title: "India"},
+				}},
+				{Code: "00", Title: "Prolegomena again"},
+			},
+		},
+	}
+
+	issues := Lint(nodes)
+
+	tests := []struct {
+		rule     string
+		code     string
+		expected string
+	}{
+		{RuleDuplicateCode, "00", ""},
+		{RuleEmptyTitle, "001", ""},
+		{RuleSuspiciousTitle, "001.1", ""},
+		{RuleMisplaced, "001", "00"},
+		{RuleMisplaced, "001.1", "001"},
+		{RuleOrphan, "030", "03"},
+		{RulePlaceParent, "(540)", "(54)"},
+		{RulePlaceParent, "(540.1)", "(540)"},
+	}
+
+	for _, tt := range tests {
+		issue := findIssue(issues, tt.rule, tt.code)
+		if issue == nil {
+			t.Errorf("Expected %s issue for %q", tt.rule, tt.code)
+			continue
+		}
+		if issue.Expected != tt.expected {
+			t.Errorf("%s issue for %q: expected parent %q, got %q", tt.rule, tt.code, tt.expected, issue.Expected)
+		}
+	}
+
+	if issue := findIssue(issues, RulePlaceParent, "(54)"); issue != nil {
+		t.Errorf("Did not expect place-parent issue for (54): %+v", issue)
+	}
+	if issue := findIssue(issues, RuleMisplaced, "0"); issue != nil {
+		t.Errorf("Did not expect misplaced issue for 0: %+v", issue)
+	}
+}
+
+func TestLintFile(t *testing.T) {
+	tempDir := t.TempDir()
+
+	udcContent := `
+- code: TOP
+  title: UDC Summary Root
+  children:
+    - code: "6"
+      title: Applied sciences
+      children:
+        - code: "62"
+          title: Engineering
+`
+	addendumContent := `
+- code: "62.LOCAL"
+  title: "Local engineering"
+- code: "62"
+  title: "Engineering override"
+- code: "999.1"
+  title: "Company classifications"
+`
+	if err := os.WriteFile(filepath.Join(tempDir, "udc_full.yaml"), []byte(udcContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "udc_addendum_local.yaml"), []byte(addendumContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	issues, err := LintFile(filepath.Join(tempDir, "udc_full.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if issue := findIssue(issues, RuleDuplicateCode, "62"); issue == nil {
+		t.Error("Expected duplicate-code issue for 62")
+	} else if issue.Source != "udc_addendum_local.yaml" {
+		t.Errorf("Expected duplicate to be reported against the addendum, got %q", issue.Source)
+	}
+	if issue := findIssue(issues, RuleOrphan, "999.1"); issue == nil {
+		t.Error("Expected orphan issue for 999.1")
+	}
+	if issue := findIssue(issues, RuleMisplaced, "62.LOCAL"); issue != nil {
+		t.Errorf("Did not expect addendum root 62.LOCAL to be misplaced: %+v", issue)
+	}
+}
@@ -6,6 +6,12 @@ import (
 	"strings"
 )
 
+// SplitComposite splits a composite expression such as 621.3:681.5(075)
+// into its component codes without checking that they exist
+func SplitComposite(code string) ([]string, error) {
+	return parseComposite(code)
+}
+
 // Split composite codes like 621.3:681.5(075)
 func parseComposite(code string) ([]string, error) {
 	code = strings.ReplaceAll(code, " ", "")
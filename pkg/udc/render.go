@@ -0,0 +1,259 @@
+package udc
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+)
+
+// RenderOptions controls how a subtree is rendered
+type RenderOptions struct {
+	// Depth limits how many levels below the root are drawn (0 for all)
+	Depth int
+	// Counts maps UDC codes to the number of tags classified directly under them.
+	// When set, each node shows the total for its whole subtree.
+	Counts map[string]int
+	// Title is used as the graph label or page title
+	Title string
+}
+
+// renderNode is a node prepared for rendering
+type renderNode struct {
+	ID       string
+	Code     string
+	Title    string
+	Notes    string
+	Source   string
+	Addendum bool
+	Count    int
+	Children []*renderNode
+}
+
+// prepareRender builds the render tree below a code
+func (c *Codec) prepareRender(code string, opts RenderOptions) (*renderNode, error) {
+	node, ok := c.flat[code]
+	if !ok {
+		return nil, fmt.Errorf("unknown code: %s", code)
+	}
+	next := 0
+	var build func(n *Node, depth int) *renderNode
+	build = func(n *Node, depth int) *renderNode {
+		r := &renderNode{
+			ID:       fmt.Sprintf("n%d", next),
+			Code:     n.Code,
+			Title:    n.Title,
+			Notes:    n.Notes,
+			Source:   c.sources[n.Code],
+			Addendum: c.IsAddendum(n.Code),
+			Count:    subtreeCount(n, opts.Counts),
+		}
+		next++
+		if opts.Depth == 0 || depth < opts.Depth {
+			for _, child := range n.Children {
+				r.Children = append(r.Children, build(child, depth+1))
+			}
+		}
+		return r
+	}
+	return build(node, 0), nil
+}
+
+// subtreeCount totals the tag counts of a node and all its descendants
+func subtreeCount(n *Node, counts map[string]int) int {
+	if counts == nil {
+		return 0
+	}
+	total := counts[n.Code]
+	for _, child := range n.Children {
+		total += subtreeCount(child, counts)
+	}
+	return total
+}
+
+// label returns the text shown for a node in diagrams
+func (r *renderNode) label(withCounts bool) string {
+	label := r.Code + "\n" + r.Title
+	if withCounts {
+		label += fmt.Sprintf("\n(%d tags)", r.Count)
+	}
+	return label
+}
+
+// RenderDOT writes a subtree as a Graphviz DOT digraph
+func (c *Codec) RenderDOT(w io.Writer, code string, opts RenderOptions) error {
+	root, err := c.prepareRender(code, opts)
+	if err != nil {
+		return err
+	}
+	title := opts.Title
+	if title == "" {
+		title = "UDC " + code
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph udc {\n")
+	fmt.Fprintf(&b, "  label=%s;\n", dotQuote(title))
+	fmt.Fprintf(&b, "  rankdir=LR;\n")
+	fmt.Fprintf(&b, "  node [shape=box, style=\"rounded\", fontname=\"Helvetica\"];\n")
+	var walk func(r *renderNode)
+	walk = func(r *renderNode) {
+		attrs := fmt.Sprintf("label=%s", dotQuote(r.label(opts.Counts != nil)))
+		if r.Addendum {
+			attrs += `, style="rounded,dashed,filled", fillcolor="#e6f4ea", color="#2e7d32"`
+		}
+		fmt.Fprintf(&b, "  %s [%s];\n", r.ID, attrs)
+		for _, child := range r.Children {
+			walk(child)
+			fmt.Fprintf(&b, "  %s -> %s;\n", r.ID, child.ID)
+		}
+	}
+	walk(root)
+	fmt.Fprintf(&b, "}\n")
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// dotQuote quotes a string for use as a DOT attribute value
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
+
+// RenderMermaid writes a subtree as a Mermaid flowchart
+func (c *Codec) RenderMermaid(w io.Writer, code string, opts RenderOptions) error {
+	root, err := c.prepareRender(code, opts)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	if opts.Title != "" {
+		fmt.Fprintf(&b, "---\ntitle: %s\n---\n", opts.Title)
+	}
+	fmt.Fprintf(&b, "flowchart LR\n")
+	fmt.Fprintf(&b, "  classDef addendum fill:#e6f4ea,stroke:#2e7d32,stroke-dasharray:5 5\n")
+	var walk func(r *renderNode)
+	walk = func(r *renderNode) {
+		fmt.Fprintf(&b, "  %s[%s]\n", r.ID, mermaidQuote(r.label(opts.Counts != nil)))
+		if r.Addendum {
+			fmt.Fprintf(&b, "  class %s addendum\n", r.ID)
+		}
+		for _, child := range r.Children {
+			walk(child)
+			fmt.Fprintf(&b, "  %s --> %s\n", r.ID, child.ID)
+		}
+	}
+	walk(root)
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// mermaidQuote quotes a node label, escaping characters Mermaid treats as syntax
+func mermaidQuote(s string) string {
+	s = strings.ReplaceAll(s, `"`, "#quot;")
+	s = strings.ReplaceAll(s, "\n", "<br/>")
+	return `"` + s + `"`
+}
+
+// RenderHTML writes a subtree as a self-contained HTML page with
+// collapsible navigation and a detail section for every node
+func (c *Codec) RenderHTML(w io.Writer, code string, opts RenderOptions) error {
+	root, err := c.prepareRender(code, opts)
+	if err != nil {
+		return err
+	}
+	title := opts.Title
+	if title == "" {
+		title = "UDC " + code + " " + root.Title
+	}
+
+	var nodes []*renderNode
+	var walk func(r *renderNode)
+	walk = func(r *renderNode) {
+		nodes = append(nodes, r)
+		for _, child := range r.Children {
+			walk(child)
+		}
+	}
+	walk(root)
+
+	return htmlTemplate.Execute(w, map[string]any{
+		"Title":      title,
+		"Root":       root,
+		"Nodes":      nodes,
+		"WithCounts": opts.Counts != nil,
+	})
+}
+
+var htmlTemplate = template.Must(template.New("udc").Funcs(template.FuncMap{
+	"dict": func(pairs ...any) map[string]any {
+		m := make(map[string]any)
+		for i := 0; i+1 < len(pairs); i += 2 {
+			m[pairs[i].(string)] = pairs[i+1]
+		}
+		return m
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{ .Title }}</title>
+<style>
+  body { margin: 0; font-family: Helvetica, Arial, sans-serif; display: flex; height: 100vh; }
+  nav { width: 35%; overflow: auto; border-right: 1px solid #ccc; padding: 1em; box-sizing: border-box; }
+  main { flex: 1; overflow: auto; padding: 1em 2em; }
+  nav ul { list-style: none; padding-left: 1.2em; margin: 0; }
+  nav > ul { padding-left: 0; }
+  summary { cursor: pointer; }
+  a { color: #1a4f8b; text-decoration: none; }
+  a:hover { text-decoration: underline; }
+  .code { font-family: monospace; font-weight: bold; }
+  .addendum { color: #2e7d32; }
+  .badge { font-size: 0.8em; background: #eee; border-radius: 0.8em; padding: 0 0.5em; }
+  .addendum .badge.local { background: #e6f4ea; }
+  section { border-bottom: 1px solid #eee; padding: 0.5em 0; }
+  section:target { background: #fff8dc; }
+</style>
+</head>
+<body>
+<nav>
+<h2>{{ .Title }}</h2>
+<ul>{{ template "nav" dict "Node" .Root "WithCounts" .WithCounts "Open" true }}</ul>
+</nav>
+<main>
+{{- range .Nodes }}
+<section id="{{ .ID }}"{{ if .Addendum }} class="addendum"{{ end }}>
+  <h3><span class="code">{{ .Code }}</span> {{ .Title }}{{ if .Addendum }} <span class="badge local">local addendum</span>{{ end }}</h3>
+  {{- if .Notes }}
+  <p>{{ .Notes }}</p>
+  {{- end }}
+  <p>
+    {{- if .Source }}Source: {{ .Source }}{{ end }}
+    {{- if $.WithCounts }} · Tags: {{ .Count }}{{ end }}
+  </p>
+  {{- if .Children }}
+  <ul>
+    {{- range .Children }}
+    <li><a href="#{{ .ID }}"><span class="code">{{ .Code }}</span> {{ .Title }}</a></li>
+    {{- end }}
+  </ul>
+  {{- end }}
+</section>
+{{- end }}
+</main>
+</body>
+</html>
+{{ define "nav" }}{{ $n := .Node }}<li{{ if $n.Addendum }} class="addendum"{{ end }}>
+{{- if $n.Children }}<details{{ if .Open }} open{{ end }}><summary>{{ template "navlink" . }}</summary><ul>
+{{- range $n.Children }}{{ template "nav" dict "Node" . "WithCounts" $.WithCounts "Open" false }}{{ end }}
+</ul></details>
+{{- else }}{{ template "navlink" . }}{{ end }}</li>
+{{ end }}
+{{ define "navlink" }}<a href="#{{ .Node.ID }}"><span class="code">{{ .Node.Code }}</span> {{ .Node.Title }}</a>
+{{- if .WithCounts }} <span class="badge">{{ .Node.Count }}</span>{{ end }}{{ end }}
+`))
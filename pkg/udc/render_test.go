@@ -0,0 +1,116 @@
+package udc
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func loadRenderCodec(t *testing.T) *Codec {
+	tempDir := t.TempDir()
+
+	udcContent := `
+- code: "62"
+  title: "Engineering"
+  children:
+    - code: "621"
+      title: "Mechanical engineering"
+      children:
+        - code: "621.6"
+          title: "Fluids handling"
+`
+	addendumContent := `
+- code: "621.LOCAL"
+  title: "Plant \"special\" machinery"
+`
+	if err := os.WriteFile(filepath.Join(tempDir, "udc_full.yaml"), []byte(udcContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "udc_addendum_local.yaml"), []byte(addendumContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	codec, err := LoadCodec(filepath.Join(tempDir, "udc_full.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Attach the addendum root so it is part of the rendered subtree
+	parent, _ := codec.Node("621")
+	local, _ := codec.Node("621.LOCAL")
+	parent.Children = append(parent.Children, local)
+	return codec
+}
+
+func TestRenderDOT(t *testing.T) {
+	codec := loadRenderCodec(t)
+
+	var buf bytes.Buffer
+	err := codec.RenderDOT(&buf, "62", RenderOptions{Counts: map[string]int{"621.6": 3, "621": 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	if !strings.HasPrefix(out, "digraph udc {") {
+		t.Errorf("Expected a digraph, got:\n%s", out)
+	}
+	if !strings.Contains(out, `label="62\nEngineering\n(4 tags)"`) {
+		t.Errorf("Expected rolled-up tag count on root, got:\n%s", out)
+	}
+	if !strings.Contains(out, `Plant \"special\" machinery`) {
+		t.Errorf("Expected quotes to be escaped, got:\n%s", out)
+	}
+	if !strings.Contains(out, "dashed") {
+		t.Errorf("Expected addendum node to be marked, got:\n%s", out)
+	}
+	if !strings.Contains(out, "n0 -> n1;") {
+		t.Errorf("Expected an edge from the root, got:\n%s", out)
+	}
+}
+
+func TestRenderMermaidDepth(t *testing.T) {
+	codec := loadRenderCodec(t)
+
+	var buf bytes.Buffer
+	if err := codec.RenderMermaid(&buf, "62", RenderOptions{Depth: 1}); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	if !strings.Contains(out, "flowchart LR") {
+		t.Errorf("Expected a flowchart, got:\n%s", out)
+	}
+	if !strings.Contains(out, "Mechanical engineering") {
+		t.Errorf("Expected first level to be rendered, got:\n%s", out)
+	}
+	if strings.Contains(out, "Fluids handling") {
+		t.Errorf("Expected depth limit to exclude second level, got:\n%s", out)
+	}
+}
+
+func TestRenderHTML(t *testing.T) {
+	codec := loadRenderCodec(t)
+
+	var buf bytes.Buffer
+	if err := codec.RenderHTML(&buf, "621", RenderOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	if !strings.Contains(out, "<details open>") {
+		t.Error("Expected collapsible navigation")
+	}
+	if !strings.Contains(out, "local addendum") {
+		t.Error("Expected addendum node to be marked")
+	}
+	if !strings.Contains(out, "Plant &#34;special&#34; machinery") {
+		t.Error("Expected titles to be HTML-escaped")
+	}
+
+	if err := codec.RenderHTML(&buf, "NONEXISTENT", RenderOptions{}); err == nil {
+		t.Error("Expected error for unknown code")
+	}
+}
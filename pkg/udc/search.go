@@ -1,16 +1,26 @@
 package udc
 
 import (
+	"sort"
 	"strings"
 )
 
-func search(flat map[string]*Node, term string) []*Node {
-	term = strings.ToLower(term)
+func search(flat map[string]*Node, terms ...string) []*Node {
+	for i, term := range terms {
+		terms[i] = strings.ToLower(term)
+	}
 	var results []*Node
 	for _, node := range flat {
-		if strings.Contains(strings.ToLower(node.Title), term) {
-			results = append(results, node)
+		title := strings.ToLower(node.Title)
+		for _, term := range terms {
+			if strings.Contains(title, term) {
+				results = append(results, node)
+				break
+			}
 		}
 	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Code < results[j].Code
+	})
 	return results
 }
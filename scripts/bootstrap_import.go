@@ -5,12 +5,9 @@ import (
 	"fmt"
 	"os"
 
-	"gopkg.in/yaml.v3"
-
 	"github.com/thornzero/udc_codec/pkg/assettag"
 	"github.com/thornzero/udc_codec/pkg/config"
 	"github.com/thornzero/udc_codec/pkg/db"
-	"github.com/thornzero/udc_codec/pkg/udc"
 )
 
 func openFile(filename string) (*os.File, error) {
@@ -18,62 +15,15 @@ func openFile(filename string) (*os.File, error) {
 	return os.Open(cfg.Path(filename))
 }
 
-// YAML structure loaders
-
-func loadISA(filename string) (map[string]string, error) {
-	f, err := openFile(filename)
-	if err != nil {
-		return nil, err
-	}
-	defer f.Close()
-	var data map[string]string
-	decoder := yaml.NewDecoder(f)
-	if err := decoder.Decode(&data); err != nil {
-		return nil, err
-	}
-	return data, nil
-}
-
-func loadIEC81346(filename string) (map[string]string, error) {
-	f, err := openFile(filename)
-	if err != nil {
-		return nil, err
-	}
-	defer f.Close()
-
-	var data map[string]string
-	decoder := yaml.NewDecoder(f)
-	if err := decoder.Decode(&data); err != nil {
-		return nil, err
-	}
-	return data, nil
-}
-
 func main() {
-	// Load YAML data
-	codec, err := udc.LoadCodec("data/udc_full.yaml")
-	if err != nil {
-		panic(err)
-	}
-
-	isa, err := loadISA("data/isa_prefix.yaml")
+	// Load and check the reference data
+	resolver, err := assettag.NewResolver(config.Load())
 	if err != nil {
 		panic(err)
 	}
 
-	systems, err := loadIEC81346("data/81346_systems.yaml")
-	if err != nil {
-		panic(err)
-	}
-
-	resolver := &assettag.Resolver{
-		UDC:      codec,
-		ISA:      isa,
-		IEC81346: systems,
-	}
-
 	// Open DB
-	store, err := db.OpenDB("tags.db")
+	store, err := db.OpenDB(config.Load().DBPath)
 	if err != nil {
 		panic(err)
 	}
@@ -82,7 +32,7 @@ func main() {
 	}
 
 	// Load initial asset tags (you supply this file)
-	f, err := openFile("data/asset_tags.json")
+	f, err := openFile(assettag.TagListFile)
 	if err != nil {
 		panic(err)
 	}